@@ -1,15 +1,15 @@
 package cli
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/soden46/hyperlux-chain/consensus"
@@ -34,8 +34,18 @@ func RunCLI() {
 	// ================= LEDGER & NODE =================
 	case "init":
 		handleInit()
+	case "genesis-export":
+		handleGenesisExport()
 	case "start":
 		handleStart()
+	case "snapshot-create":
+		handleSnapshotCreate()
+	case "snapshot-list":
+		handleSnapshotList()
+	case "snapshot-restore":
+		handleSnapshotRestore()
+	case "prune":
+		handlePrune()
 
 	// ================= TRANSAKSI =================
 	case "tx":
@@ -46,6 +56,20 @@ func RunCLI() {
 		handleTxBulkRandomParallel()
 	case "tx-bulk-multi":
 		handleTxBulkMulti()
+	case "tx-show":
+		handleTxShow()
+	case "account-history":
+		handleAccountHistory()
+	case "balance-at":
+		handleBalanceAt()
+	case "validators-at":
+		handleValidatorsAt()
+	case "block-signers":
+		handleBlockSigners()
+	case "poh-verify":
+		handlePoHVerify()
+	case "epoch-info":
+		handleEpochInfo()
 
 	// ================= PENGUJIAN & OTOMASI =================
 	case "stress-test":
@@ -68,6 +92,8 @@ func RunCLI() {
 		handleFixValidators()
 	case "full-test":
 		handleFullTest()
+	case "migrate-address":
+		handleMigrateAddress()
 
 	// ================= VALIDATOR SECURITY (baru) =================
 	case "validator-status":
@@ -89,9 +115,21 @@ func RunCLI() {
 func printUsage() {
 	fmt.Println("Usage: hyperlux -[command] [arguments]")
 	fmt.Println("Commands:")
-	fmt.Println(" - init                   - Inisialisasi ledger baru")
+	fmt.Println(" - init [--genesis <file>] - Inisialisasi ledger baru (genesis.json = hash genesis sama di semua node)")
+	fmt.Println(" - genesis-export [file] [--from-state] - Tulis genesis.json (asli, atau state saat ini)")
 	fmt.Println(" - start                  - Memulai node dan sinkronisasi (consensus auto-producer aktif)")
-	fmt.Println(" - tx send <to> <amount> <walletfile>")
+	fmt.Println(" - snapshot-create        - Snapshot state di head (otomatis tiap HYPERLUX_SNAPSHOT_INTERVAL blok)")
+	fmt.Println(" - snapshot-list          - Daftar snapshot tersimpan")
+	fmt.Println(" - snapshot-restore <file|height> [--trust-hash <blockhash>] [--genesis <file>] [--force] - Bootstrap node dari snapshot")
+	fmt.Println(" - prune [depth]          - Hapus body TX & versi state lama (background: HYPERLUX_PRUNE=1)")
+	fmt.Println(" - tx send <to> <amount> <walletfile> [memo] [--fee <fee>] [--nonce <nonce>]")
+	fmt.Println(" - tx-show <hash>          - Status TX: blok, index, fee, alasan gagal")
+	fmt.Println(" - account-history <addr> [--sent|--received] [--page N] [--limit N]")
+	fmt.Println(" - balance-at <addr> <height> - Balance & nonce setelah block <height>")
+	fmt.Println(" - validators-at <height>  - Stake validator setelah block <height>")
+	fmt.Println(" - block-signers <height>  - Sertifikat commit: validator yang menandatangani block <height>")
+	fmt.Println(" - poh-verify <height>     - Verifikasi ulang segmen Proof of History block <height> (paralel)")
+	fmt.Println(" - epoch-info [epoch]      - Active set & jadwal leader epoch (default: epoch berjalan)")
 	fmt.Println(" - tx-bulk <count> <to> <walletfile>")
 	fmt.Println(" - tx-bulk-random-parallel <count> <walletfile> <workers>")
 	fmt.Println(" - tx-bulk-multi <walletCount> <perWallet>")
@@ -101,14 +139,15 @@ func printUsage() {
 	fmt.Println(" - wallet-bulk <count>")
 	fmt.Println(" - metrics                - Menampilkan metrik blockchain")
 	fmt.Println(" - commit                 - Memaksa commit block sekali")
-	fmt.Println(" - airdrop <amount> <folder>")
+	fmt.Println(" - airdrop <amount> <folder> - Mint saldo dev (hanya sebelum init)")
 	fmt.Println(" - fix-validators         - Memperbaiki data validator")
 	fmt.Println(" - full-test <walletCount> <perWallet> <intervalSeconds>")
+	fmt.Println(" - migrate-address <walletfile> - Kirim TX migrasi saldo & validator address lama (hlcEd...) ke format baru")
 	fmt.Println("")
 	fmt.Println("Validator & Security:")
 	fmt.Println(" - validator-status <address>")
 	fmt.Println(" - suspend <address> <scope:propose|vote|all> <duration:e.g. 15m,2h,24h>")
-	fmt.Println(" - slash <address> <amount> [reporterAddress]")
+	fmt.Println(" - slash <address> <amount> [reporterAddress] - Slash manual (hanya sebelum init)")
 	fmt.Println(" - show-econ              - Tampilkan treasury, burned, total stake, dsb.")
 }
 
@@ -120,22 +159,168 @@ func ensureValidatorsReady() {
 
 // ================= HANDLERS UNTUK SETIAP PERINTAH =================
 func handleInit() {
+	for i := 2; i < len(os.Args); i++ {
+		if os.Args[i] == "--genesis" {
+			if i+1 >= len(os.Args) {
+				fmt.Println("Usage: hyperlux -init [--genesis <file>]")
+				return
+			}
+			if err := ledger.InitLedgerWithGenesis(os.Args[i+1]); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+	}
 	ledger.InitLedger()
 }
 
+func handleGenesisExport() {
+	out, fromState := "", false
+	for _, a := range os.Args[2:] {
+		if a == "--from-state" {
+			fromState = true
+		} else {
+			out = a
+		}
+	}
+	data, err := ledger.ExportGenesis(fromState)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if out == "" {
+		fmt.Println(string(data))
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		log.Fatal("❌ Gagal tulis genesis:", err)
+	}
+	fmt.Println("✅ Genesis ditulis ke", out)
+}
+
 func handleStart() {
 	network.InitNetwork()
 	consensus.InitConsensus() // ini juga akan AutoLoad validator wallets & start block producer
 	fmt.Println("✅ Node is running...")
+
+	// producer & network jalan di goroutine: tahan proses sampai dihentikan
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	fmt.Println("🛑 Node berhenti, menyimpan mempool & state...")
+	if err := ledger.SaveAllData(); err != nil {
+		log.Println("⚠️", err)
+	}
+}
+
+func handleSnapshotCreate() {
+	info, err := ledger.CreateSnapshot()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("   Block     : #%d %s\n", info.Height, info.BlockHash)
+	fmt.Printf("   StateRoot : %s\n", info.StateRoot)
+	fmt.Printf("   Accounts  : %d (%d bytes)\n", info.Accounts, info.Size)
+}
+
+func handleSnapshotList() {
+	list, err := ledger.ListSnapshots()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	fmt.Printf("📸 Snapshots di %s (interval=%d, keep=%d)\n", ledger.Snapshots.Dir, ledger.Snapshots.Interval, ledger.Snapshots.Keep)
+	if len(list) == 0 {
+		fmt.Println("   (kosong)")
+	}
+	for _, s := range list {
+		fmt.Printf("   #%-8d %.16s  root=%.16s  %d akun  %d bytes  %s\n",
+			s.Height, s.BlockHash, s.StateRoot, s.Accounts, s.Size, time.Unix(s.CreatedAt, 0).Format(time.RFC3339))
+	}
+}
+
+func handleSnapshotRestore() {
+	usage := "Usage: hyperlux -snapshot-restore <file|height> [--trust-hash <blockhash>] [--genesis <file>] [--force]"
+	if len(os.Args) < 3 {
+		fmt.Println(usage)
+		return
+	}
+	path, force := os.Args[2], false
+	var trust ledger.SnapshotTrust
+	genesisFile := ""
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--force":
+			force = true
+		case "--trust-hash", "--genesis":
+			if i+1 >= len(os.Args) {
+				fmt.Println(usage)
+				return
+			}
+			if os.Args[i] == "--genesis" {
+				genesisFile = os.Args[i+1]
+			} else {
+				trust.BlockHash = os.Args[i+1]
+			}
+			i++
+		}
+	}
+	// genesis tepercaya: file yang dikonfigurasi, atau genesis chain lokal
+	if genesisFile != "" {
+		g, err := ledger.LoadGenesisFile(genesisFile)
+		if err != nil {
+			log.Fatalf("❌ genesis %s: %v", genesisFile, err)
+		}
+		trust.GenesisHash = g.Hash()
+	} else if h, ok := ledger.LocalGenesisHash(); ok {
+		trust.GenesisHash = h
+	} else {
+		log.Fatal("❌ node belum punya genesis: pakai --genesis <file>")
+	}
+	if h, err := strconv.Atoi(path); err == nil {
+		path = ledger.SnapshotPath(h)
+	}
+	snap, err := ledger.LoadSnapshot(path)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	// tanpa --trust-hash, sertifikat commit blok dicek terhadap set epoch lokal
+	if err := ledger.RestoreSnapshot(snap, trust, force); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("   Chain ID  : %s\n", snap.ChainID)
+	fmt.Printf("   Head      : #%d %s\n", snap.Height, snap.Block.Hash)
+	fmt.Println("   Blok berikutnya diimpor dari peer setelah node di-start.")
+}
+
+func handlePrune() {
+	if len(os.Args) > 2 {
+		d, err := strconv.Atoi(os.Args[2])
+		if err != nil || d < 1 {
+			log.Fatal("❌ depth tidak valid:", os.Args[2])
+		}
+		ledger.Pruning.Depth = d
+	}
+	res, err := ledger.PruneNow()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("✂️  Prune depth=%d: %d blok, %d versi state, %d bytes reclaimed\n",
+		ledger.Pruning.Depth, res.Blocks, res.Versions, res.Bytes)
+	if to := ledger.BodiesPrunedTo(); to >= 0 {
+		fmt.Printf("   Body TX tersimpan mulai block #%d\n", to+1)
+	}
 }
 
 func handleTx() {
 	if len(os.Args) < 6 || os.Args[2] != "send" {
-		fmt.Println("Usage: hyperlux -tx send <to> <amount> <walletfile>")
+		fmt.Println("Usage: hyperlux -tx send <to> <amount> <walletfile> [memo] [--fee <fee>] [--nonce <nonce>]")
 		return
 	}
 	to := os.Args[3]
-	amount, err := strconv.Atoi(os.Args[4])
+	if err := wallet.ValidateAddress(to); err != nil {
+		log.Fatalf("❌ Address tujuan tidak valid (%s): %v", to, err)
+	}
+	amount, err := ledger.ParsePositiveAmount(os.Args[4])
 	if err != nil {
 		log.Fatal("❌ Jumlah transaksi tidak valid:", err)
 	}
@@ -146,14 +331,263 @@ func handleTx() {
 		log.Fatal("❌ Gagal load wallet:", err)
 	}
 
-	tx := ledger.NewTransaction(w, to, amount)
+	// sisa argumen: [memo] [--fee N] [--nonce N]; --nonce + fee lebih tinggi = replace-by-fee
+	opts := ledger.TxOptions{}
+	for i := 6; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--fee", "--nonce":
+			if i+1 >= len(os.Args) {
+				log.Fatalf("❌ %s butuh nilai", os.Args[i])
+			}
+			if os.Args[i] == "--fee" {
+				if opts.Fee, err = ledger.ParseAmount(os.Args[i+1]); err != nil {
+					log.Fatal("❌ Fee tidak valid:", err)
+				}
+			} else if opts.Nonce, err = strconv.Atoi(os.Args[i+1]); err != nil || opts.Nonce <= 0 {
+				log.Fatal("❌ Nonce tidak valid:", os.Args[i+1])
+			}
+			i++
+		default:
+			opts.Memo = os.Args[i]
+		}
+	}
+
+	tx := ledger.NewTransactionWithOpts(w, to, amount, opts)
 	if err := ledger.ValidateAndAddToMempool(tx); err != nil {
 		log.Fatal("❌", err)
 	}
+	ledger.SaveMempool()
 
 	hash := ledger.HashTransaction(tx)
 	fmt.Println("✅ TX berhasil dikirim")
 	fmt.Println("TX Hash:", hash)
+	fmt.Println("Cek status: hyperlux tx-show", hash)
+}
+
+func handleTxShow() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -tx-show <hash>")
+		return
+	}
+	hash := strings.ToLower(os.Args[2])
+
+	r, ok := ledger.GetReceipt(hash)
+	if !ok {
+		if tx, pending := ledger.PendingTx(hash); pending {
+			fmt.Printf("⏳ TX %s masih pending di mempool (from=%s nonce=%d)\n", hash, tx.From, tx.Nonce)
+			return
+		}
+		fmt.Printf("❌ TX %s tidak ditemukan\n", hash)
+		return
+	}
+
+	if r.Status == ledger.ReceiptSuccess {
+		fmt.Printf("✅ TX %s\n", r.TxHash)
+		fmt.Printf("   Status  : %s\n", r.Status)
+		fmt.Printf("   Block   : #%d (hash=%.12s...) index=%d\n", r.Height, r.BlockHash, r.Index)
+	} else {
+		fmt.Printf("❌ TX %s\n", r.TxHash)
+		fmt.Printf("   Status  : %s (tidak masuk blok)\n", r.Status)
+		fmt.Printf("   Dicoba  : block #%d\n", r.Height)
+		fmt.Printf("   Alasan  : %s\n", r.Error)
+	}
+	if r.Pruned {
+		fmt.Printf("   Fee     : %d dibayar\n", r.FeePaid)
+		fmt.Println("   Body    : sudah dipangkas (prune mode), detail TX tidak tersedia")
+		fmt.Printf("   Time    : %s\n", time.Unix(r.Timestamp, 0).UTC().Format(time.RFC3339))
+		return
+	}
+	fmt.Printf("   From    : %s (nonce=%d)\n", r.Tx.From, r.Tx.Nonce)
+	fmt.Printf("   To      : %s\n", r.Tx.To)
+	fmt.Printf("   Amount  : %d\n", r.Tx.Amount)
+	fmt.Printf("   Fee     : %d dibayar (fee tx=%d)\n", r.FeePaid, r.Tx.Fee)
+	if r.Tx.Memo != "" {
+		fmt.Printf("   Memo    : %s\n", r.Tx.Memo)
+	}
+	fmt.Printf("   Time    : %s\n", time.Unix(r.Timestamp, 0).UTC().Format(time.RFC3339))
+}
+
+func handleAccountHistory() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -account-history <addr> [--sent|--received] [--page N] [--limit N]")
+		return
+	}
+	addr := os.Args[2]
+	if err := wallet.ValidateAddress(addr); err != nil {
+		log.Fatalf("❌ Address tidak valid (%s): %v", addr, err)
+	}
+
+	dir, page, limit := ledger.DirAll, 1, 20
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--sent":
+			dir = ledger.DirSent
+		case "--received":
+			dir = ledger.DirReceived
+		case "--page", "--limit":
+			if i+1 >= len(os.Args) {
+				log.Fatalf("❌ %s butuh nilai", os.Args[i])
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n <= 0 {
+				log.Fatalf("❌ %s tidak valid: %s", os.Args[i], os.Args[i+1])
+			}
+			if os.Args[i] == "--page" {
+				page = n
+			} else {
+				limit = n
+			}
+			i++
+		default:
+			log.Fatalf("❌ argumen tidak dikenal: %s", os.Args[i])
+		}
+	}
+
+	entries, more := ledger.AccountHistory(addr, dir, (page-1)*limit, limit)
+	fmt.Printf("📜 History %s (page %d, %d per page)\n", addr, page, limit)
+	if len(entries) == 0 {
+		fmt.Println("   (kosong)")
+		return
+	}
+	for _, e := range entries {
+		line := fmt.Sprintf("   #%-6d %-8s %-7s %s", e.Height, e.Direction, e.Status, e.TxHash)
+		if r, ok := ledger.GetReceipt(e.TxHash); ok {
+			peer := r.Tx.To
+			if e.Direction == ledger.DirReceived {
+				peer = r.Tx.From
+			}
+			line += fmt.Sprintf("  amount=%d fee=%d peer=%s", r.Tx.Amount, r.FeePaid, peer)
+		}
+		fmt.Println(line)
+	}
+	if more {
+		fmt.Printf("   ... berikutnya: --page %d\n", page+1)
+	}
+}
+
+func parseHeightArg(s string) int {
+	h, err := strconv.Atoi(s)
+	if err != nil || h < 0 {
+		log.Fatal("❌ height tidak valid:", s)
+	}
+	return h
+}
+
+func handleBalanceAt() {
+	if len(os.Args) < 4 {
+		fmt.Println("Usage: hyperlux -balance-at <addr> <height>")
+		return
+	}
+	addr, height := os.Args[2], parseHeightArg(os.Args[3])
+	bal, nonce, err := ledger.GetAccountAt(addr, height)
+	if err != nil {
+		log.Fatalf("❌ %v (archive=%v, retention=%d blok)", err, ledger.History.Archive, ledger.History.Retention)
+	}
+	fmt.Printf("💰 %s @ block #%d\n", addr, height)
+	fmt.Printf("   Balance : %d\n", bal)
+	fmt.Printf("   Nonce   : %d\n", nonce)
+}
+
+func handleValidatorsAt() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -validators-at <height>")
+		return
+	}
+	height := parseHeightArg(os.Args[2])
+	vals, err := ledger.GetValidatorsAt(height)
+	if err != nil {
+		log.Fatalf("❌ %v (archive=%v, retention=%d blok)", err, ledger.History.Archive, ledger.History.Retention)
+	}
+	var total ledger.Amount
+	fmt.Printf("🏛  Validators @ block #%d\n", height)
+	for _, v := range vals {
+		fmt.Printf("   %s  stake=%d\n", v.Address, v.Stake)
+		total, _ = total.Add(v.Stake)
+	}
+	fmt.Printf("   Total stake: %d (%d validators)\n", total, len(vals))
+}
+
+func handleBlockSigners() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -block-signers <height>")
+		return
+	}
+	height := parseHeightArg(os.Args[2])
+	b, ok := ledger.GetBlockByHeight(height)
+	if !ok {
+		log.Fatalf("❌ block #%d tidak ditemukan", height)
+	}
+	fmt.Printf("🧾 Block #%d (hash=%.12s...) proposer=%s\n", b.Index, b.Hash, b.Proposer)
+	if b.VRF != nil {
+		fmt.Printf("   VRF     : round %d, seed %.12s..., output %.16x...\n", b.VRF.Round, b.VRF.Seed, ledger.BlockVRFOutput(b))
+	}
+	c := b.Commit
+	if c == nil {
+		fmt.Println("   Tidak ada sertifikat commit (genesis / blok sebelum sertifikat commit)")
+		return
+	}
+	fmt.Printf("   Round   : %d\n", c.Round)
+	for _, sig := range c.Sigs {
+		fmt.Printf("   ✍️  %s  stake=%d\n", sig.Validator, sig.Stake)
+	}
+	fmt.Printf("   Stake   : %d/%d (%.2f%%) dari %d signature\n",
+		c.Power, c.Total, float64(c.Power)/float64(max(c.Total, 1))*100, len(c.Sigs))
+	if err := ledger.VerifyStoredCommit(height); err != nil {
+		fmt.Printf("   Verifikasi: ❌ %v\n", err)
+		return
+	}
+	fmt.Println("   Verifikasi: ✅ > 2/3 stake, semua signature valid")
+}
+
+func handleEpochInfo() {
+	set := ledger.CurrentEpochSet()
+	published := true
+	if len(os.Args) >= 3 {
+		epoch, err := strconv.Atoi(os.Args[2])
+		if err != nil || epoch < 0 {
+			log.Fatalf("❌ epoch tidak valid: %q", os.Args[2])
+		}
+		var ok bool
+		if set, ok = ledger.GetEpochSet(epoch); !ok {
+			log.Fatalf("❌ set epoch %d belum dipublikasikan", epoch)
+		}
+	} else {
+		_, published = ledger.GetEpochSet(set.Epoch)
+	}
+	fmt.Printf("📅 Epoch %d: block #%d–#%d (%d blok/epoch, max %d validator aktif)\n",
+		set.Epoch, set.Start, set.End(), ledger.Params.EpochLength, ledger.Params.MaxActiveValidators)
+	if !published {
+		fmt.Println("   ⚠️ belum dipublikasikan (dihitung dari stake saat ini)")
+	}
+	fmt.Printf("   Seed VRF : %s\n", set.Seed)
+	fmt.Printf("   Active set: %d validator, stake %d\n", len(set.Validators), set.TotalStake)
+	blocks := set.End() - set.Start + 1
+	for _, v := range set.Validators {
+		share := set.Share(v.Address)
+		fmt.Printf("   %s  stake=%-10d leader round 0: %5.1f%%  (≈%.1f blok)\n", v.Address, v.Stake, share*100, share*float64(blocks))
+	}
+	for _, addr := range set.Jailed {
+		fmt.Printf("   ⛔ %s  di-jail (tidak aktif epoch ini)\n", addr)
+	}
+}
+
+func handlePoHVerify() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -poh-verify <height>")
+		return
+	}
+	height := parseHeightArg(os.Args[2])
+	start := time.Now()
+	rec, err := ledger.VerifyStoredPoH(height)
+	if rec != nil {
+		fmt.Printf("⏱️ Block #%d PoH: %d ticks, %d hashes, %d entries → %.12s...\n",
+			height, rec.Ticks, rec.Hashes, len(rec.Entries), rec.Hash)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("   Verifikasi: ✅ rantai hash valid (%d worker, %s)\n", ledger.PoH.VerifyWorkers, time.Since(start).Round(time.Microsecond))
 }
 
 func handleTxBulk() {
@@ -164,6 +598,9 @@ func handleTxBulk() {
 	count, _ := strconv.Atoi(os.Args[2])
 	to := os.Args[3]
 	walletFile := os.Args[4]
+	if err := wallet.ValidateAddress(to); err != nil {
+		log.Fatalf("❌ Address tujuan tidak valid (%s): %v", to, err)
+	}
 
 	w, err := wallet.LoadWallet(walletFile)
 	if err != nil {
@@ -210,9 +647,7 @@ func handleTxBulkRandomParallel() {
 		go func() {
 			defer wg.Done()
 			for range jobs {
-				rnd := make([]byte, 4)
-				rand.Read(rnd)
-				toAddr := "hlcRnd" + hex.EncodeToString(rnd)
+				toAddr := wallet.RandomAddress()
 				tx := ledger.NewTransaction(w, toAddr, 1)
 				_ = ledger.ValidateAndAddToMempool(tx)
 			}
@@ -244,9 +679,7 @@ func handleTxBulkMulti() {
 			defer wg.Done()
 			w, _ := wallet.LoadWallet("bulk-wallets/" + files[id].Name())
 			for j := 0; j < perWallet; j++ {
-				rnd := make([]byte, 4)
-				rand.Read(rnd)
-				toAddr := "hlcRnd" + hex.EncodeToString(rnd)
+				toAddr := wallet.RandomAddress()
 				tx := ledger.NewTransaction(w, toAddr, 1)
 				_ = ledger.ValidateAndAddToMempool(tx)
 			}
@@ -308,9 +741,7 @@ func handleStressTest() {
 						continue
 					}
 					for j := 0; j < perWallet; j++ {
-						rnd := make([]byte, 4)
-						rand.Read(rnd)
-						toAddr := "hlcRnd" + hex.EncodeToString(rnd)
+						toAddr := wallet.RandomAddress()
 						tx := ledger.NewTransaction(w, toAddr, 1)
 						_ = ledger.ValidateAndAddToMempool(tx)
 					}
@@ -406,9 +837,7 @@ func handleStressAndCommit() {
 						continue
 					}
 					for j := 0; j < perWallet; j++ {
-						rnd := make([]byte, 4)
-						rand.Read(rnd)
-						toAddr := "hlcRnd" + hex.EncodeToString(rnd)
+						toAddr := wallet.RandomAddress()
 						tx := ledger.NewTransaction(w, toAddr, 1)
 						_ = ledger.ValidateAndAddToMempool(tx)
 					}
@@ -439,20 +868,20 @@ func handleWalletBulk() {
 }
 
 func handleMetrics() {
-	if len(ledger.Blockchain) == 0 {
+	height := ledger.ChainHeight()
+	last, ok := ledger.HeadBlock()
+	if !ok {
 		fmt.Println("⚠️ No blocks found")
 		return
 	}
 
-	height := len(ledger.Blockchain)
-	last := ledger.Blockchain[height-1]
 	fmt.Printf("⛓  Chain Height : %d\n", height)
 	fmt.Printf("🧱 Last Block    : #%d  (hash=%.12s...)\n", last.Index, last.Hash)
 	fmt.Printf("   TX in Block   : %d\n", len(last.Transactions))
 
 	// Block time: selisih dua blok terakhir
 	if height >= 2 {
-		prev := ledger.Blockchain[height-2]
+		prev, _ := ledger.GetBlockByHeight(height - 2)
 		dt := last.Timestamp - prev.Timestamp
 		if dt <= 0 {
 			dt = 1
@@ -472,8 +901,8 @@ func handleMetrics() {
 	if window > 0 {
 		start := height - 1 - window
 		for i := start + 1; i < height; i++ {
-			b := ledger.Blockchain[i]
-			p := ledger.Blockchain[i-1]
+			b, _ := ledger.GetBlockByHeight(i)
+			p, _ := ledger.GetBlockByHeight(i - 1)
 			sumTx += len(b.Transactions)
 			d := b.Timestamp - p.Timestamp
 			if d > 0 {
@@ -489,7 +918,25 @@ func handleMetrics() {
 
 	// Mempool + runtime profiling singkat
 	mp := ledger.GetMempoolSize()
-	fmt.Printf("🧺 Mempool Size : %d\n", mp)
+	pending, queued := ledger.Pool.Stats()
+	fmt.Printf("🧺 Mempool Size : %d (pending=%d, queued=%d, max=%d)\n", mp, pending, queued, ledger.Pool.Config().MaxSize)
+
+	if last.PoH != nil {
+		fmt.Printf("⏳ PoH (head)    : %d ticks, %d hashes (%d hash/tick) → %.12s...\n",
+			last.PoH.Ticks, last.PoH.Hashes, ledger.Params.PoHHashesPerTick, last.PoH.Hash)
+	}
+
+	sv := ledger.SigStats()
+	fmt.Printf("🔏 Sig Cache     : %d/%d entries, hit=%d miss=%d (%.1f%%), evicted=%d\n",
+		sv.CacheSize, sv.CacheCap, sv.CacheHits, sv.CacheMisses, sv.HitRate()*100, sv.CacheEvictions)
+	fmt.Printf("🔏 Sig Verify    : batches=%d (%d sigs, fallback=%d), single=%d\n",
+		sv.Batches, sv.BatchedSigs, sv.BatchFallbacks, sv.SingleVerifies)
+
+	if to := ledger.BodiesPrunedTo(); ledger.Pruning.Enabled || to >= 0 {
+		from, _ := ledger.HistoryRange()
+		fmt.Printf("✂️  Pruning       : enabled=%v depth=%d, body pruned ≤ #%d, state history dari #%d\n",
+			ledger.Pruning.Enabled, ledger.Pruning.Depth, to, from)
+	}
 
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -516,7 +963,10 @@ func handleAirdrop() {
 		fmt.Println("Example: hyperlux -airdrop 10000 bulk-wallets")
 		return
 	}
-	amount, _ := strconv.Atoi(os.Args[2])
+	amount, err := ledger.ParsePositiveAmount(os.Args[2])
+	if err != nil {
+		log.Fatal("❌ amount airdrop tidak valid:", err)
+	}
 	folder := os.Args[3]
 
 	files, err := os.ReadDir(folder)
@@ -530,11 +980,11 @@ func handleAirdrop() {
 			fmt.Println("❌ gagal load wallet:", f.Name(), err)
 			continue
 		}
-		ledger.Balances[w.AddressEd] += amount
-		fmt.Printf("💸 Airdrop %d ke %s\n", amount, w.AddressEd)
+		if err := ledger.Airdrop(w.AddressEd, amount); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	ledger.SaveBalances()
 	fmt.Printf("✅ Airdrop selesai ke %d wallet (masing-masing %d)\n", len(files), amount)
 }
 
@@ -543,6 +993,32 @@ func handleFixValidators() {
 	ledger.SaveAllData()
 }
 
+func handleMigrateAddress() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: hyperlux -migrate-address <walletfile>")
+		return
+	}
+	w, err := wallet.LoadWallet(os.Args[2])
+	if err != nil {
+		log.Fatal("❌ Gagal load wallet:", err)
+	}
+	// migrasi = TX biasa: saldo & entry validator pindah saat TX masuk blok
+	tx, err := ledger.NewMigrationTx(w)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := ledger.ValidateAndAddToMempool(tx); err != nil {
+		log.Fatal("❌", err)
+	}
+	ledger.SaveMempool()
+
+	hash := ledger.HashTransaction(tx)
+	fmt.Printf("✅ TX migrasi %s → %s dikirim (saldo dipindah: %d)\n", w.LegacyAddressEd(), w.AddressEd, tx.Amount)
+	fmt.Println("TX Hash:", hash)
+	fmt.Println("Cek status: hyperlux tx-show", hash)
+}
+
 func handleFullTest() {
 	// Usage: full-test <walletCount> <perWallet> <intervalSeconds>
 	if len(os.Args) < 5 {
@@ -605,9 +1081,7 @@ func handleFullTest() {
 				for file := range jobs {
 					w, _ := wallet.LoadWallet(file)
 					for j := 0; j < perWallet; j++ {
-						rnd := make([]byte, 4)
-						rand.Read(rnd)
-						toAddr := "hlcRnd" + hex.EncodeToString(rnd)
+						toAddr := wallet.RandomAddress()
 						tx := ledger.NewTransaction(w, toAddr, 1)
 						_ = ledger.ValidateAndAddToMempool(tx)
 					}
@@ -633,11 +1107,12 @@ func handleValidatorStatus() {
 
 	// cari validator
 	found := false
-	stake := 0
-	for _, v := range ledger.Validators {
+	var stake ledger.Amount
+	var jailedUntil int
+	for _, v := range ledger.GetValidators() {
 		if v.Address == addr {
 			found = true
-			stake = v.Stake
+			stake, jailedUntil = v.Stake, v.JailedUntil
 			break
 		}
 	}
@@ -678,6 +1153,9 @@ func handleValidatorStatus() {
 	fmt.Printf("Suspended(All)    : %v\n", sAll)
 	fmt.Printf("Suspension Scope  : %s\n", scopeStr)
 	fmt.Printf("Suspended Until   : %s\n", untilStr)
+	if jailedUntil > ledger.ChainHeight() {
+		fmt.Printf("Jailed Until Block: %d\n", jailedUntil)
+	}
 }
 
 func handleSuspend() {
@@ -712,19 +1190,26 @@ func handleSlash() {
 		return
 	}
 	addr := os.Args[2]
-	amount, err := strconv.Atoi(os.Args[3])
-	if err != nil || amount <= 0 {
-		fmt.Println("❌ amount harus bilangan bulat > 0")
+	amount, err := ledger.ParsePositiveAmount(os.Args[3])
+	if err != nil {
+		fmt.Println("❌ amount harus bilangan bulat > 0:", err)
 		return
 	}
 	reporter := ""
 	if len(os.Args) >= 5 && os.Args[4] != "-" {
 		reporter = os.Args[4]
+		if err := wallet.ValidateAddress(reporter); err != nil {
+			fmt.Printf("❌ reporter address tidak valid (%s): %v\n", reporter, err)
+			return
+		}
 	}
 
 	ensureValidatorsReady()
 
-	ledger.SlashSafetyFault(addr, amount, reporter, 1.0)
+	if err := ledger.SlashSafetyFault(addr, amount, reporter, 1.0); err != nil {
+		fmt.Println(err)
+		return
+	}
 	// persist perubahan stake + distribusi hadiah ke balance
 	ledger.SaveValidators() // <— HILANGKAN `_ =`
 	ledger.SaveBalances()
@@ -737,21 +1222,29 @@ func handleShowEcon() {
 	fmt.Println("-------------------")
 
 	// Treasury & Burned
-	fmt.Printf("Treasury Balance : %d\n", ledger.TreasuryBalance)
-	fmt.Printf("Burned Supply    : %d\n", ledger.BurnedSupply)
+	treasury, burned := ledger.Supply()
+	vals := ledger.GetValidators()
+	fmt.Printf("Treasury Balance : %d\n", treasury)
+	fmt.Printf("Burned Supply    : %d\n", burned)
+	suspended := 0
+	for _, v := range vals {
+		if ledger.IsSuspended(v.Address, ledger.ScopePropose) || ledger.IsSuspended(v.Address, ledger.ScopeVote) {
+			suspended++
+		}
+	}
+	fmt.Printf("Suspended        : %d validator\n", suspended)
 
 	// Total validator stake
-	totalStake := 0
-	maxStake := 0
+	var totalStake, maxStake ledger.Amount
 	maxAddr := ""
-	for _, v := range ledger.Validators {
-		totalStake += v.Stake
+	for _, v := range vals {
+		totalStake, _ = totalStake.Add(v.Stake)
 		if v.Stake > maxStake {
 			maxStake = v.Stake
 			maxAddr = v.Address
 		}
 	}
-	fmt.Printf("Total Validators : %d\n", len(ledger.Validators))
+	fmt.Printf("Total Validators : %d\n", len(vals))
 	fmt.Printf("Total Stake      : %d\n", totalStake)
 	if maxAddr != "" {
 		fmt.Printf("Top Validator    : %s (stake=%d)\n", maxAddr, maxStake)
@@ -759,13 +1252,13 @@ func handleShowEcon() {
 
 	// (Opsional) tampilkan beberapa saldo validator sebagai indikasi redistribusi honest
 	showN := 5
-	if showN > len(ledger.Validators) {
-		showN = len(ledger.Validators)
+	if showN > len(vals) {
+		showN = len(vals)
 	}
 	if showN > 0 {
 		fmt.Println("Sample Balances (validator):")
 		for i := 0; i < showN; i++ {
-			addr := ledger.Validators[i].Address
+			addr := vals[i].Address
 			ledger.BalanceMu.RLock()
 			bal := ledger.Balances[addr]
 			ledger.BalanceMu.RUnlock()
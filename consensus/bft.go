@@ -0,0 +1,571 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/network"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ===================== BFT (Tendermint-style) =====================
+//
+// Satu height diputuskan lewat round: propose → prevote → precommit. Hanya
+// active set epoch height tsb (ledger/epoch.go) yang boleh propose & vote.
+//   - proposer round r = validator yang lolos undian VRF (ledger/vrf.go) atas
+//     seed epoch; bila beberapa lolos, proposal dengan beta terkecil dipakai
+//   - validator prevote blok proposal bila valid (ledger.ValidateBlock) dan
+//     tidak bertentangan dengan blok yang sedang di-lock; selain itu prevote nil
+//   - > 2/3 stake prevote blok B → lock B & precommit B, selain itu precommit nil
+//   - > 2/3 stake precommit B → commit (ledger.ImportBlock)
+// Round yang timeout (proposer diam, blok invalid, vote terpecah) → view change
+// ke round berikutnya: proposer lain, timeout lebih panjang. Vote validator
+// lokal (wallet di validators/) lewat jalur yang sama dengan vote dari peer.
+
+// BFTConfig: timeout dasar per step; tiap round menambah TimeoutDelta.
+type BFTConfig struct {
+	ProposeTimeout   time.Duration
+	PrevoteTimeout   time.Duration
+	PrecommitTimeout time.Duration
+	TimeoutDelta     time.Duration
+	MaxRounds        int // round per CommitBlock sebelum menyerah
+}
+
+// BFTConfigFromEnv: HYPERLUX_BFT_PROPOSE_MS, HYPERLUX_BFT_PREVOTE_MS,
+// HYPERLUX_BFT_PRECOMMIT_MS, HYPERLUX_BFT_DELTA_MS, HYPERLUX_BFT_MAX_ROUNDS.
+func BFTConfigFromEnv() BFTConfig {
+	cfg := BFTConfig{
+		ProposeTimeout:   1000 * time.Millisecond,
+		PrevoteTimeout:   500 * time.Millisecond,
+		PrecommitTimeout: 500 * time.Millisecond,
+		TimeoutDelta:     250 * time.Millisecond,
+		MaxRounds:        5,
+	}
+	for env, dst := range map[string]*time.Duration{
+		"HYPERLUX_BFT_PROPOSE_MS":   &cfg.ProposeTimeout,
+		"HYPERLUX_BFT_PREVOTE_MS":   &cfg.PrevoteTimeout,
+		"HYPERLUX_BFT_PRECOMMIT_MS": &cfg.PrecommitTimeout,
+		"HYPERLUX_BFT_DELTA_MS":     &cfg.TimeoutDelta,
+	} {
+		if v, err := strconv.Atoi(os.Getenv(env)); err == nil && v >= 0 {
+			*dst = time.Duration(v) * time.Millisecond
+		}
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_BFT_MAX_ROUNDS")); err == nil && v > 0 {
+		cfg.MaxRounds = min(v, ledger.MaxProposerRounds)
+	}
+	return cfg
+}
+
+var BFT = BFTConfigFromEnv()
+
+func (c BFTConfig) timeout(base time.Duration, round int) time.Duration {
+	return base + time.Duration(round)*c.TimeoutDelta
+}
+
+// ===================== Height state =====================
+
+type roundState struct {
+	proposal   *ledger.Proposal
+	priority   []byte // output VRF proposer (kecil = prioritas)
+	checked    bool   // proposal sudah divalidasi
+	invalid    error  // hasil ValidateBlock
+	prevotes   *ledger.VoteSet
+	precommits *ledger.VoteSet
+}
+
+// builtBlock: hasil eksekusi proposal lokal, untuk receipt TX gagal.
+type builtBlock struct {
+	snap    []ledger.Transaction
+	results []error
+}
+
+type heightState struct {
+	mu         sync.Mutex
+	height     int
+	prevHash   string
+	seed       string // seed epoch VRF untuk height ini
+	validators []ledger.ValidatorDef
+
+	rounds map[int]*roundState
+	blocks map[string]ledger.Block // blok proposal yang lolos validasi
+	built  map[string]builtBlock
+
+	lockedRound int
+	lockedHash  string
+	validHash   string // blok terakhir dengan > 2/3 prevote (dipropose ulang)
+
+	notify chan struct{}
+}
+
+func newHeightState(head ledger.Block, validators []ledger.ValidatorDef) *heightState {
+	return &heightState{
+		height:      head.Index + 1,
+		prevHash:    head.Hash,
+		seed:        ledger.EpochSeed(head, head.Index+1),
+		validators:  append([]ledger.ValidatorDef(nil), validators...),
+		rounds:      map[int]*roundState{},
+		blocks:      map[string]ledger.Block{},
+		built:       map[string]builtBlock{},
+		lockedRound: -1,
+		notify:      make(chan struct{}, 1),
+	}
+}
+
+// round: state round r (dibuat saat pertama dipakai). hs.mu harus dipegang.
+func (hs *heightState) round(r int) *roundState {
+	rs, ok := hs.rounds[r]
+	if !ok {
+		rs = &roundState{
+			prevotes:   ledger.NewVoteSet(hs.height, r, ledger.VotePrevote, hs.validators),
+			precommits: ledger.NewVoteSet(hs.height, r, ledger.VotePrecommit, hs.validators),
+		}
+		hs.rounds[r] = rs
+	}
+	return rs
+}
+
+func (hs *heightState) wake() {
+	select {
+	case hs.notify <- struct{}{}:
+	default:
+	}
+}
+
+// waitFor menunggu cond (dievaluasi dengan hs.mu dipegang) atau timeout.
+func (hs *heightState) waitFor(d time.Duration, cond func() bool) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		hs.mu.Lock()
+		ok := cond()
+		hs.mu.Unlock()
+		if ok {
+			return true
+		}
+		select {
+		case <-hs.notify:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// addProposal / addVote menerima pesan dari validator mana pun di active set:
+// suspend lokal (CLI, jam dinding) bukan state chain, jadi hanya dipakai saat
+// validator lokal menandatangani (castVotes, localProposer). Kalau ikut di
+// sini, node bisa menghitung stake berbeda untuk round yang sama.
+func (hs *heightState) addProposal(p ledger.Proposal) {
+	if p.Height != hs.height || p.Round < 0 || p.Round >= ledger.MaxProposerRounds {
+		return
+	}
+	if p.Block.VRF == nil || p.Block.VRF.Round > p.Round {
+		fmt.Printf("⚠️ Proposal height %d round %d dari %s: round VRF blok di atas round proposal\n", p.Height, p.Round, p.Proposer)
+		return
+	}
+	beta, err := ledger.VerifyProposerVRF(p.Proposer, p.PubKey, hs.seed, hs.height, p.Round, p.VRFProof, hs.validators)
+	if err != nil {
+		fmt.Printf("⚠️ Proposal height %d round %d dari %s ditolak: %v\n", p.Height, p.Round, p.Proposer, err)
+		return
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	rs := hs.round(p.Round)
+	if rs.proposal != nil && (rs.checked || !ledger.VRFPriority(beta, rs.priority)) {
+		return // pemenang undian lain lebih dulu / prioritas lebih tinggi
+	}
+	rs.proposal, rs.priority = &p, beta
+	hs.wake()
+}
+
+func (hs *heightState) addVote(v ledger.Vote) {
+	if v.Height != hs.height || v.Round < 0 || v.Round >= ledger.MaxProposerRounds {
+		return
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	rs := hs.round(v.Round)
+	set := rs.prevotes
+	if v.Type == ledger.VotePrecommit {
+		set = rs.precommits
+	}
+	added, err := set.Add(v)
+	switch {
+	case errors.Is(err, ledger.ErrVoteConflict):
+		fmt.Printf("🚨 Equivocation %s height %d round %d: %v\n", v.Type, v.Height, v.Round, err)
+		// bukti masuk blok berikutnya → jail di state chain
+		if prev, ok := set.VoteOf(v.Validator); ok {
+			if err := ledger.ReportEquivocation(prev, v); err != nil {
+				fmt.Println("⚠️ bukti equivocation ditolak:", err)
+			}
+		}
+	case err != nil:
+		fmt.Printf("⚠️ Vote ditolak: %v\n", err)
+	case added:
+		hs.wake()
+	}
+}
+
+// checkProposal: proposal round r valid? (ValidateBlock di luar lock).
+func (hs *heightState) checkProposal(r int) (*ledger.Proposal, bool) {
+	hs.mu.Lock()
+	rs := hs.round(r)
+	p, checked, invalid := rs.proposal, rs.checked, rs.invalid
+	hs.mu.Unlock()
+	if p == nil {
+		return nil, false
+	}
+	if checked {
+		return p, invalid == nil
+	}
+	err := ledger.ValidateBlock(p.Block)
+	hs.mu.Lock()
+	if rs.proposal != p { // diganti proposal berprioritas lebih tinggi
+		hs.mu.Unlock()
+		return hs.checkProposal(r)
+	}
+	rs.checked, rs.invalid = true, err
+	if err == nil {
+		hs.blocks[p.Block.Hash] = p.Block
+	}
+	hs.mu.Unlock()
+	if err != nil {
+		fmt.Printf("❌ Proposal height %d round %d dari %s invalid: %v\n", p.Height, p.Round, p.Proposer, err)
+	}
+	return p, err == nil
+}
+
+// commitReady: blok dengan > 2/3 precommit di round mana pun. hs.mu dipegang.
+func (hs *heightState) commitReady() (ledger.Block, int, bool) {
+	for r, rs := range hs.rounds {
+		if hash, ok := rs.precommits.TwoThirdsMajority(); ok && hash != "" {
+			if b, known := hs.blockByHash(hash); known {
+				return b, r, true
+			}
+		}
+	}
+	return ledger.Block{}, 0, false
+}
+
+// blockByHash: blok tervalidasi, atau isi proposal mana pun dengan hash tsb
+// (divalidasi ulang saat ImportBlock). hs.mu dipegang.
+func (hs *heightState) blockByHash(hash string) (ledger.Block, bool) {
+	if b, ok := hs.blocks[hash]; ok {
+		return b, true
+	}
+	for _, rs := range hs.rounds {
+		if rs.proposal != nil && rs.proposal.Block.Hash == hash {
+			return rs.proposal.Block, true
+		}
+	}
+	return ledger.Block{}, false
+}
+
+// higherRound: round > r yang sudah diikuti > 1/3 stake (validator lain sudah
+// maju, ikut lompat supaya round tidak terus berbeda). hs.mu dipegang.
+func (hs *heightState) higherRound(r int) int {
+	best := r
+	for rr, rs := range hs.rounds {
+		if rr <= best {
+			continue
+		}
+		power := max(rs.prevotes.Voted(), rs.precommits.Voted())
+		if ledger.HasOneThird(power, rs.prevotes.Total()) {
+			best = rr
+		}
+	}
+	return best
+}
+
+// castVotes: semua validator lokal (tidak disuspend vote/all) menandatangani vote.
+func (hs *heightState) castVotes(typ ledger.VoteType, r int, hash string) {
+	for _, v := range hs.validators {
+		w := ledger.ValidatorWallets[v.Address]
+		if w == nil || ledger.IsSuspended(v.Address, ledger.ScopeVote) || ledger.IsSuspended(v.Address, ledger.ScopeAll) {
+			continue
+		}
+		vote := ledger.SignVote(w, typ, hs.height, r, hash)
+		hs.addVote(vote)
+		network.PublishVote(vote)
+		label := "nil"
+		if hash != "" {
+			label = fmt.Sprintf("%.12s", hash)
+		}
+		fmt.Printf("🗳️ %s %s %s (height %d round %d)\n", v.Address, typ, label, hs.height, r)
+	}
+}
+
+// vrfTicket: validator lokal yang lolos undian VRF round ini.
+type vrfTicket struct {
+	val  ledger.ValidatorDef
+	w    *wallet.Wallet
+	vrf  ledger.VRFHeader
+	beta []byte
+}
+
+// localProposer: pemenang undian terbaik di antara validator lokal (nil bila tidak ada).
+func (hs *heightState) localProposer(r int) *vrfTicket {
+	var best *vrfTicket
+	for _, v := range hs.validators {
+		w := ledger.ValidatorWallets[v.Address]
+		if w == nil || ledger.IsSuspended(v.Address, ledger.ScopePropose) {
+			continue
+		}
+		vrf, beta, ok, err := ledger.ProveProposer(w, hs.seed, hs.height, r, hs.validators)
+		if err != nil {
+			fmt.Println("❌ VRF:", err)
+			continue
+		}
+		if ok && (best == nil || ledger.VRFPriority(beta, best.beta)) {
+			best = &vrfTicket{val: v, w: w, vrf: vrf, beta: beta}
+		}
+	}
+	return best
+}
+
+// propose: proposer lokal mengirim blok valid terakhir (bila ada) atau blok baru.
+func (hs *heightState) propose(r int, t *vrfTicket) {
+	hs.mu.Lock()
+	b, ok := hs.blocks[hs.validHash]
+	hs.mu.Unlock()
+	if !ok {
+		built, snap, results, err := ledger.BuildProposalBlock(&t.val, t.w, t.vrf)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		b = built
+		hs.mu.Lock()
+		hs.built[b.Hash] = builtBlock{snap: snap, results: results}
+		hs.mu.Unlock()
+	}
+	p := ledger.SignProposal(t.w, hs.height, r, b, t.vrf.Proof)
+	hs.addProposal(p)
+	network.PublishProposal(p)
+	fmt.Printf("📣 Proposal height %d round %d: block %.12s (%d tx)\n", hs.height, r, b.Hash, len(b.Transactions))
+}
+
+// runRound menjalankan satu round; ok=true bila blok height ini siap di-commit.
+func (hs *heightState) runRound(r int) (ledger.Block, int, bool) {
+
+	ready := func() bool {
+		_, _, ok := hs.commitReady()
+		return ok || hs.higherRound(r) > r
+	}
+
+	// 1) propose: validator lokal yang lolos undian VRF (proposer lain tidak diketahui
+	//    sampai proposal-nya datang bersama proof)
+	if t := hs.localProposer(r); t != nil {
+		fmt.Printf("🎲 Height %d round %d: %s lolos undian VRF (stake=%d, beta=%x...)\n", hs.height, r, t.val.Address, t.val.Stake, t.beta[:6])
+		hs.propose(r, t)
+	} else {
+		fmt.Printf("🎲 Height %d round %d: tidak ada validator lokal yang lolos undian VRF\n", hs.height, r)
+	}
+	hs.waitFor(BFT.timeout(BFT.ProposeTimeout, r), func() bool {
+		return hs.round(r).proposal != nil || ready()
+	})
+
+	// 2) prevote: blok proposal bila valid & cocok dengan lock
+	prevote := ""
+	if p, ok := hs.checkProposal(r); ok {
+		hs.mu.Lock()
+		if hs.lockedHash == "" || hs.lockedHash == p.Block.Hash {
+			prevote = p.Block.Hash
+		}
+		hs.mu.Unlock()
+	}
+	hs.castVotes(ledger.VotePrevote, r, prevote)
+	hs.waitFor(BFT.timeout(BFT.PrevoteTimeout, r), func() bool {
+		_, ok := hs.round(r).prevotes.TwoThirdsMajority()
+		return ok || ready()
+	})
+
+	// 3) precommit: > 2/3 prevote blok → lock; > 2/3 prevote nil → unlock
+	precommit := ""
+	hs.mu.Lock()
+	if hash, ok := hs.round(r).prevotes.TwoThirdsMajority(); ok {
+		if _, known := hs.blocks[hash]; hash != "" && known {
+			hs.lockedRound, hs.lockedHash, hs.validHash = r, hash, hash
+			precommit = hash
+		} else if hash == "" {
+			hs.lockedRound, hs.lockedHash = -1, ""
+		}
+	}
+	hs.mu.Unlock()
+	hs.castVotes(ledger.VotePrecommit, r, precommit)
+	hs.waitFor(BFT.timeout(BFT.PrecommitTimeout, r), func() bool {
+		hash, ok := hs.round(r).precommits.TwoThirdsMajority()
+		return (ok && hash == "") || ready()
+	})
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return hs.commitReady()
+}
+
+// ===================== Message routing =====================
+
+const maxPendingMsgs = 4096
+
+type bftMsg struct {
+	vote     *ledger.Vote
+	proposal *ledger.Proposal
+}
+
+func (m bftMsg) height() int {
+	if m.vote != nil {
+		return m.vote.Height
+	}
+	return m.proposal.Height
+}
+
+var (
+	bftMu   sync.Mutex
+	current *heightState
+	pending []bftMsg // pesan height berikutnya sebelum round dimulai
+)
+
+func (hs *heightState) deliver(m bftMsg) {
+	if m.vote != nil {
+		hs.addVote(*m.vote)
+	} else {
+		hs.addProposal(*m.proposal)
+	}
+}
+
+// setCurrent memasang height aktif & mengirim pesan yang sudah menunggu.
+func setCurrent(hs *heightState) {
+	bftMu.Lock()
+	current = hs
+	var ready, keep []bftMsg
+	for _, m := range pending {
+		switch h := m.height(); {
+		case h == hs.height:
+			ready = append(ready, m)
+		case h > hs.height:
+			keep = append(keep, m)
+		}
+	}
+	pending = keep
+	bftMu.Unlock()
+	for _, m := range ready {
+		hs.deliver(m)
+	}
+}
+
+func clearCurrent(hs *heightState) {
+	bftMu.Lock()
+	if current == hs {
+		current = nil
+	}
+	bftMu.Unlock()
+}
+
+func hasLocalValidator() bool {
+	for _, v := range ledger.ActiveValidators(ledger.ChainHeight()) {
+		if ledger.ValidatorWallets[v.Address] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// route: pesan dari peer → height aktif, atau antri untuk height berikutnya
+// (dan node ikut height tsb walau mempool lokal kosong).
+func route(m bftMsg) {
+	bftMu.Lock()
+	hs := current
+	if hs != nil && m.height() == hs.height {
+		bftMu.Unlock()
+		hs.deliver(m)
+		return
+	}
+	next := ledger.ChainHeight()
+	join := false
+	if h := m.height(); h >= next && h <= next+1 && len(pending) < maxPendingMsgs && hasLocalValidator() {
+		pending = append(pending, m)
+		join = hs == nil && h == next
+	}
+	bftMu.Unlock()
+	if join {
+		go commitHeight(false)
+	}
+}
+
+func consumeMessages() {
+	for {
+		select {
+		case v := <-network.Votes():
+			route(bftMsg{vote: &v})
+		case p := <-network.Proposals():
+			route(bftMsg{proposal: &p})
+		}
+	}
+}
+
+// ===================== Height driver =====================
+
+// runBFT memutuskan blok height berikutnya. ok=false bila tidak ada blok yang
+// mendapat > 2/3 precommit dalam BFT.MaxRounds round.
+func runBFT() (ledger.Block, bool) {
+	head, ok := ledger.HeadBlock()
+	if !ok {
+		fmt.Println("⚠️ Chain kosong, jalankan init dulu")
+		return ledger.Block{}, false
+	}
+	hs := newHeightState(head, ledger.ActiveValidators(head.Index+1))
+	setCurrent(hs)
+	defer clearCurrent(hs)
+
+	for r := 0; r < BFT.MaxRounds; {
+		b, cr, ok := hs.runRound(r)
+		if ok {
+			return hs.commit(b, cr)
+		}
+		if ledger.ChainHeight() > hs.height {
+			return ledger.Block{}, false // height ini sudah masuk lewat gossip blok
+		}
+		hs.mu.Lock()
+		next := max(r+1, hs.higherRound(r))
+		hs.mu.Unlock()
+		if next < BFT.MaxRounds {
+			fmt.Printf("🔄 View change height %d: round %d → %d\n", hs.height, r, next)
+		}
+		r = next
+	}
+	fmt.Printf("❌ BFT gagal mencapai consensus di height %d setelah %d round\n", hs.height, BFT.MaxRounds)
+	return ledger.Block{}, false
+}
+
+func (hs *heightState) commit(b ledger.Block, r int) (ledger.Block, bool) {
+	hs.mu.Lock()
+	cert, err := hs.rounds[r].precommits.CommitCert(b.Hash)
+	built, isLocal := hs.built[b.Hash]
+	hs.mu.Unlock()
+	if err != nil {
+		fmt.Println(err)
+		return ledger.Block{}, false
+	}
+	b.Commit = cert // bukti finality ikut disimpan & di-broadcast bersama blok
+
+	if err := ledger.ImportBlock(b); err != nil && !errors.Is(err, ledger.ErrBlockKnown) {
+		fmt.Println("❌ gagal commit block:", err)
+		return ledger.Block{}, false
+	}
+	if isLocal {
+		ledger.RecordFailedTxs(b.Index, built.snap, built.results) // receipt gagal + buang dari mempool
+	}
+	fmt.Printf("✅ BFT reached consensus: block %d round %d, %d signatures, precommit stake %d/%d (%.2f%%)\n",
+		b.Index, r, len(cert.Sigs), cert.Power, cert.Total, float64(cert.Power)/float64(max(cert.Total, 1))*100)
+	fmt.Printf("✅ Block %d committed by %s with %d txs\n", b.Index, b.Proposer, len(b.Transactions))
+	fmt.Printf("   MerkleRoot: %s | StateRoot: %.16s... | Timestamp: %d\n", b.MerkleRoot, b.StateRoot, b.Timestamp)
+	if b.PoH != nil {
+		fmt.Printf("   PoH: %d ticks, %d hashes, %d entries → %.12s...\n", b.PoH.Ticks, b.PoH.Hashes, len(b.PoH.Entries), b.PoH.Hash)
+	}
+	return b, true
+}
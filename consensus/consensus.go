@@ -1,12 +1,8 @@
 package consensus
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"math/big"
 	"runtime"
-	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,13 +14,6 @@ import (
 const BlockTime = 350 * time.Millisecond
 
 var (
-	// PoH state
-	pohChain []string
-	pohSlot  int64
-
-	// DPoS delegates (top-N)
-	Delegates []string
-
 	// metrics (berbasis wall-clock, bukan height delta)
 	lastBlockWall time.Time
 	lastTPS       float64
@@ -38,17 +27,17 @@ var (
 func InitConsensus() {
 	fmt.Println("⚡ Consensus engine initialized")
 
-	initPoH()
-	initDPoS()
+	ledger.StartPoH()
 	initBFT()
 
 	ledger.LoadValidators()
-	if len(ledger.Validators) == 0 {
+	if n := len(ledger.GetValidators()); n == 0 {
 		fmt.Println("⚠️ Tidak ada validator terdaftar")
 	} else {
-		fmt.Printf("✅ Loaded %d validators from DB\n", len(ledger.Validators))
+		fmt.Printf("✅ Loaded %d validators from DB\n", n)
 	}
 	ledger.AutoLoadValidatorWallets()
+	initDPoS()
 
 	go blockProducer()
 	fmt.Println("✅ Consensus modules ready")
@@ -64,7 +53,13 @@ func blockProducer() {
 
 // ===================== CommitBlock =====================
 
+// CommitBlock: satu height BFT bila mempool lokal berisi TX.
 func CommitBlock() {
+	commitHeight(true)
+}
+
+// commitHeight: requireTxs=false dipakai saat ikut height yang dimulai peer.
+func commitHeight(requireTxs bool) {
 	// hindari overlap
 	if !atomic.CompareAndSwapInt32(&committing, 0, 1) {
 		return
@@ -74,42 +69,25 @@ func CommitBlock() {
 	start := time.Now()
 
 	mempoolBefore := ledger.GetMempoolSize()
-	if mempoolBefore == 0 {
+	if requireTxs && mempoolBefore == 0 {
 		return
 	}
-
-	// PoH slot
-	slotHash := nextPoH("block-commit")
-
-	// pilih proposer via VRF + skip yang suspended (propose)
-	if len(ledger.Validators) == 0 {
+	if len(ledger.ActiveValidators(ledger.ChainHeight())) == 0 {
 		fmt.Println("⚠️ No validators registered")
 		return
 	}
-	validator := selectValidatorVRFEligible(slotHash)
-	valWallet := ledger.ValidatorWallets[validator.Address]
-	if valWallet == nil {
-		fmt.Printf("❌ Wallet not found for validator %s\n", validator.Address)
-		return
-	}
 
-	fmt.Printf("🎲 Selected proposer: %s (stake=%d) | slotHash=%.12s...\n",
-		validator.Address, validator.Stake, slotHash)
+	// posisi PoH lokal (segmen sejak head ikut blok yang dipropose)
+	poh := ledger.PoHStatus()
+	fmt.Printf("⏱️ PoH tick %d | hash=%.12s... | %d tick sejak head\n", poh.TotalTicks, poh.Hash, poh.Ticks)
 
-	// BFT vote (skip voter yang suspended vote/all)
-	if !bftVote(slotHash, ledger.Validators) {
+	// propose → prevote → precommit (lihat bft.go); proposer yang gagal
+	// diganti lewat view change, bukan di-slash lokal
+	newBlock, ok := runBFT()
+	if !ok {
 		fmt.Println("❌ Block rejected by BFT")
-		ledger.SlashValidator(validator.Address, 10) // contoh penalti ringan
 		return
 	}
-
-	// Snapshot & eksekusi paralel (mutasi state dilakukan di executor)
-	snap := ledger.MempoolSnapshot()
-	validTxs := ledger.ProcessTxListParallel(snap)
-
-	// Build block & broadcast
-	newBlock := ledger.AddBlockWithTxs(&validator, valWallet, validTxs)
-	ledger.RemoveCommittedFromMempool(validTxs)
 	ledger.AddCheckpoint(newBlock)
 	network.BroadcastBlock(newBlock)
 
@@ -122,143 +100,36 @@ func CommitBlock() {
 	printMetrics(newBlock)
 }
 
-// ===================== DPoS + VRF =====================
+// ===================== DPoS =====================
 
+// Active set dirotasi per epoch di ledger (lihat ledger/epoch.go): proposer
+// (VRF) & vote BFT hanya dari set epoch height yang sedang diputuskan.
 func initDPoS() {
-	ledger.LoadValidators()
-	if len(ledger.Validators) == 0 {
+	set := ledger.CurrentEpochSet()
+	if len(set.Validators) == 0 {
 		fmt.Println("⚠️ No validators for DPoS")
 		return
 	}
-	sort.Slice(ledger.Validators, func(i, j int) bool {
-		return ledger.Validators[i].Stake > ledger.Validators[j].Stake
-	})
-	max := 5
-	if len(ledger.Validators) < max {
-		max = len(ledger.Validators)
-	}
-	Delegates = Delegates[:0]
-	for i := 0; i < max; i++ {
-		Delegates = append(Delegates, ledger.Validators[i].Address)
-	}
-	fmt.Printf("⚡ DPoS Consensus initialized with %d delegates\n", len(Delegates))
-}
-
-func selectValidatorVRF(seed string) ledger.ValidatorDef {
-	hash := sha256.Sum256([]byte(seed))
-	rnd := new(big.Int).SetBytes(hash[:])
-
-	totalStake := big.NewInt(0)
-	for _, v := range ledger.Validators {
-		totalStake.Add(totalStake, big.NewInt(int64(v.Stake)))
-	}
-	if totalStake.Cmp(big.NewInt(0)) == 0 {
-		return ledger.ValidatorDef{}
-	}
-
-	r := new(big.Int).Mod(rnd, totalStake)
-	acc := big.NewInt(0)
-	for _, v := range ledger.Validators {
-		acc.Add(acc, big.NewInt(int64(v.Stake)))
-		if r.Cmp(acc) < 0 {
-			return v
-		}
-	}
-	return ledger.Validators[0]
-}
-
-// pilih validator eligible (tidak suspended propose). Jika pick suspended → fallback linear scan
-func selectValidatorVRFEligible(seed string) ledger.ValidatorDef {
-	pick := selectValidatorVRF(seed)
-	if !ledger.IsSuspended(pick.Address, ledger.ScopePropose) {
-		return pick
-	}
-	for _, v := range ledger.Validators {
-		if !ledger.IsSuspended(v.Address, ledger.ScopePropose) {
-			return v
+	local := 0
+	for _, v := range set.Validators {
+		if ledger.ValidatorWallets[v.Address] != nil {
+			local++
 		}
 	}
-	return pick
+	fmt.Printf("⚡ DPoS epoch %d: %d/%d validator aktif (%d lokal), epoch %d blok, berikutnya di block #%d\n",
+		set.Epoch, len(set.Validators), len(ledger.GetValidators()), local, ledger.Params.EpochLength, set.End()+1)
 }
 
-// ===================== PoH =====================
+// ===================== BFT =====================
 
-func initPoH() {
-	fmt.Println("Proof of History module initialized")
-	if len(pohChain) == 0 {
-		genesis := generatePoH("genesis", "init", time.Now().UnixNano())
-		pohChain = append(pohChain, genesis)
-		fmt.Println("✅ PoH genesis slot:", genesis)
-	}
-}
-
-func generatePoH(prevHash, data string, timestamp int64) string {
-	input := fmt.Sprintf("%s|%s|%d", prevHash, data, timestamp)
-	hash := sha256.Sum256([]byte(input))
-	return hex.EncodeToString(hash[:])
-}
-
-func nextPoH(data string) string {
-	if len(pohChain) == 0 {
-		initPoH()
-	}
-	prev := pohChain[len(pohChain)-1]
-	pohSlot++
-	hash := generatePoH(prev, data, time.Now().UnixNano())
-	pohChain = append(pohChain, hash)
-	return hash
-}
-
-// ===================== BFT (simulasi) =====================
+var bftOnce sync.Once
 
 func initBFT() {
-	fmt.Println("⚡ BFT Consensus initialized")
+	bftOnce.Do(func() { go consumeMessages() })
+	fmt.Printf("⚡ BFT Consensus initialized (propose=%s prevote=%s precommit=%s, max %d rounds)\n",
+		BFT.ProposeTimeout, BFT.PrevoteTimeout, BFT.PrecommitTimeout, BFT.MaxRounds)
 }
 
-func bftVote(blockHash string, validators []ledger.ValidatorDef) bool {
-	var yesCount int32
-	var wg sync.WaitGroup
-	results := make(chan bool, len(validators))
-
-	for _, v := range validators {
-		if ledger.IsSuspended(v.Address, ledger.ScopeVote) || ledger.IsSuspended(v.Address, ledger.ScopeAll) {
-			continue
-		}
-		wg.Add(1)
-		go func(val ledger.ValidatorDef) {
-			defer wg.Done()
-			isValid := validateBlock(blockHash)
-			results <- isValid
-			if isValid {
-				fmt.Printf("🗳️ %s voted YES for block %.12s\n", val.Address, blockHash)
-			} else {
-				fmt.Printf("🗳️ %s voted NO for block %.12s\n", val.Address, blockHash)
-			}
-		}(v)
-	}
-
-	wg.Wait()
-	close(results)
-
-	for res := range results {
-		if res {
-			atomic.AddInt32(&yesCount, 1)
-		}
-	}
-
-	yes := int(yesCount)
-	threshold := (len(validators)*2)/3 + 1
-	if yes >= threshold {
-		fmt.Printf("✅ BFT reached consensus: %d/%d YES (%.2f%%)\n",
-			yes, len(validators), float64(yes)/float64(len(validators))*100)
-		return true
-	}
-	fmt.Println("❌ BFT failed to reach consensus")
-	return false
-}
-
-func validateBlock(_ string) bool { return true }
-
 // ===================== Metrics =====================
 
 func printMetrics(newBlock ledger.Block) {
@@ -270,6 +141,12 @@ func printMetrics(newBlock ledger.Block) {
 		}
 		lastTPS = float64(len(newBlock.Transactions)) / dt
 		fmt.Printf("📊 Metrics → BlockTime=%.2fs, TPS=%.2f, Finality=BFT instant\n", dt, lastTPS)
+		sv := ledger.SigStats()
+		fmt.Printf("🔏 SigCache hit=%.1f%% (%d/%d), batches=%d, fallback=%d, single=%d\n",
+			sv.HitRate()*100, sv.CacheSize, sv.CacheCap, sv.Batches, sv.BatchFallbacks, sv.SingleVerifies)
+		if ps := ledger.PruneStatus(); ledger.Pruning.Enabled && ps.Runs > 0 {
+			fmt.Printf("✂️  Pruning body ≤ #%d, reclaimed=%d bytes (%d runs)\n", ps.PrunedTo, ps.BytesReclaimed, ps.Runs)
+		}
 	}
 	lastBlockWall = now
 }
@@ -280,5 +157,14 @@ func GetLastBlockTime() int64 {
 	}
 	return int64(time.Since(lastBlockWall).Seconds())
 }
-func GetLastTPS() float64       { return lastTPS }
-func GetFinalityStatus() string { return "BFT instant" }
+func GetLastTPS() float64 { return lastTPS }
+
+// GetFinalityStatus: finality head berdasarkan sertifikat commit yang tersimpan.
+func GetFinalityStatus() string {
+	head, ok := ledger.HeadBlock()
+	if !ok || head.Commit == nil {
+		return "BFT instant"
+	}
+	c := head.Commit
+	return fmt.Sprintf("BFT instant (#%d: %d signatures, %d/%d stake)", head.Index, len(c.Sigs), c.Power, c.Total)
+}
@@ -0,0 +1,30 @@
+package crypto
+
+import "crypto/sha256"
+
+// Domain separation untuk hashing pohon Merkle (hindari second-preimage leaf vs node).
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// HashBytes: sha256 mentah.
+func HashBytes(data []byte) [32]byte {
+	return sha256.Sum256(data)
+}
+
+func hashLeaf(keyHash, valueHash [32]byte) [32]byte {
+	buf := make([]byte, 0, 1+64)
+	buf = append(buf, leafPrefix)
+	buf = append(buf, keyHash[:]...)
+	buf = append(buf, valueHash[:]...)
+	return sha256.Sum256(buf)
+}
+
+func hashNode(left, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 1+64)
+	buf = append(buf, nodePrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
@@ -0,0 +1,226 @@
+package crypto
+
+// ================== Sparse Merkle Tree ==================
+//
+// Pohon biner 256 level, posisi leaf = sha256(key). Subtree kosong bernilai 0x00..00
+// dan subtree yang hanya berisi satu leaf langsung bernilai hash leaf tersebut,
+// jadi node internal hanya ada selama jalurnya masih dipakai >= 2 leaf.
+//
+// Node tidak pernah diubah setelah dibuat: Update/Delete menyalin jalur dari
+// root ke leaf saja, sehingga Clone cukup O(1) dan hash subtree yang tidak
+// tersentuh tetap tersimpan. Root() hanya menghitung ulang node baru.
+//
+// Tidak thread-safe (hash node diisi saat Root pertama kali dipanggil); pemanggil
+// yang mengatur locking, atau panggil Root() sebelum pohon dibagi ke goroutine lain.
+
+var emptyHash [32]byte
+
+type SparseMerkleTree struct {
+	root  *smtNode
+	count int
+}
+
+// smtNode: leaf (key/value terisi) atau node internal (left/right, salah satunya
+// boleh nil = subtree kosong).
+type smtNode struct {
+	leaf        bool
+	key, value  [32]byte // keyHash & valueHash, khusus leaf
+	left, right *smtNode
+	hash        [32]byte
+	hashed      bool
+}
+
+// MerkleProof: sibling dari root ke bawah, plus leaf yang menempati ujung jalur.
+type MerkleProof struct {
+	Siblings  [][32]byte `json:"siblings"`
+	HasLeaf   bool       `json:"has_leaf"`
+	LeafKey   [32]byte   `json:"leaf_key"`
+	LeafValue [32]byte   `json:"leaf_value"`
+}
+
+func NewSparseMerkleTree() *SparseMerkleTree {
+	return &SparseMerkleTree{}
+}
+
+// Clone: salinan yang bisa diubah tanpa memengaruhi pohon asal (node dipakai bersama).
+func (t *SparseMerkleTree) Clone() *SparseMerkleTree {
+	c := *t
+	return &c
+}
+
+func (t *SparseMerkleTree) Update(key, value []byte) {
+	var added bool
+	t.root = insertNode(t.root, HashBytes(key), HashBytes(value), 0, &added)
+	if added {
+		t.count++
+	}
+}
+
+func (t *SparseMerkleTree) Delete(key []byte) {
+	var removed bool
+	t.root = deleteNode(t.root, HashBytes(key), 0, &removed)
+	if removed {
+		t.count--
+	}
+}
+
+func (t *SparseMerkleTree) Len() int { return t.count }
+
+func (t *SparseMerkleTree) Root() [32]byte {
+	return nodeHash(t.root)
+}
+
+// Prove membuat proof (inclusion atau non-inclusion) untuk key.
+func (t *SparseMerkleTree) Prove(key []byte) MerkleProof {
+	target := HashBytes(key)
+	var proof MerkleProof
+	n := t.root
+	for depth := 0; n != nil && !n.leaf; depth++ {
+		if bitAt(target, depth) == 0 {
+			proof.Siblings = append(proof.Siblings, nodeHash(n.right))
+			n = n.left
+		} else {
+			proof.Siblings = append(proof.Siblings, nodeHash(n.left))
+			n = n.right
+		}
+	}
+	if n != nil {
+		proof.HasLeaf = true
+		proof.LeafKey = n.key
+		proof.LeafValue = n.value
+	}
+	return proof
+}
+
+// VerifyMerkleProof: cek bahwa (key, value) ada di pohon dengan root tsb.
+func VerifyMerkleProof(root [32]byte, key, value []byte, proof MerkleProof) bool {
+	kh := HashBytes(key)
+	if !proof.HasLeaf || proof.LeafKey != kh || proof.LeafValue != HashBytes(value) {
+		return false
+	}
+	return foldProof(kh, hashLeaf(kh, proof.LeafValue), proof.Siblings) == root
+}
+
+// VerifyNonInclusion: cek bahwa key TIDAK ada di pohon dengan root tsb.
+func VerifyNonInclusion(root [32]byte, key []byte, proof MerkleProof) bool {
+	kh := HashBytes(key)
+	start := emptyHash
+	if proof.HasLeaf {
+		if proof.LeafKey == kh {
+			return false
+		}
+		// leaf lain harus berada di jalur yang sama dengan key
+		for d := range proof.Siblings {
+			if bitAt(proof.LeafKey, d) != bitAt(kh, d) {
+				return false
+			}
+		}
+		start = hashLeaf(proof.LeafKey, proof.LeafValue)
+	}
+	return foldProof(kh, start, proof.Siblings) == root
+}
+
+// ================== internal ==================
+
+func foldProof(path, cur [32]byte, siblings [][32]byte) [32]byte {
+	for d := len(siblings) - 1; d >= 0; d-- {
+		if bitAt(path, d) == 0 {
+			cur = combine(cur, siblings[d])
+		} else {
+			cur = combine(siblings[d], cur)
+		}
+	}
+	return cur
+}
+
+// combine: node dengan dua anak kosong tetap kosong; selain itu hash node biasa.
+func combine(left, right [32]byte) [32]byte {
+	if left == emptyHash && right == emptyHash {
+		return emptyHash
+	}
+	return hashNode(left, right)
+}
+
+func nodeHash(n *smtNode) [32]byte {
+	if n == nil {
+		return emptyHash
+	}
+	if !n.hashed {
+		if n.leaf {
+			n.hash = hashLeaf(n.key, n.value)
+		} else {
+			n.hash = combine(nodeHash(n.left), nodeHash(n.right))
+		}
+		n.hashed = true
+	}
+	return n.hash
+}
+
+// insertNode mengembalikan node pengganti n; node lama tidak disentuh.
+func insertNode(n *smtNode, key, value [32]byte, depth int, added *bool) *smtNode {
+	if n == nil {
+		*added = true
+		return &smtNode{leaf: true, key: key, value: value}
+	}
+	if n.leaf {
+		if n.key == key {
+			if n.value == value {
+				return n
+			}
+			return &smtNode{leaf: true, key: key, value: value}
+		}
+		// dua leaf di jalur yang sama: pecah jadi node internal sampai bitnya berbeda
+		split := &smtNode{}
+		if bitAt(n.key, depth) == 0 {
+			split.left = n
+		} else {
+			split.right = n
+		}
+		n = split
+	}
+	c := *n
+	c.hashed = false
+	if bitAt(key, depth) == 0 {
+		c.left = insertNode(n.left, key, value, depth+1, added)
+	} else {
+		c.right = insertNode(n.right, key, value, depth+1, added)
+	}
+	return &c
+}
+
+func deleteNode(n *smtNode, key [32]byte, depth int, removed *bool) *smtNode {
+	if n == nil {
+		return nil
+	}
+	if n.leaf {
+		if n.key == key {
+			*removed = true
+			return nil
+		}
+		return n
+	}
+	left, right := n.left, n.right
+	if bitAt(key, depth) == 0 {
+		left = deleteNode(left, key, depth+1, removed)
+	} else {
+		right = deleteNode(right, key, depth+1, removed)
+	}
+	if !*removed {
+		return n
+	}
+	// subtree yang tinggal satu leaf dinaikkan, supaya root sama dengan pohon yang
+	// dibangun ulang dari nol
+	switch {
+	case left == nil && right == nil:
+		return nil
+	case left == nil && right.leaf:
+		return right
+	case right == nil && left.leaf:
+		return left
+	}
+	return &smtNode{left: left, right: right}
+}
+
+func bitAt(h [32]byte, i int) byte {
+	return (h[i/8] >> (7 - uint(i%8))) & 1
+}
@@ -0,0 +1,177 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+// ================== ECVRF-EDWARDS25519-SHA512-TAI (RFC 9381) ==================
+//
+// VRF di atas kunci Ed25519 yang sama dengan wallet: output (beta) unik untuk
+// (kunci, input) dan bisa diverifikasi siapa pun dari proof (pi) + public key,
+// tapi tidak bisa dihitung tanpa private key.
+
+const (
+	vrfSuite     byte = 0x03 // ECVRF-EDWARDS25519-SHA512-TAI
+	vrfPtLen          = 32
+	vrfCLen           = 16
+	vrfQLen           = 32
+	VRFProofLen       = vrfPtLen + vrfCLen + vrfQLen // 80 byte
+	VRFOutputLen      = sha512.Size                  // 64 byte
+)
+
+var (
+	ErrVRFPublicKey = errors.New("vrf: public key tidak valid")
+	ErrVRFProof     = errors.New("vrf: proof tidak valid")
+)
+
+// VRFProve: pi = proof untuk alpha dengan private key Ed25519.
+func VRFProve(priv ed25519.PrivateKey, alpha []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("vrf: private key harus 64 byte")
+	}
+	// x & prefix nonce seperti RFC 8032 (SHA512(seed), clamp 32 byte pertama)
+	hashed := sha512.Sum512(priv.Seed())
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(hashed[:32])
+	if err != nil {
+		return nil, err
+	}
+	pk := priv.Public().(ed25519.PublicKey)
+
+	H, err := encodeToCurveTAI(pk, alpha)
+	if err != nil {
+		return nil, err
+	}
+	hString := H.Bytes()
+	gamma := new(edwards25519.Point).ScalarMult(x, H)
+
+	kh := sha512.New()
+	kh.Write(hashed[32:])
+	kh.Write(hString)
+	k, _ := edwards25519.NewScalar().SetUniformBytes(kh.Sum(nil))
+
+	kB := new(edwards25519.Point).ScalarBaseMult(k)
+	kH := new(edwards25519.Point).ScalarMult(k, H)
+	c := challenge(pk, hString, gamma.Bytes(), kB.Bytes(), kH.Bytes())
+
+	s := edwards25519.NewScalar().MultiplyAdd(scalarFromC(c), x, k)
+
+	pi := make([]byte, 0, VRFProofLen)
+	pi = append(pi, gamma.Bytes()...)
+	pi = append(pi, c...)
+	pi = append(pi, s.Bytes()...)
+	return pi, nil
+}
+
+// VRFVerify: cek pi untuk (pk, alpha); kembalikan beta bila valid.
+func VRFVerify(pk ed25519.PublicKey, alpha, pi []byte) ([]byte, error) {
+	Y, err := stringToPoint(pk)
+	if err != nil || isSmallOrder(Y) {
+		return nil, ErrVRFPublicKey
+	}
+	gamma, c, s, err := decodeProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	H, err := encodeToCurveTAI(pk, alpha)
+	if err != nil {
+		return nil, err
+	}
+	negC := edwards25519.NewScalar().Negate(scalarFromC(c))
+	// U = s*B − c*Y, V = s*H − c*Gamma
+	U := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(negC, Y, s)
+	V := new(edwards25519.Point).VarTimeMultiScalarMult(
+		[]*edwards25519.Scalar{s, negC}, []*edwards25519.Point{H, gamma})
+
+	want := challenge(pk, H.Bytes(), gamma.Bytes(), U.Bytes(), V.Bytes())
+	if string(want) != string(c) {
+		return nil, ErrVRFProof
+	}
+	return proofToHash(gamma), nil
+}
+
+// VRFProofToHash: beta dari pi tanpa verifikasi (hanya untuk proof yang sudah dicek).
+func VRFProofToHash(pi []byte) ([]byte, error) {
+	gamma, _, _, err := decodeProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	return proofToHash(gamma), nil
+}
+
+// ================== Internal ==================
+
+// encodeToCurveTAI: try-and-increment, salt = public key (RFC 9381 §5.4.1.1).
+func encodeToCurveTAI(salt, alpha []byte) (*edwards25519.Point, error) {
+	for ctr := 0; ctr < 256; ctr++ {
+		h := sha512.New()
+		h.Write([]byte{vrfSuite, 0x01})
+		h.Write(salt)
+		h.Write(alpha)
+		h.Write([]byte{byte(ctr), 0x00})
+		if P, err := stringToPoint(h.Sum(nil)[:vrfPtLen]); err == nil {
+			return new(edwards25519.Point).MultByCofactor(P), nil
+		}
+	}
+	return nil, errors.New("vrf: encode_to_curve gagal") // peluang ~2^-256
+}
+
+// challenge: c = SHA512(suite || 0x02 || Y || H || Gamma || U || V || 0x00)[:16].
+func challenge(points ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x02})
+	for _, p := range points {
+		h.Write(p)
+	}
+	h.Write([]byte{0x00})
+	return h.Sum(nil)[:vrfCLen]
+}
+
+func proofToHash(gamma *edwards25519.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x03})
+	h.Write(new(edwards25519.Point).MultByCofactor(gamma).Bytes())
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+func decodeProof(pi []byte) (gamma *edwards25519.Point, c []byte, s *edwards25519.Scalar, err error) {
+	if len(pi) != VRFProofLen {
+		return nil, nil, nil, ErrVRFProof
+	}
+	if gamma, err = stringToPoint(pi[:vrfPtLen]); err != nil {
+		return nil, nil, nil, ErrVRFProof
+	}
+	c = pi[vrfPtLen : vrfPtLen+vrfCLen]
+	if s, err = edwards25519.NewScalar().SetCanonicalBytes(pi[vrfPtLen+vrfCLen:]); err != nil {
+		return nil, nil, nil, ErrVRFProof // s ≥ q
+	}
+	return gamma, c, s, nil
+}
+
+// scalarFromC: c 16 byte little-endian → scalar (selalu < q).
+func scalarFromC(c []byte) *edwards25519.Scalar {
+	var buf [32]byte
+	copy(buf[:], c)
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(buf[:])
+	return s
+}
+
+// stringToPoint: decoding RFC 8032 yang menolak encoding non-kanonik.
+func stringToPoint(b []byte) (*edwards25519.Point, error) {
+	P, err := new(edwards25519.Point).SetBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	if string(P.Bytes()) != string(b) {
+		return nil, errors.New("encoding titik tidak kanonik")
+	}
+	return P, nil
+}
+
+func isSmallOrder(P *edwards25519.Point) bool {
+	return new(edwards25519.Point).MultByCofactor(P).Equal(edwards25519.NewIdentityPoint()) == 1
+}
@@ -3,6 +3,7 @@ module github.com/soden46/hyperlux-chain
 go 1.25.0
 
 require (
+	filippo.io/edwards25519 v1.1.0
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0
 	github.com/dgraph-io/badger/v3 v3.2103.5
 	github.com/libp2p/go-libp2p v0.43.0
@@ -0,0 +1,208 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ================== Legacy address migration ==================
+//
+// Address lama ("hlcEd" + 4 byte pubkey) dipindah ke format bech32 baru lewat
+// TX biasa yang masuk blok: From = address legacy pubkey, To = address baru
+// pubkey yang sama, ditandatangani kunci itu (lihat SenderMatchesPubKey).
+// Saldo pindah sebagai amount (saldo − fee), dan entry validator legacy ikut
+// berganti address saat blok dieksekusi (migrateValidators), jadi semua node
+// mendapat state & state root yang sama. Tidak ada perubahan state di luar blok.
+//
+// Address legacy hanya 4 byte pubkey, jadi kunci lain dengan prefix sama mudah
+// di-grind. Karena itu migrasi hanya sah untuk address yang pubkey penuhnya
+// tercatat di genesis (LegacyKeys, ikut hash genesis & snapshot), dan hanya
+// oleh pubkey tersebut.
+
+// LegacyKeys: address legacy → pubkey Ed25519 penuh (hex) dari genesis aktif.
+var LegacyKeys = map[string]string{}
+
+func legacyKeysOf(g Genesis) map[string]string {
+	out := make(map[string]string, len(g.LegacyKeys))
+	for k, v := range g.LegacyKeys {
+		out[k] = v
+	}
+	return out
+}
+
+// isMigrationTx: TX dari address legacy ke address baru pub, dengan pub sama
+// persis dengan pubkey yang tercatat untuk address legacy tsb.
+func isMigrationTx(tx Transaction, pub []byte) bool {
+	key, ok := LegacyKeys[tx.From]
+	return ok && key == hex.EncodeToString(pub) && tx.To == wallet.AddressFromPubKey(pub)
+}
+
+// knownLegacyKeys: pubkey penuh untuk address legacy Ed25519 di state genesis
+// g, diambil dari tabel aktif, TX legacy yang tercatat di chain (pubkey
+// pertama yang menurunkan address tsb), atau wallet di folder validators/.
+// Address tanpa pubkey tercatat tidak masuk tabel (tidak bisa dimigrasi).
+func knownLegacyKeys(g Genesis) map[string]string {
+	want := map[string]bool{}
+	for addr := range g.Balances {
+		want[addr] = strings.HasPrefix(addr, "hlcEd") && wallet.IsLegacyAddress(addr)
+	}
+	for _, v := range g.Validators {
+		want[v.Address] = strings.HasPrefix(v.Address, "hlcEd") && wallet.IsLegacyAddress(v.Address)
+	}
+	out := map[string]string{}
+	record := func(pub []byte) {
+		addr := wallet.LegacyAddressFromPubKey(pub)
+		if _, done := out[addr]; !done && want[addr] && len(pub) == ed25519.PublicKeySize {
+			out[addr] = hex.EncodeToString(pub)
+		}
+	}
+	for addr, key := range LegacyKeys {
+		if want[addr] {
+			out[addr] = key
+		}
+	}
+	for h := ChainBase(); h < ChainHeight(); h++ {
+		b, ok := GetBlockByHeight(h)
+		if !ok {
+			continue
+		}
+		for _, tx := range b.Transactions {
+			if pub, err := hex.DecodeString(tx.PubKey); err == nil && tx.From == wallet.LegacyAddressFromPubKey(pub) {
+				record(pub)
+			}
+		}
+	}
+	files, _ := os.ReadDir("validators")
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		if w, err := wallet.LoadWallet(filepath.Join("validators", f.Name())); err == nil {
+			record(w.PubEd)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// NewMigrationTx membuat TX yang memindahkan seluruh saldo address legacy
+// wallet (dikurangi fee) ke address barunya.
+func NewMigrationTx(w *wallet.Wallet) (Transaction, error) {
+	legacy := w.LegacyAddressEd()
+	if legacy == "" || wallet.ValidateAddress(w.AddressEd) != nil {
+		return Transaction{}, fmt.Errorf("❌ wallet tidak punya pubkey Ed25519 yang valid")
+	}
+	if LegacyKeys[legacy] != hex.EncodeToString(w.PubEd) {
+		return Transaction{}, fmt.Errorf("❌ pubkey wallet tidak tercatat untuk %s di tabel migrasi genesis", legacy)
+	}
+	tx := Transaction{
+		Version:    TxVersion,
+		ChainID:    ChainID,
+		From:       legacy,
+		To:         w.AddressEd,
+		Nonce:      GetNextNonce(legacy),
+		ValidUntil: ChainHeight() + DefaultTxValidity,
+		PubKey:     hex.EncodeToString(w.PubEd),
+		Signature:  placeholderSignature,
+	}
+	tx.Fee = CalculateFee(tx) // amount berukuran tetap: fee tidak berubah
+	bal := GetBalance(legacy)
+	if bal <= tx.Fee {
+		return Transaction{}, fmt.Errorf("❌ saldo %s (%d) tidak cukup untuk fee migrasi %d", legacy, bal, tx.Fee)
+	}
+	tx.Amount = bal - tx.Fee
+	tx.Signature = hex.EncodeToString(w.SignEd(TxSigningBytes(tx)))
+	return tx, nil
+}
+
+// migrateValidators: validators setelah TX migrasi di txs (semua sudah
+// dieksekusi): entry legacy pengirim berganti ke address baru; bila address
+// baru sudah terdaftar, stake digabung.
+func migrateValidators(validators []ValidatorDef, txs []Transaction) []ValidatorDef {
+	var out []ValidatorDef
+	for _, tx := range txs {
+		if !wallet.IsLegacyAddress(tx.From) {
+			continue
+		}
+		src := out
+		if src == nil {
+			src = validators
+		}
+		from, to := -1, -1
+		for i, v := range src {
+			switch v.Address {
+			case tx.From:
+				from = i
+			case tx.To:
+				to = i
+			}
+		}
+		if from < 0 {
+			continue
+		}
+		out = append([]ValidatorDef(nil), src...)
+		if to < 0 {
+			out[from].Address = tx.To
+			continue
+		}
+		out[to].Stake, _ = out[to].Stake.Add(out[from].Stake) // dibatasi MaxSupply
+		out[to].JailedUntil = max(out[to].JailedUntil, out[from].JailedUntil)
+		out = append(out[:from], out[from+1:]...)
+	}
+	if out == nil {
+		return validators
+	}
+	return out
+}
+
+// queueLegacyValidatorMigrations: validator yang masih terdaftar dengan
+// address legacy dan wallet-nya ada di folder validators/ dimasukkan TX
+// migrasinya ke mempool; address berganti saat TX masuk blok.
+func queueLegacyValidatorMigrations() {
+	vals := GetValidators()
+	pending := false
+	for _, v := range vals {
+		if wallet.IsLegacyAddress(v.Address) {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return
+	}
+	files, _ := os.ReadDir("validators")
+	queued := false
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		w, err := wallet.LoadWallet(filepath.Join("validators", f.Name()))
+		if err != nil {
+			continue
+		}
+		if _, ok := findValidator(vals, w.LegacyAddressEd()); !ok {
+			continue
+		}
+		tx, err := NewMigrationTx(w)
+		if err == nil {
+			err = ValidateAndAddToMempool(tx)
+		}
+		if err != nil {
+			fmt.Printf("⚠️ migrasi validator %s belum bisa diantrikan: %v\n", w.LegacyAddressEd(), err)
+			continue
+		}
+		fmt.Printf("🔁 TX migrasi %s → %s masuk mempool\n", w.LegacyAddressEd(), w.AddressEd)
+		queued = true
+	}
+	if queued {
+		SaveMempool()
+	}
+}
@@ -0,0 +1,137 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ================== Amount ==================
+//
+// Semua saldo, amount, fee & stake memakai Amount (uint64, satuan terkecil HYLUX).
+// Invariant: total supply ≤ MaxSupply (2^63-1), jadi saldo + amount apa pun yang
+// lolos validasi tidak mungkin overflow uint64. Aritmetika lewat Add/Sub/Mul
+// yang mengembalikan error, bukan wrap-around.
+
+type Amount uint64
+
+const MaxSupply Amount = math.MaxInt64
+
+var (
+	ErrAmountOverflow  = errors.New("amount overflow")
+	ErrAmountUnderflow = errors.New("amount underflow (saldo kurang)")
+	ErrAmountZero      = errors.New("amount harus > 0")
+	ErrAmountInvalid   = errors.New("amount tidak valid")
+)
+
+func (a Amount) Add(b Amount) (Amount, error) {
+	s, carry := bits.Add64(uint64(a), uint64(b), 0)
+	if carry != 0 {
+		return 0, ErrAmountOverflow
+	}
+	return Amount(s), nil
+}
+
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b > a {
+		return 0, ErrAmountUnderflow
+	}
+	return a - b, nil
+}
+
+func (a Amount) Mul(n Amount) (Amount, error) {
+	hi, lo := bits.Mul64(uint64(a), uint64(n))
+	if hi != 0 {
+		return 0, ErrAmountOverflow
+	}
+	return Amount(lo), nil
+}
+
+// MulDiv: a*num/den dengan intermediate 128-bit (pembagian pro-rata stake).
+func (a Amount) MulDiv(num, den Amount) Amount {
+	if den == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(a), uint64(num))
+	if hi >= uint64(den) {
+		return Amount(math.MaxUint64) // hasil > uint64; tidak terjadi bila num ≤ den
+	}
+	q, _ := bits.Div64(hi, lo, uint64(den))
+	return Amount(q)
+}
+
+// Frac: a × f untuk f di [0,1] (persentase slashing/distribusi), dibulatkan ke bawah.
+func (a Amount) Frac(f float64) Amount {
+	if f <= 0 {
+		return 0
+	}
+	if f >= 1 {
+		return a
+	}
+	// presisi 1e-9 cukup untuk persen kebijakan
+	const scale = 1_000_000_000
+	return a.MulDiv(Amount(f*scale), scale)
+}
+
+// SumAmounts menjumlah dengan pengecekan overflow.
+func SumAmounts(xs ...Amount) (Amount, error) {
+	var total Amount
+	var err error
+	for _, x := range xs {
+		if total, err = total.Add(x); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// ParseAmount: bilangan bulat desimal non-negatif ≤ MaxSupply (tanpa tanda).
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s[0] == '+' || s[0] == '-' {
+		return 0, fmt.Errorf("%w: %q", ErrAmountInvalid, s)
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || Amount(v) > MaxSupply {
+		return 0, fmt.Errorf("%w: %q", ErrAmountInvalid, s)
+	}
+	return Amount(v), nil
+}
+
+// ParsePositiveAmount: seperti ParseAmount, tapi 0 ditolak (amount transfer).
+func ParsePositiveAmount(s string) (Amount, error) {
+	a, err := ParseAmount(s)
+	if err == nil && a == 0 {
+		err = ErrAmountZero
+	}
+	return a, err
+}
+
+// mustCredit: kredit saldo; overflow berarti invariant MaxSupply dilanggar.
+func mustCredit(balances map[string]Amount, addr string, amt Amount) {
+	v, err := balances[addr].Add(amt)
+	if err != nil {
+		panic(fmt.Sprintf("ledger: invariant supply dilanggar saat kredit %s: %v", addr, err))
+	}
+	balances[addr] = v
+}
+
+// totalSupply: saldo akun + stake + treasury (burned tidak dihitung).
+func totalSupply(balances map[string]Amount, validators []ValidatorDef) (Amount, error) {
+	total, _ := Supply()
+	var err error
+	for _, b := range balances {
+		if total, err = total.Add(b); err != nil {
+			return 0, err
+		}
+	}
+	for _, v := range validators {
+		if total, err = total.Add(v.Stake); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
@@ -1,6 +1,7 @@
 package ledger
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -17,12 +18,18 @@ type Block struct {
 	PrevHash     string        `json:"prev_hash"`
 	Hash         string        `json:"hash"`
 	MerkleRoot   string        `json:"merkle_root"`
-	Proposer     string        `json:"proposer"` // validator address
+	StateRoot    string        `json:"state_root"` // SMT root setelah blok dieksekusi
+	Proposer     string        `json:"proposer"`   // validator address
+	ProposerKey  string        `json:"proposer_pubkey"`
+	Signature    string        `json:"signature"` // ed25519(proposer, hash)
 	Transactions []Transaction `json:"transactions"`
+	VRF          *VRFHeader    `json:"vrf,omitempty"`      // proof undian proposer, ikut hash header
+	PoH          *PoHRecord    `json:"poh,omitempty"`      // segmen PoH sejak parent (ringkasan ikut hash header)
+	Evidence     []Evidence    `json:"evidence,omitempty"` // bukti equivocation → jail (jail.go), ikut hash header
+	Commit       *CommitCert   `json:"commit,omitempty"`   // precommit > 2/3 stake, di luar hash header
+	Pruned       bool          `json:"pruned,omitempty"`   // body sudah dipangkas (prune.go): MerkleRoot tidak bisa dicek ulang
 }
 
-var Blockchain []Block
-
 // ================== Helpers ==================
 
 func ComputeMerkleRoot(txs []Transaction) string {
@@ -54,97 +61,143 @@ func ComputeMerkleRoot(txs []Transaction) string {
 	return hex.EncodeToString(hashes[0])
 }
 
-func hashBlockHeader(idx int, ts int64, prev, merkle, proposer string) string {
-	header := fmt.Sprintf("%d|%d|%s|%s|%s", idx, ts, prev, merkle, proposer)
-	sum := sha256.Sum256([]byte(header))
+// hashBlockHeader: sha256 encoding kanonik header; ikut mengikat ChainID,
+// jadi blok dari chain lain gagal cek hash.
+func hashBlockHeader(b Block) string {
+	return hashBlockHeaderFor(ChainID, b)
+}
+
+// HeaderHash: hash header b menurut chain ID aktif (nilai yang wajib ada di b.Hash).
+func (b Block) HeaderHash() string {
+	return hashBlockHeader(b)
+}
+
+func hashBlockHeaderFor(chainID string, b Block) string {
+	sum := sha256.Sum256(encodeHeaderForHash(chainID, b))
 	return hex.EncodeToString(sum[:])
 }
 
-func NewBlock(index int, txs []Transaction, prevHash string, proposerWallet *wallet.Wallet) Block {
+func NewBlock(index int, txs []Transaction, prevHash, stateRoot string, proposerWallet *wallet.Wallet) Block {
+	return newBlock(index, txs, prevHash, stateRoot, proposerWallet, nil, nil, nil)
+}
+
+func newBlock(index int, txs []Transaction, prevHash, stateRoot string, proposerWallet *wallet.Wallet, vrf *VRFHeader, poh *PoHRecord, evidence []Evidence) Block {
 	ts := time.Now().Unix()
 	proposer := ""
 	if proposerWallet != nil {
 		proposer = proposerWallet.AddressEd
 	}
-	mr := ComputeMerkleRoot(txs)
-	h := hashBlockHeader(index, ts, prevHash, mr, proposer)
-	return Block{
+	b := Block{
 		Index:        index,
 		Timestamp:    ts,
 		PrevHash:     prevHash,
-		Hash:         h,
-		MerkleRoot:   mr,
+		MerkleRoot:   ComputeMerkleRoot(txs),
+		StateRoot:    stateRoot,
 		Proposer:     proposer,
 		Transactions: txs,
+		VRF:          vrf,
+		PoH:          poh,
+		Evidence:     evidence,
+	}
+	b.Hash = hashBlockHeader(b)
+	if proposerWallet != nil {
+		signBlock(&b, proposerWallet)
 	}
+	return b
 }
 
-// ================== Checkpoint (stub) ==================
+// ================== Proposer signature ==================
 
-func AddCheckpoint(b Block) {
-	// diimpl sederhana; bisa disambung ke snapshot/fast sync nantinya
-	// no-op selain memastikan API tersedia
+// signBlock: proposer menandatangani hash header (bytes) dengan kunci Ed25519.
+func signBlock(b *Block, w *wallet.Wallet) {
+	hb, _ := hex.DecodeString(b.Hash)
+	b.ProposerKey = hex.EncodeToString(w.PubEd)
+	b.Signature = hex.EncodeToString(w.SignEd(hb))
 }
 
-// ================== Committing blocks ==================
-
-// AddBlock (legacy/dev): ambil TX dari mempool tanpa validasi batch state.
-func AddBlock(val *ValidatorDef, valWallet *wallet.Wallet) Block {
-	if len(Blockchain) == 0 {
-		genesis := NewBlock(0, []Transaction{}, "0", nil)
-		Blockchain = append(Blockchain, genesis)
+// VerifyBlockSignature: pubkey harus menurunkan address Proposer & signature valid atas hash.
+func VerifyBlockSignature(b Block) error {
+	pub, err := hex.DecodeString(b.ProposerKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("proposer pubkey invalid")
 	}
-
-	last := Blockchain[len(Blockchain)-1]
-
-	// snapshot mempool
-	MempoolMu.RLock()
-	txs := make([]Transaction, len(Mempool))
-	copy(txs, Mempool)
-	MempoolMu.RUnlock()
-
-	newBlock := NewBlock(len(Blockchain), txs, last.Hash, valWallet)
-	Blockchain = append(Blockchain, newBlock)
-
-	// reset mempool
-	ClearMempool()
-
-	SaveAllData()
-
-	fmt.Printf("✅ Block %d committed by %s with %d txs\n",
-		newBlock.Index, val.Address, len(newBlock.Transactions))
-	fmt.Printf("   MerkleRoot: %s | Timestamp: %d\n",
-		newBlock.MerkleRoot, newBlock.Timestamp)
-
-	return newBlock
-}
-
-// AddBlockWithTxs: commit block menggunakan TX valid & bagi fee + reward.
-func AddBlockWithTxs(val *ValidatorDef, _ *wallet.Wallet, txs []Transaction) Block {
-	if len(Blockchain) == 0 {
-		genesis := NewBlock(0, []Transaction{}, "0", nil)
-		Blockchain = append(Blockchain, genesis)
+	if wallet.AddressFromPubKey(pub) != b.Proposer {
+		return fmt.Errorf("pubkey bukan milik proposer %s", b.Proposer)
 	}
+	sig, err := hex.DecodeString(b.Signature)
+	if err != nil {
+		return fmt.Errorf("signature bukan hex")
+	}
+	hb, err := hex.DecodeString(b.Hash)
+	if err != nil {
+		return fmt.Errorf("hash bukan hex")
+	}
+	if !ed25519.Verify(pub, hb, sig) {
+		return fmt.Errorf("signature proposer tidak valid")
+	}
+	return nil
+}
 
-	last := Blockchain[len(Blockchain)-1]
-	newBlock := NewBlock(len(Blockchain), txs, last.Hash, nil)
-	Blockchain = append(Blockchain, newBlock)
-
-	// fee & reward tetap
-	totalFees := 0
+// creditProposer: proposer menerima total fee + Params.BlockReward.
+func creditProposer(balances map[string]Amount, proposer string, txs []Transaction) error {
+	total := Params.BlockReward
+	var err error
 	for _, tx := range txs {
-		totalFees += tx.Fee
+		if total, err = total.Add(tx.Fee); err != nil {
+			return err
+		}
 	}
-	BalanceMu.Lock()
-	Balances[val.Address] += totalFees + 5 // contoh reward tetap
-	BalanceMu.Unlock()
+	v, err := balances[proposer].Add(total)
+	if err != nil {
+		return err
+	}
+	balances[proposer] = v
+	return nil
+}
 
-	SaveAllData()
+// ================== Committing blocks ==================
 
-	fmt.Printf("✅ Block %d committed by %s with %d txs\n",
-		newBlock.Index, val.Address, len(newBlock.Transactions))
-	fmt.Printf("   MerkleRoot: %s | Timestamp: %d\n",
-		newBlock.MerkleRoot, newBlock.Timestamp)
+// ensureGenesis: buat genesis jika store kosong, lalu kembalikan head.
+func ensureGenesis() Block {
+	if head, ok := HeadBlock(); ok {
+		return head
+	}
+	genesis, err := createDevGenesis()
+	if err != nil {
+		fmt.Println("❌ gagal simpan genesis:", err)
+	}
+	return genesis
+}
 
-	return newBlock
+// BuildProposalBlock: blok kandidat di atas head tanpa mengubah state global.
+// TX dieksekusi di salinan akun yang disentuh; blok baru di-commit lewat
+// ImportBlock setelah quorum precommit. snap & results dipakai untuk receipt
+// TX yang gagal. vrf = proof undian proposer (ProveProposer) yang ikut ke
+// header; segmen PoH sejak parent direkam dari generator lokal; bukti
+// equivocation yang menunggu ikut dimasukkan.
+func BuildProposalBlock(val *ValidatorDef, valWallet *wallet.Wallet, vrf VRFHeader) (b Block, snap []Transaction, results []error, err error) {
+	last := ensureGenesis()
+	height := last.Index + 1
+
+	// ruang evidence dipesan sebelum TX dipilih (ValidateBlock menghitungnya
+	// dalam MaxBlockBytes); bukti selalu didahulukan dari TX
+	budget := Params.MaxBlockBytes - blockOverhead(val.Address)
+	prev := GetValidators()
+	evidence, _ := pendingEvidence(height, prev, budget)
+	snap = selectBlockTxs(Pool.Pending(0), val.Address, evidenceBytes(evidence))
+	balances, nonces := touchedAccounts(snap, val.Address)
+	results = ExecuteTxsResults(snap, height, balances, nonces)
+	txs := AcceptedTxs(snap, results)
+	if err := creditProposer(balances, val.Address, txs); err != nil {
+		return Block{}, nil, nil, fmt.Errorf("❌ reward proposer: %w", err)
+	}
+	poh, err := recorder.record(last, txs)
+	if err != nil {
+		return Block{}, nil, nil, err
+	}
+	// set final setelah migrasi address di txs; dibatasi sisa ruang TX yang diterima
+	evidence, validators := pendingEvidence(height, migrateValidators(prev, txs), budget-txBytes(txs))
+	root := treeRootHex(stateTreeAfter(last, height, balances, nonces, prev, validators))
+	b = newBlock(height, txs, last.Hash, root, valWallet, &vrf, poh, evidence)
+	return b, snap, results, nil
 }
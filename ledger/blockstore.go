@@ -0,0 +1,459 @@
+package ledger
+
+import (
+	"container/list"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ================== Block Store ==================
+//
+// Layout di LevelDB:
+//   b/h/<height 8-byte BE> → block (encoding kanonik, lihat codec.go)
+//   b/x/<hash>             → height (8-byte BE)
+//   b/head                 → height head (8-byte BE)
+//   b/base                 → height blok terendah (node hasil restore snapshot)
+//   b/pruned               → body TX ≤ height ini sudah dihapus (lihat prune.go)
+//   i/...                  → receipt & index TX per address (lihat receipt.go)
+//   h/...                  → versi state per blok (lihat history.go)
+//   epoch/<epoch>          → active set per epoch (lihat epoch.go)
+//   state/...              → state setelah head (akun per key), ditulis di batch
+//                            yang sama dengan blok (lihat storage.go, recoverState)
+//
+// Hanya head yang disimpan di memori; blok lain di-load lazily lewat LRU cache.
+
+const (
+	prefixBlockByHeight = "b/h/"
+	prefixBlockByHash   = "b/x/"
+	keyChainHead        = "b/head"
+
+	legacyBlockchainKey = "blockchain" // format lama: seluruh chain dalam satu blob JSON
+
+	blockCacheSize = 1024
+)
+
+var (
+	chainMu    sync.RWMutex
+	headHeight = -1
+	headBlock  Block
+
+	blockCache = newBlockLRU(blockCacheSize)
+)
+
+func heightKey(h int) []byte {
+	k := make([]byte, len(prefixBlockByHeight)+8)
+	copy(k, prefixBlockByHeight)
+	binary.BigEndian.PutUint64(k[len(prefixBlockByHeight):], uint64(h))
+	return k
+}
+
+func hashKey(hash string) []byte {
+	return []byte(prefixBlockByHash + hash)
+}
+
+func encodeHeight(h int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(h))
+	return b
+}
+
+func decodeHeight(b []byte) (int, bool) {
+	if len(b) != 8 {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint64(b)), true
+}
+
+// ================== Public API ==================
+
+// ChainHeight: jumlah blok yang tersimpan (head index + 1), 0 jika kosong.
+func ChainHeight() int {
+	chainMu.RLock()
+	defer chainMu.RUnlock()
+	return headHeight + 1
+}
+
+// HeadBlock mengembalikan blok terakhir.
+func HeadBlock() (Block, bool) {
+	chainMu.RLock()
+	defer chainMu.RUnlock()
+	if headHeight < 0 {
+		return Block{}, false
+	}
+	return headBlock, true
+}
+
+// GetBlockByHeight: cache dulu, lalu LevelDB.
+func GetBlockByHeight(h int) (Block, bool) {
+	if h < 0 {
+		return Block{}, false
+	}
+	if b, ok := blockCache.get(h); ok {
+		return b, true
+	}
+	InitDB()
+	data, err := db.Get(heightKey(h), nil)
+	if err != nil {
+		return Block{}, false
+	}
+	b, err := decodeStoredBlock(data)
+	if err != nil {
+		fmt.Printf("⚠️ block %d corrupt: %v\n", h, err)
+		return Block{}, false
+	}
+	blockCache.add(h, b)
+	return b, true
+}
+
+// GetBlockByHash lookup via index hash → height.
+func GetBlockByHash(hash string) (Block, bool) {
+	InitDB()
+	data, err := db.Get(hashKey(hash), nil)
+	if err != nil {
+		return Block{}, false
+	}
+	h, ok := decodeHeight(data)
+	if !ok {
+		return Block{}, false
+	}
+	return GetBlockByHeight(h)
+}
+
+// AppendBlock menulis blok baru di atas head (height, index hash, head, receipt,
+// versi state, state + state/height, active set epoch berikutnya di boundary)
+// dalam satu batch ber-fsync.
+// State global harus sudah berisi hasil blok.
+func AppendBlock(b Block) error {
+	return appendBlock(b, nil)
+}
+
+// appendBlock: st nil = simpan state global saat ini (seluruh akun); selain itu
+// state hasil re-eksekusi blok (ImportBlock): hanya akun yang disentuh blok yang
+// ditulis & digabung ke state global setelah batch tertulis, bersama validators
+// setelah bukti jail blok dan pohon state sebagai basis blok berikutnya.
+func appendBlock(b Block, st *blockState) error {
+	InitDB()
+	// sebelum chainMu: membaca lock state
+	var sv *stateVersion
+	var enc encodedState
+	var err error
+	validators := GetValidators()
+	if st == nil {
+		sv = prepareStateVersion(b.Index)
+		enc, err = encodeState()
+	} else {
+		validators = st.validators
+		sv = prepareStateVersionOf(b.Index, st.balances, st.nonces, validators, false)
+		enc, err = encodeStateOf(st.balances, st.nonces, validators)
+	}
+	if err != nil {
+		return fmt.Errorf("❌ encode state: %w", err)
+	}
+	epoch, publish := pendingEpochSet(b, validators)
+	chainMu.Lock()
+	defer chainMu.Unlock()
+
+	if b.Index != headHeight+1 {
+		if known, ok := GetBlockByHeight(b.Index); ok && known.Hash == b.Hash {
+			return ErrBlockKnown
+		}
+		return fmt.Errorf("❌ block index %d tidak menyambung ke head %d", b.Index, headHeight)
+	}
+	batch := new(leveldb.Batch)
+	batch.Put(heightKey(b.Index), EncodeBlock(b))
+	batch.Put(hashKey(b.Hash), encodeHeight(b.Index))
+	batch.Put([]byte(keyChainHead), encodeHeight(b.Index))
+	indexBlock(batch, b)
+	sv.write(batch)
+	enc.put(batch)
+	batch.Put([]byte(keyStateHeight), encodeHeight(b.Index))
+	if publish {
+		putEpochSet(batch, epoch)
+	}
+	if err := db.Write(batch, syncWrite); err != nil {
+		return err
+	}
+	sv.commit()
+	if publish {
+		epochPublished(epoch)
+	}
+	if st != nil {
+		BalanceMu.Lock()
+		NonceTableMu.Lock()
+		mergeAccounts(st.balances, st.nonces)
+		NonceTableMu.Unlock()
+		BalanceMu.Unlock()
+		setValidators(validators)
+		setHeadStateTree(b.Hash, st.tree)
+	}
+
+	headHeight = b.Index
+	headBlock = b
+	blockCache.add(b.Index, b)
+	schedulePrune()
+	recorder.committed(b)
+	return nil
+}
+
+// mergeAccounts memasang akun hasil blok ke state global (pemanggil memegang
+// BalanceMu & NonceTableMu); akun bernilai nol dihapus.
+func mergeAccounts(balances map[string]Amount, nonces map[string]int) {
+	for addr, bal := range balances {
+		if bal == 0 {
+			delete(Balances, addr)
+		} else {
+			Balances[addr] = bal
+		}
+	}
+	for addr, n := range nonces {
+		if n == 0 {
+			delete(NonceTable, addr)
+		} else {
+			NonceTable[addr] = n
+		}
+	}
+}
+
+// LoadBlockchain memuat head pointer (dan migrasi sekali dari blob lama jika ada).
+func LoadBlockchain() {
+	InitDB()
+	if err := migrateLegacyBlockchain(); err != nil {
+		fmt.Println("⚠️ migrasi blockchain lama gagal:", err)
+	}
+
+	data, err := db.Get([]byte(keyChainHead), nil)
+	if err != nil {
+		return
+	}
+	h, ok := decodeHeight(data)
+	if !ok {
+		return
+	}
+	b, ok := GetBlockByHeight(h)
+	if !ok {
+		fmt.Printf("⚠️ head block %d tidak ditemukan\n", h)
+		return
+	}
+	chainMu.Lock()
+	headHeight = h
+	headBlock = b
+	chainMu.Unlock()
+
+	reindexTxs(h)
+}
+
+// recoverState memastikan state tersimpan = efek head (state/height = head).
+// Beda berarti commit format lama terputus di tengah (blok tersimpan, state
+// belum): state dibangun ulang dari versi state history. Bila root hasilnya
+// tidak cocok dengan head, head dimundurkan ke height tertinggi yang root-nya
+// cocok (blok di atasnya disinkron ulang); tanpa height yang cocok node
+// menolak start.
+func recoverState() {
+	head := ChainHeight() - 1
+	if head < 0 {
+		return
+	}
+	data, err := db.Get([]byte(keyStateHeight), nil)
+	if err == leveldb.ErrNotFound {
+		// DB sebelum commit atomik: validator/treasury pindah ke DB, state = head
+		if err := writeStateAt(head); err != nil {
+			fmt.Println("⚠️ gagal migrasi state ke DB:", err)
+		}
+		return
+	}
+	stored, ok := decodeHeight(data)
+	if ok && stored == head {
+		return
+	}
+	fmt.Printf("⚠️ State tersimpan untuk block %d, head %d: memulihkan dari state history\n", stored, head)
+	for h := head; h >= ChainBase(); h-- {
+		st, err := stateAt(h)
+		if err != nil {
+			log.Fatalf("❌ recovery state gagal di block %d: %v (restore snapshot / sync ulang)", h, err)
+		}
+		b, ok := GetBlockByHeight(h)
+		if !ok {
+			log.Fatalf("❌ recovery state: block %d tidak ditemukan", h)
+		}
+		root := st.rootHex()
+		if b.StateRoot != "" && root != b.StateRoot {
+			fmt.Printf("⚠️ state root history block %d %.12s ≠ header %.12s\n", h, root, b.StateRoot)
+			continue
+		}
+		BalanceMu.Lock()
+		Balances = st.balances
+		BalanceMu.Unlock()
+		NonceTableMu.Lock()
+		NonceTable = st.nonces
+		NonceTableMu.Unlock()
+		setValidators(st.validators)
+		setSupply(st.treasury, st.burned)
+		if err := rollbackHead(h, head); err != nil {
+			log.Fatalf("❌ gagal simpan state hasil recovery: %v", err)
+		}
+		fmt.Printf("🔧 State dipulihkan ke block %d (root %.12s)\n", h, root)
+		return
+	}
+	log.Fatalf("❌ recovery state: tidak ada block %d..%d dengan state root cocok (restore snapshot / sync ulang)", ChainBase(), head)
+}
+
+// rollbackHead menulis state global sebagai state block h dan, bila h < head,
+// membuang block h+1..head beserta index, versi history & set epoch yang
+// dipublikasikannya (satu batch). Block tsb diambil ulang lewat sync.
+func rollbackHead(h, head int) error {
+	batch := new(leveldb.Batch)
+	st, err := encodeState()
+	if err != nil {
+		return err
+	}
+	st.put(batch)
+	batch.Put([]byte(keyStateHeight), encodeHeight(h))
+	if h < head {
+		for i := h + 1; i <= head; i++ {
+			b, ok := GetBlockByHeight(i)
+			if !ok {
+				continue
+			}
+			batch.Delete(heightKey(i))
+			batch.Delete(hashKey(b.Hash))
+			unindexBlock(batch, b)
+		}
+		dropHistoryAbove(batch, h)
+		for e := EpochOf(h+1) + 1; e <= EpochOf(head+1); e++ {
+			batch.Delete(epochKey(e))
+		}
+		batch.Put([]byte(keyChainHead), encodeHeight(h))
+		batch.Put([]byte(keyIndexHeight), encodeHeight(h))
+	}
+	if err := db.Write(batch, syncWrite); err != nil {
+		return err
+	}
+	resetHeadStateTree()
+	if h < head {
+		b, _ := GetBlockByHeight(h)
+		chainMu.Lock()
+		headHeight, headBlock = h, b
+		chainMu.Unlock()
+		blockCache.clear()
+		clearEpochCache()
+		fmt.Printf("⏪ Head dimundurkan %d → %d, block di atasnya disinkron ulang\n", head, h)
+	}
+	return nil
+}
+
+// writeStateAt: state global + state/height dalam satu batch.
+func writeStateAt(height int) error {
+	st, err := encodeState()
+	if err != nil {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	st.put(batch)
+	batch.Put([]byte(keyStateHeight), encodeHeight(height))
+	return db.Write(batch, syncWrite)
+}
+
+// migrateLegacyBlockchain memecah key "blockchain" (JSON []Block) ke skema per-blok.
+func migrateLegacyBlockchain() error {
+	data, err := db.Get([]byte(legacyBlockchainKey), nil)
+	if err == leveldb.ErrNotFound || len(data) == 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var legacy []Block
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	for _, b := range legacy {
+		batch.Put(heightKey(b.Index), EncodeBlock(b))
+		batch.Put(hashKey(b.Hash), encodeHeight(b.Index))
+	}
+	if len(legacy) > 0 {
+		batch.Put([]byte(keyChainHead), encodeHeight(legacy[len(legacy)-1].Index))
+	}
+	batch.Delete([]byte(legacyBlockchainKey))
+	if err := db.Write(batch, syncWrite); err != nil {
+		return err
+	}
+	fmt.Printf("🔁 Migrated %d blocks ke block store per-height\n", len(legacy))
+	return nil
+}
+
+// decodeStoredBlock: blok versi awal store masih tersimpan sebagai JSON.
+func decodeStoredBlock(data []byte) (Block, error) {
+	if isBinaryEncoded(data) {
+		return DecodeBlock(data)
+	}
+	var b Block
+	err := json.Unmarshal(data, &b)
+	return b, err
+}
+
+// ================== LRU cache ==================
+
+type blockLRU struct {
+	mu    sync.Mutex
+	cap   int
+	ll    *list.List
+	items map[int]*list.Element
+}
+
+type lruEntry struct {
+	height int
+	block  Block
+}
+
+func newBlockLRU(capacity int) *blockLRU {
+	return &blockLRU{cap: capacity, ll: list.New(), items: map[int]*list.Element{}}
+}
+
+func (c *blockLRU) get(h int) (Block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[h]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*lruEntry).block, true
+	}
+	return Block{}, false
+}
+
+func (c *blockLRU) remove(h int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[h]; ok {
+		c.ll.Remove(el)
+		delete(c.items, h)
+	}
+}
+
+func (c *blockLRU) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = map[int]*list.Element{}
+}
+
+func (c *blockLRU) add(h int, b Block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[h]; ok {
+		el.Value.(*lruEntry).block = b
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[h] = c.ll.PushFront(&lruEntry{height: h, block: b})
+	if c.ll.Len() > c.cap {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).height)
+	}
+}
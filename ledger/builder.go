@@ -0,0 +1,90 @@
+package ledger
+
+import (
+	"strings"
+)
+
+// ================== Block builder ==================
+//
+// Memilih TX dari mempool berdasarkan prioritas fee (TxPool.Pending) sampai
+// Params.MaxBlockBytes / Params.MaxBlockTxs. TX yang tidak muat tetap di
+// mempool untuk slot berikutnya. ValidateBlock menegakkan batas yang sama.
+
+// minBlockBytes: header + satu TX harus muat (dicek di genesis).
+const minBlockBytes = 1024
+
+// blockOverhead: ukuran EncodeBlock tanpa TX dengan semua field hex terisi
+// penuh (hash 32 byte, pubkey 32, signature 64, proof VRF 80) plus ringkasan
+// PoH, jadi batas atas yang aman. Entry PoH & sertifikat commit tidak dihitung
+// ValidateBlock; evidence dihitung, jadi ruangnya dipesan terpisah
+// (evidenceBytes).
+func blockOverhead(proposer string) int {
+	h := strings.Repeat("00", 32)
+	b := Block{
+		PrevHash:    h,
+		MerkleRoot:  h,
+		StateRoot:   h,
+		Hash:        h,
+		Proposer:    proposer,
+		ProposerKey: h,
+		Signature:   h + h,
+		VRF:         &VRFHeader{Seed: h, Proof: strings.Repeat("00", 80)},
+		PoH:         &PoHRecord{Hash: h, Root: h},
+	}
+	return len(EncodeBlock(b))
+}
+
+// evidenceBytes: tambahan ukuran EncodeBlock untuk section evidence evs.
+func evidenceBytes(evs []Evidence) int {
+	if len(evs) == 0 {
+		return 0
+	}
+	return len(EncodeBlock(Block{Evidence: evs})) - len(EncodeBlock(Block{}))
+}
+
+// txBytes: ukuran TX di EncodeBlock (prefix panjang + tx).
+func txBytes(txs []Transaction) int {
+	n := 0
+	for _, tx := range txs {
+		n += 4 + len(EncodeTransaction(tx))
+	}
+	return n
+}
+
+// SelectBlockTxs: ambil TX dari candidates (urut prioritas) selama muat di blok.
+// Jika TX sender tidak muat, TX berikutnya dari sender yang sama ikut dilewati
+// supaya nonce tidak bolong.
+func SelectBlockTxs(candidates []Transaction, proposer string) []Transaction {
+	return selectBlockTxs(candidates, proposer, 0)
+}
+
+// selectBlockTxs: seperti SelectBlockTxs dengan reserved byte dipesan untuk
+// section lain yang dihitung ValidateBlock (evidence).
+func selectBlockTxs(candidates []Transaction, proposer string, reserved int) []Transaction {
+	maxBytes, maxTxs := Params.MaxBlockBytes, Params.MaxBlockTxs
+	used := blockOverhead(proposer) + reserved
+	out := make([]Transaction, 0, min(len(candidates), maxTxs))
+	skipped := map[string]bool{}
+
+	for _, tx := range candidates {
+		if len(out) >= maxTxs || maxBytes-used < 4 {
+			break
+		}
+		if skipped[tx.From] {
+			continue
+		}
+		size := txBytes([]Transaction{tx})
+		if used+size > maxBytes {
+			skipped[tx.From] = true
+			continue
+		}
+		used += size
+		out = append(out, tx)
+	}
+	return out
+}
+
+// BuildBlockTxs: kandidat blok berikutnya dari mempool untuk proposer tsb.
+func BuildBlockTxs(proposer string) []Transaction {
+	return SelectBlockTxs(Pool.Pending(0), proposer)
+}
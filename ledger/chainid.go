@@ -0,0 +1,24 @@
+package ledger
+
+// ================== Chain ID ==================
+//
+// Chain ID ditetapkan saat genesis (genesis.json atau HYPERLUX_CHAIN_ID untuk
+// devnet ad-hoc) dan disimpan di DB. Semua TX menandatangani
+// chain ID ini dan hash header blok ikut mengikatnya, jadi TX/blok devnet tidak
+// bisa di-replay ke testnet/mainnet.
+
+const (
+	DefaultChainID = "hyperlux-devnet"
+	keyChainID     = "meta/chain_id"
+)
+
+var ChainID = DefaultChainID
+
+// LoadChainID membaca chain ID dari DB (jika genesis sudah dibuat).
+func LoadChainID() {
+	InitDB()
+	data, err := db.Get([]byte(keyChainID), nil)
+	if err == nil && len(data) > 0 {
+		ChainID = string(data)
+	}
+}
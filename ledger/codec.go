@@ -0,0 +1,469 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ================== Canonical binary codec ==================
+//
+// Dipakai untuk hash, fee & pesan wire; JSON hanya untuk tampilan/CLI.
+// Aturan encoding (deterministik, satu representasi per nilai):
+//   - integer   → 8 byte big-endian (ukuran tidak bergantung nilai, fee stabil)
+//   - string    → uint32 BE panjang + bytes
+//   - hex field → tag 0x01 + uint32 panjang + raw bytes bila hex lowercase valid,
+//                 selain itu tag 0x00 + string apa adanya (hex uppercase / huruf
+//                 campuran ditolak decoder: satu nilai, satu encoding)
+//   - list      → uint32 BE jumlah item + item
+// Setiap pesan diawali satu byte tag tipe.
+
+// ================== Tag registry ==================
+//
+// Semua tag pesan (ledger & network) didaftarkan di sini supaya nilainya unik;
+// tag baru ambil nilai kosong berikutnya di kelompoknya.
+const (
+	// transaksi & blok
+	tagTx         byte = 0x01
+	tagTxSigning  byte = 0x02
+	tagTxList     byte = 0x03
+	tagMempool    byte = 0x04 // TX + waktu masuk mempool
+	tagBlock      byte = 0x10
+	tagHeader     byte = 0x11
+	tagParams     byte = 0x12 // params chain, diikat hash genesis
+	tagLegacyKeys byte = 0x13 // tabel migrasi address legacy, diikat hash genesis
+
+	// state tersimpan (receipt, history, snapshot)
+	tagReceipt            byte = 0x20
+	tagValidatorSetLegacy byte = 0x21 // hanya dibaca (history/snapshot lama)
+	tagSnapshot           byte = 0x22
+	tagValidatorSet       byte = 0x23
+
+	// konsensus (vote BFT, VRF, PoH, epoch)
+	tagVote            byte = 0x30
+	tagVoteSigning     byte = 0x31
+	tagProposal        byte = 0x32
+	tagProposalSigning byte = 0x33
+	tagVRFAlpha        byte = 0x34
+	tagVRFSeed         byte = 0x35
+	tagPoHStart        byte = 0x36
+	tagPoHEntries      byte = 0x37
+	tagPoHStream       byte = 0x38
+	tagEpochSet        byte = 0x39
+
+	// pesan wire network (hanya dipakai package network)
+	TagMiniBlock       byte = 0x40
+	TagMiniBlockHeader byte = 0x41
+)
+
+const (
+	// section opsional setelah TX blok; sertifikat commit (lama, tanpa marker)
+	// selalu diawali byte 0x00 (height 8 byte BE) jadi tidak bentrok
+	blockExtVRF      byte = 0x01
+	blockExtPoH      byte = 0x02
+	blockExtPruned   byte = 0x03 // blok tersimpan tanpa body (prune.go)
+	blockExtEvidence byte = 0x04 // bukti equivocation (jail.go)
+)
+
+var ErrNonCanonical = errors.New("encoding tidak kanonik")
+
+type Encoder struct {
+	buf []byte
+}
+
+func NewEncoder(tag byte) *Encoder {
+	return &Encoder{buf: []byte{tag}}
+}
+
+func (e *Encoder) Bytes() []byte { return e.buf }
+
+func (e *Encoder) Uint64(v uint64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+}
+
+func (e *Encoder) Int64(v int64) { e.Uint64(uint64(v)) }
+func (e *Encoder) Int(v int)     { e.Uint64(uint64(int64(v))) }
+
+func (e *Encoder) Len(n int) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+}
+
+func (e *Encoder) Raw(b []byte) {
+	e.Len(len(b))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *Encoder) String(s string) {
+	e.Len(len(s))
+	e.buf = append(e.buf, s...)
+}
+
+// Hex: field hex (hash, pubkey, signature) disimpan sebagai raw bytes bila kanonik.
+func (e *Encoder) Hex(s string) {
+	if b, ok := canonicalHex(s); ok {
+		e.buf = append(e.buf, 0x01)
+		e.Raw(b)
+		return
+	}
+	e.buf = append(e.buf, 0x00)
+	e.String(s)
+}
+
+// canonicalHex: hanya hex lowercase yang dianggap kanonik (uppercase = malleable).
+func canonicalHex(s string) ([]byte, bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || hex.EncodeToString(b) != s {
+		return nil, false
+	}
+	return b, true
+}
+
+type Decoder struct {
+	buf []byte
+	off int
+	err error
+}
+
+func NewDecoder(data []byte, tag byte) *Decoder {
+	d := &Decoder{buf: data}
+	if len(data) == 0 || data[0] != tag {
+		d.err = fmt.Errorf("tag pesan salah (want 0x%02x)", tag)
+		return d
+	}
+	d.off = 1
+	return d
+}
+
+func (d *Decoder) Err() error { return d.err }
+
+// Finish: error jika ada sisa byte (mencegah trailing garbage).
+func (d *Decoder) Finish() error {
+	if d.err == nil && d.off != len(d.buf) {
+		d.err = fmt.Errorf("%w: %d byte sisa", ErrNonCanonical, len(d.buf)-d.off)
+	}
+	return d.err
+}
+
+// More: masih ada byte tersisa (field opsional di akhir pesan).
+func (d *Decoder) More() bool { return d.err == nil && d.off < len(d.buf) }
+
+func (d *Decoder) peek() byte {
+	if !d.More() {
+		return 0
+	}
+	return d.buf[d.off]
+}
+
+func (d *Decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.off+n > len(d.buf) {
+		d.err = errors.New("data terpotong")
+		return nil
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *Decoder) Uint64() uint64 {
+	b := d.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *Decoder) Int64() int64 { return int64(d.Uint64()) }
+func (d *Decoder) Int() int     { return int(d.Int64()) }
+
+func (d *Decoder) Len() int {
+	b := d.take(4)
+	if b == nil {
+		return 0
+	}
+	n := int(binary.BigEndian.Uint32(b))
+	if n > len(d.buf)-d.off {
+		d.err = errors.New("panjang melebihi data")
+		return 0
+	}
+	return n
+}
+
+func (d *Decoder) Raw() []byte {
+	b := d.take(d.Len())
+	return append([]byte(nil), b...)
+}
+
+func (d *Decoder) String() string {
+	return string(d.take(d.Len()))
+}
+
+func (d *Decoder) Hex() string {
+	flag := d.take(1)
+	if flag == nil {
+		return ""
+	}
+	switch flag[0] {
+	case 0x01:
+		return hex.EncodeToString(d.take(d.Len()))
+	case 0x00:
+		s := d.String()
+		if _, err := hex.DecodeString(s); err == nil && d.err == nil {
+			d.err = fmt.Errorf("%w: hex valid harus lowercase dengan tag 0x01", ErrNonCanonical)
+		}
+		return s
+	default:
+		d.err = fmt.Errorf("%w: flag hex 0x%02x", ErrNonCanonical, flag[0])
+		return ""
+	}
+}
+
+// ================== Transaction ==================
+
+func encodeTxFields(e *Encoder, tx Transaction) {
+	e.Int(tx.Version)
+	e.String(tx.ChainID)
+	e.String(tx.From)
+	e.String(tx.To)
+	e.Uint64(uint64(tx.Amount))
+	e.Uint64(uint64(tx.Fee))
+	e.Int(tx.Nonce)
+	e.Int(tx.ValidUntil)
+	e.String(tx.Memo)
+	e.Hex(tx.PubKey)
+}
+
+// EncodeTransaction: encoding kanonik lengkap (termasuk signature).
+func EncodeTransaction(tx Transaction) []byte {
+	e := NewEncoder(tagTx)
+	encodeTxFields(e, tx)
+	e.Hex(tx.Signature)
+	return e.Bytes()
+}
+
+func DecodeTransaction(data []byte) (Transaction, error) {
+	d := NewDecoder(data, tagTx)
+	tx := decodeTxFields(d)
+	if err := d.Finish(); err != nil {
+		return Transaction{}, err
+	}
+	return tx, nil
+}
+
+func decodeTxFields(d *Decoder) Transaction {
+	var tx Transaction
+	tx.Version = d.Int()
+	tx.ChainID = d.String()
+	tx.From = d.String()
+	tx.To = d.String()
+	tx.Amount = Amount(d.Uint64())
+	tx.Fee = Amount(d.Uint64())
+	tx.Nonce = d.Int()
+	tx.ValidUntil = d.Int()
+	tx.Memo = d.String()
+	tx.PubKey = d.Hex()
+	tx.Signature = d.Hex()
+	return tx
+}
+
+// encodeTxSigning: semua field kecuali signature, dengan tag terpisah.
+func encodeTxSigning(tx Transaction) []byte {
+	e := NewEncoder(tagTxSigning)
+	encodeTxFields(e, tx)
+	return e.Bytes()
+}
+
+// ================== Block ==================
+
+func encodeHeaderFields(e *Encoder, b Block) {
+	e.Int(b.Index)
+	e.Int64(b.Timestamp)
+	e.Hex(b.PrevHash)
+	e.Hex(b.MerkleRoot)
+	e.Hex(b.StateRoot)
+	e.String(b.Proposer)
+}
+
+// encodeHeaderForHash: preimage hash header (mengikat chain ID).
+func encodeHeaderForHash(chainID string, b Block) []byte {
+	e := NewEncoder(tagHeader)
+	e.String(chainID)
+	encodeHeaderFields(e, b)
+	// section opsional dengan marker blockExt* yang sama dengan EncodeBlock:
+	// kombinasi VRF/PoH/evidence berbeda tidak bisa menghasilkan preimage sama
+	// (blok lama tanpa section apa pun: hash tetap sama)
+	if b.VRF != nil {
+		e.buf = append(e.buf, blockExtVRF)
+		encodeVRF(e, b.VRF)
+	}
+	if b.PoH != nil {
+		e.buf = append(e.buf, blockExtPoH)
+		encodePoHSummary(e, b.PoH)
+	}
+	if len(b.Evidence) > 0 {
+		e.buf = append(e.buf, blockExtEvidence)
+		encodeEvidence(e, b.Evidence)
+	}
+	return e.Bytes()
+}
+
+func EncodeBlock(b Block) []byte {
+	e := NewEncoder(tagBlock)
+	encodeHeaderFields(e, b)
+	e.Hex(b.Hash)
+	e.Hex(b.ProposerKey)
+	e.Hex(b.Signature)
+	e.Len(len(b.Transactions))
+	for _, tx := range b.Transactions {
+		e.Raw(EncodeTransaction(tx))
+	}
+	if b.VRF != nil {
+		e.buf = append(e.buf, blockExtVRF)
+		encodeVRF(e, b.VRF)
+	}
+	if b.PoH != nil {
+		e.buf = append(e.buf, blockExtPoH)
+		encodePoH(e, b.PoH)
+	}
+	if len(b.Evidence) > 0 {
+		e.buf = append(e.buf, blockExtEvidence)
+		encodeEvidence(e, b.Evidence)
+	}
+	if b.Pruned {
+		e.buf = append(e.buf, blockExtPruned)
+	}
+	if b.Commit != nil { // opsional di akhir: blok lama & proposal tanpa sertifikat
+		encodeCommit(e, b.Commit)
+	}
+	return e.Bytes()
+}
+
+func DecodeBlock(data []byte) (Block, error) {
+	d := NewDecoder(data, tagBlock)
+	var b Block
+	b.Index = d.Int()
+	b.Timestamp = d.Int64()
+	b.PrevHash = d.Hex()
+	b.MerkleRoot = d.Hex()
+	b.StateRoot = d.Hex()
+	b.Proposer = d.String()
+	b.Hash = d.Hex()
+	b.ProposerKey = d.Hex()
+	b.Signature = d.Hex()
+	// jumlah TX dari wire tidak dipakai untuk prealokasi: n ≤ sisa byte saja,
+	// jadi payload k byte bisa meminta ruang k × sizeof(Transaction)
+	n := d.Len()
+	if d.Err() == nil {
+		b.Transactions = []Transaction{}
+	}
+	for i := 0; i < n && d.Err() == nil; i++ {
+		tx, err := DecodeTransaction(d.take(d.Len()))
+		if d.Err() != nil {
+			break
+		}
+		if err != nil {
+			return Block{}, fmt.Errorf("tx %d: %w", i, err)
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	if d.More() && d.peek() == blockExtVRF {
+		d.take(1)
+		b.VRF = decodeVRF(d)
+	}
+	if d.More() && d.peek() == blockExtPoH {
+		d.take(1)
+		b.PoH = decodePoH(d)
+	}
+	if d.More() && d.peek() == blockExtEvidence {
+		d.take(1)
+		evs, err := decodeEvidence(d)
+		if err != nil {
+			return Block{}, err
+		}
+		if len(evs) == 0 && d.Err() == nil {
+			return Block{}, fmt.Errorf("%w: section evidence kosong", ErrNonCanonical)
+		}
+		b.Evidence = evs
+	}
+	if d.More() && d.peek() == blockExtPruned {
+		d.take(1)
+		b.Pruned = true
+	}
+	if d.More() {
+		b.Commit = decodeCommit(d)
+	}
+	if err := d.Finish(); err != nil {
+		return Block{}, err
+	}
+	return b, nil
+}
+
+// ================== Chain params ==================
+
+// encodeChainParams: semua field ChainParams berurutan (diikat hash genesis).
+func encodeChainParams(p ChainParams) []byte {
+	e := NewEncoder(tagParams)
+	e.Uint64(uint64(p.BlockReward))
+	e.Uint64(uint64(p.FeePerByte))
+	e.Int(p.MaxBlockBytes)
+	e.Int(p.MaxBlockTxs)
+	e.Uint64(p.PoHHashesPerTick)
+	e.Int(p.PoHMaxTicks)
+	e.Int(p.EpochLength)
+	e.Int(p.MaxActiveValidators)
+	return e.Bytes()
+}
+
+// encodeLegacyKeys: pasangan address legacy → pubkey, urut address.
+func encodeLegacyKeys(keys map[string]string) []byte {
+	addrs := make([]string, 0, len(keys))
+	for a := range keys {
+		addrs = append(addrs, a)
+	}
+	sort.Strings(addrs)
+	e := NewEncoder(tagLegacyKeys)
+	e.Len(len(addrs))
+	for _, a := range addrs {
+		e.String(a)
+		e.Hex(keys[a])
+	}
+	return e.Bytes()
+}
+
+// ================== Tx list (mempool persistence) ==================
+
+func EncodeTxList(txs []Transaction) []byte {
+	e := NewEncoder(tagTxList)
+	e.Len(len(txs))
+	for _, tx := range txs {
+		e.Raw(EncodeTransaction(tx))
+	}
+	return e.Bytes()
+}
+
+func DecodeTxList(data []byte) ([]Transaction, error) {
+	d := NewDecoder(data, tagTxList)
+	n := d.Len()
+	out := []Transaction{} // tanpa prealokasi dari n (lihat DecodeBlock)
+	for i := 0; i < n && d.Err() == nil; i++ {
+		tx, err := DecodeTransaction(d.take(d.Len()))
+		if d.Err() != nil {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tx)
+	}
+	return out, d.Finish()
+}
+
+// isBinaryEncoded: data lama di DB masih JSON (diawali '{' atau '[').
+func isBinaryEncoded(data []byte) bool {
+	return len(data) > 0 && !bytes.HasPrefix(data, []byte("{")) && !bytes.HasPrefix(data, []byte("["))
+}
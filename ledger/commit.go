@@ -0,0 +1,157 @@
+package ledger
+
+import "fmt"
+
+// ================== Commit certificate ==================
+//
+// Bukti finality BFT: precommit > 2/3 stake atas hash blok, disimpan bersama
+// blok di luar hash header (signature baru ada setelah hash). ImportBlock
+// (gossip/sync) memverifikasi sertifikat terhadap active set epoch blok,
+// jadi "BFT instant" bisa dicek ulang kapan pun dari store.
+
+type CommitSig struct {
+	Validator string `json:"validator"`
+	Stake     Amount `json:"stake"`
+	PubKey    string `json:"pubkey"`
+	Signature string `json:"signature"` // precommit (height, round, hash)
+}
+
+type CommitCert struct {
+	Height    int         `json:"height"`
+	Round     int         `json:"round"`
+	BlockHash string      `json:"block_hash"`
+	Power     Amount      `json:"power"`      // total stake penanda tangan
+	Total     Amount      `json:"total"`      // total stake set validator
+	Sigs      []CommitSig `json:"signatures"` // urut address validator
+}
+
+// CommitCert: sertifikat dari precommit untuk hash (harus > 2/3 stake).
+func (s *VoteSet) CommitCert(hash string) (*CommitCert, error) {
+	if s.Type != VotePrecommit {
+		return nil, fmt.Errorf("❌ sertifikat commit butuh precommit, bukan %s", s.Type)
+	}
+	if hash == "" || !HasTwoThirds(s.Power(hash), s.total) {
+		return nil, fmt.Errorf("❌ precommit %.12s hanya %d/%d stake", hash, s.Power(hash), s.total)
+	}
+	c := &CommitCert{Height: s.Height, Round: s.Round, BlockHash: hash, Power: s.Power(hash), Total: s.total}
+	for _, v := range s.Votes() {
+		if v.BlockHash == hash {
+			c.Sigs = append(c.Sigs, CommitSig{Validator: v.Validator, Stake: s.stakes[v.Validator], PubKey: v.PubKey, Signature: v.Signature})
+		}
+	}
+	return c, nil
+}
+
+// VerifyCommit: sertifikat b ditandatangani validator (set sebelum blok)
+// dengan stake tercatat & total > 2/3.
+func VerifyCommit(b Block, validators []ValidatorDef) error {
+	c := b.Commit
+	if c == nil {
+		return fmt.Errorf("blok tanpa sertifikat commit")
+	}
+	if c.Height != b.Index || c.BlockHash != b.Hash {
+		return fmt.Errorf("sertifikat untuk block %d/%.12s", c.Height, c.BlockHash)
+	}
+	stakes := make(map[string]Amount, len(validators))
+	for _, v := range validators {
+		stakes[v.Address] = v.Stake
+	}
+	total := TotalStake(validators)
+	if c.Total != total {
+		return fmt.Errorf("total stake %d ≠ set validator %d", c.Total, total)
+	}
+	var power Amount
+	for i, sig := range c.Sigs {
+		if i > 0 && sig.Validator <= c.Sigs[i-1].Validator {
+			return fmt.Errorf("signature %d tidak urut / duplikat", i)
+		}
+		stake, ok := stakes[sig.Validator]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrVoteValidator, sig.Validator)
+		}
+		if sig.Stake != stake {
+			return fmt.Errorf("stake %s %d ≠ tercatat %d", sig.Validator, sig.Stake, stake)
+		}
+		if err := VerifyVote(sig.vote(c)); err != nil {
+			return fmt.Errorf("%s: %w", sig.Validator, err)
+		}
+		power, _ = power.Add(stake)
+	}
+	if power != c.Power {
+		return fmt.Errorf("power %d ≠ jumlah stake penanda tangan %d", c.Power, power)
+	}
+	if !HasTwoThirds(power, total) {
+		return fmt.Errorf("hanya %d/%d stake (butuh > 2/3)", power, total)
+	}
+	return nil
+}
+
+func (sig CommitSig) vote(c *CommitCert) Vote {
+	return Vote{
+		Type: VotePrecommit, Height: c.Height, Round: c.Round, BlockHash: c.BlockHash,
+		Validator: sig.Validator, PubKey: sig.PubKey, Signature: sig.Signature,
+	}
+}
+
+// GetBlockCommit: sertifikat block di height (nil untuk genesis / blok lama).
+func GetBlockCommit(height int) (*CommitCert, bool) {
+	b, ok := GetBlockByHeight(height)
+	if !ok {
+		return nil, false
+	}
+	return b.Commit, true
+}
+
+// VerifyStoredCommit: cek ulang sertifikat blok tersimpan terhadap active set
+// epoch-nya; blok sebelum set epoch dipublikasikan memakai set validator
+// setelah block height-1 (butuh state history untuk height tsb).
+func VerifyStoredCommit(height int) error {
+	b, ok := GetBlockByHeight(height)
+	if !ok {
+		return fmt.Errorf("❌ block #%d tidak ditemukan", height)
+	}
+	if s, ok := epochSetFor(height); ok {
+		return VerifyCommit(b, s.Validators)
+	}
+	vals, err := GetValidatorsAt(height - 1)
+	if err != nil {
+		return fmt.Errorf("❌ set validator @%d: %w", height-1, err)
+	}
+	return VerifyCommit(b, vals)
+}
+
+// ================== Codec ==================
+
+func encodeCommit(e *Encoder, c *CommitCert) {
+	e.Int(c.Height)
+	e.Int(c.Round)
+	e.Hex(c.BlockHash)
+	e.Uint64(uint64(c.Power))
+	e.Uint64(uint64(c.Total))
+	e.Len(len(c.Sigs))
+	for _, sig := range c.Sigs {
+		e.String(sig.Validator)
+		e.Uint64(uint64(sig.Stake))
+		e.Hex(sig.PubKey)
+		e.Hex(sig.Signature)
+	}
+}
+
+func decodeCommit(d *Decoder) *CommitCert {
+	c := &CommitCert{}
+	c.Height = d.Int()
+	c.Round = d.Int()
+	c.BlockHash = d.Hex()
+	c.Power = Amount(d.Uint64())
+	c.Total = Amount(d.Uint64())
+	n := d.Len()
+	for i := 0; i < n && d.Err() == nil; i++ {
+		var sig CommitSig
+		sig.Validator = d.String()
+		sig.Stake = Amount(d.Uint64())
+		sig.PubKey = d.Hex()
+		sig.Signature = d.Hex()
+		c.Sigs = append(c.Sigs, sig)
+	}
+	return c
+}
@@ -0,0 +1,275 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ================== Epoch & active set ==================
+//
+// Chain dibagi epoch Params.EpochLength blok. Saat blok terakhir epoch e
+// di-commit, active set epoch e+1 dihitung dari stake saat itu: validator yang
+// masih di-jail di height awal epoch (JailedUntil, state chain, lihat jail.go)
+// dibuang, sisanya diurut stake lalu dipotong ke
+// Params.MaxActiveValidators. Set + seed undian VRF epoch (= jadwal leader:
+// peluang tiap validator sebanding stake-nya di set) ditulis di batch yang sama
+// dengan blok, jadi proposer & vote BFT selama satu epoch memakai set yang
+// sama walau stake berubah di tengah epoch. Riwayat set disimpan per epoch
+// (tidak ikut dipangkas). Set untuk blok berikutnya (tanpa seed) di-commit
+// leaf "epoch" state root tiap blok, lihat state_root.go. Set tidak pernah
+// dihitung ulang di tengah epoch; suspend lokal (validator.go) tidak ikut.
+
+const prefixEpochSet = "epoch/" // epoch/<epoch 8-byte BE> → EpochSet
+
+type EpochSet struct {
+	Epoch      int            `json:"epoch"`
+	Start      int            `json:"start"`       // height pertama yang memakai set ini
+	Seed       string         `json:"seed"`        // seed undian VRF epoch
+	TotalStake Amount         `json:"total_stake"` // stake active set
+	Validators []ValidatorDef `json:"validators"`  // active set, urut stake (tie: address)
+	Jailed     []string       `json:"jailed,omitempty"`
+}
+
+// End: height terakhir epoch.
+func (s EpochSet) End() int { return (s.Epoch+1)*Params.EpochLength - 1 }
+
+// Share: peluang addr lolos undian round 0 (stake / stake active set).
+func (s EpochSet) Share(addr string) float64 {
+	for _, v := range s.Validators {
+		if v.Address == addr && s.TotalStake > 0 {
+			return float64(v.Stake) / float64(s.TotalStake)
+		}
+	}
+	return 0
+}
+
+var (
+	epochMu    sync.RWMutex
+	epochCache = map[int]EpochSet{}
+)
+
+// EpochOf: epoch untuk height (seed VRF & active set).
+func EpochOf(height int) int { return height / Params.EpochLength }
+
+// EpochStart: height pertama epoch.
+func EpochStart(epoch int) int { return epoch * Params.EpochLength }
+
+func epochKey(epoch int) []byte {
+	return binary.BigEndian.AppendUint64([]byte(prefixEpochSet), uint64(epoch))
+}
+
+// selectActiveSet: validator ber-stake & tidak di-jail di height, top
+// Params.MaxActiveValidators menurut stake.
+func selectActiveSet(validators []ValidatorDef, height int) (active []ValidatorDef, jailed []string) {
+	for _, v := range validators {
+		switch {
+		case v.Stake == 0:
+		case v.JailedUntil > height:
+			jailed = append(jailed, v.Address)
+		default:
+			active = append(active, ValidatorDef{Address: v.Address, Stake: v.Stake})
+		}
+	}
+	if len(active) == 0 {
+		// semua di-jail: set kosong membuat chain berhenti permanen (jail hanya
+		// bisa dicabut di boundary epoch berikutnya), jadi tetap pakai semuanya
+		for _, v := range validators {
+			if v.Stake > 0 {
+				active = append(active, ValidatorDef{Address: v.Address, Stake: v.Stake})
+			}
+		}
+		jailed = nil
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Stake != active[j].Stake {
+			return active[i].Stake > active[j].Stake
+		}
+		return active[i].Address < active[j].Address
+	})
+	if len(active) > Params.MaxActiveValidators {
+		active = active[:Params.MaxActiveValidators]
+	}
+	sort.Strings(jailed)
+	return active, jailed
+}
+
+// epochMembers: set tanpa seed yang mulai berlaku di height, dari stake validators.
+func epochMembers(height int, validators []ValidatorDef) EpochSet {
+	active, jailed := selectActiveSet(validators, height)
+	return EpochSet{
+		Epoch:      EpochOf(height),
+		Start:      height,
+		TotalStake: TotalStake(active),
+		Validators: active,
+		Jailed:     jailed,
+	}
+}
+
+// computeEpochSet: set untuk blok setelah parent, dari stake validators.
+func computeEpochSet(parent Block, validators []ValidatorDef) EpochSet {
+	s := epochMembers(parent.Index+1, validators)
+	s.Seed = EpochSeed(parent, parent.Index+1)
+	return s
+}
+
+// nextEpochMembers: active set (tanpa seed) yang berlaku untuk blok setelah
+// height dengan stake validators, untuk leaf "epoch" state root. Dalam satu
+// epoch = set terpublikasi; di boundary (atau set belum ada) dihitung ulang
+// seperti pendingEpochSet. Genesis tidak meng-commit set (nil): set pertama
+// diturunkan dari genesis.json.
+func nextEpochMembers(height int, validators []ValidatorDef) *EpochSet {
+	if height < 1 {
+		return nil
+	}
+	next := EpochOf(height + 1)
+	if next == EpochOf(height) {
+		if s, ok := GetEpochSet(next); ok {
+			s.Seed = ""
+			return &s
+		}
+	}
+	s := epochMembers(height+1, validators)
+	return &s
+}
+
+// pendingEpochSet: set yang harus dipublikasikan bersama blok b (blok terakhir
+// epoch, atau epoch berikutnya belum punya set: genesis / DB sebelum rotasi
+// epoch / restore snapshot lama), dari validators setelah blok b.
+func pendingEpochSet(b Block, validators []ValidatorDef) (EpochSet, bool) {
+	next := EpochOf(b.Index + 1)
+	if next == EpochOf(b.Index) && b.Index > 0 {
+		if _, ok := GetEpochSet(next); ok {
+			return EpochSet{}, false
+		}
+	}
+	return computeEpochSet(b, validators), true
+}
+
+func putEpochSet(batch *leveldb.Batch, s EpochSet) {
+	batch.Put(epochKey(s.Epoch), encodeEpochSet(s))
+}
+
+// epochPublished: dipanggil setelah batch berisi s ditulis.
+func epochPublished(s EpochSet) {
+	epochMu.Lock()
+	epochCache[s.Epoch] = s
+	epochMu.Unlock()
+	fmt.Printf("📅 Epoch %d (block #%d–#%d): %d validator aktif, stake %d, %d di-jail, seed %.12s...\n",
+		s.Epoch, s.Start, s.End(), len(s.Validators), s.TotalStake, len(s.Jailed), s.Seed)
+}
+
+func clearEpochCache() {
+	epochMu.Lock()
+	epochCache = map[int]EpochSet{}
+	epochMu.Unlock()
+}
+
+// ================== Query ==================
+
+// GetEpochSet: active set yang dipublikasikan untuk epoch.
+func GetEpochSet(epoch int) (EpochSet, bool) {
+	epochMu.RLock()
+	s, ok := epochCache[epoch]
+	epochMu.RUnlock()
+	if ok {
+		return s, true
+	}
+	InitDB()
+	data, err := db.Get(epochKey(epoch), nil)
+	if err != nil {
+		return EpochSet{}, false
+	}
+	s, err = decodeEpochSet(data)
+	if err != nil {
+		fmt.Printf("⚠️ set epoch %d corrupt: %v\n", epoch, err)
+		return EpochSet{}, false
+	}
+	epochMu.Lock()
+	epochCache[epoch] = s
+	epochMu.Unlock()
+	return s, true
+}
+
+// epochSetFor: set yang berlaku untuk blok di height (false = blok sebelum
+// set epoch-nya dipublikasikan).
+func epochSetFor(height int) (EpochSet, bool) {
+	s, ok := GetEpochSet(EpochOf(height))
+	if !ok || height < s.Start {
+		return EpochSet{}, false
+	}
+	return s, true
+}
+
+// ActiveValidators: set validator yang boleh propose & vote untuk blok di
+// height. Tanpa set terpublikasi (DB sebelum rotasi epoch) dihitung dari stake
+// saat ini; blok berikutnya yang di-commit mempublikasikannya.
+func ActiveValidators(height int) []ValidatorDef {
+	if s, ok := epochSetFor(height); ok {
+		return s.Validators
+	}
+	active, _ := selectActiveSet(GetValidators(), height)
+	return active
+}
+
+func inValidatorSet(vals []ValidatorDef, addr string) bool {
+	for _, v := range vals {
+		if v.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentEpochSet: set untuk blok berikutnya di atas head.
+func CurrentEpochSet() EpochSet {
+	head, ok := HeadBlock()
+	if !ok {
+		head = Block{Index: -1} // chain kosong: set untuk genesis
+	}
+	if s, ok := epochSetFor(head.Index + 1); ok {
+		return s
+	}
+	return computeEpochSet(head, GetValidators())
+}
+
+// ================== Codec ==================
+
+func encodeEpochSet(s EpochSet) []byte {
+	e := NewEncoder(tagEpochSet)
+	e.Int(s.Epoch)
+	e.Int(s.Start)
+	e.Hex(s.Seed)
+	e.Uint64(uint64(s.TotalStake))
+	e.Len(len(s.Validators))
+	for _, v := range s.Validators {
+		e.String(v.Address)
+		e.Uint64(uint64(v.Stake))
+	}
+	e.Len(len(s.Jailed))
+	for _, addr := range s.Jailed {
+		e.String(addr)
+	}
+	return e.Bytes()
+}
+
+func decodeEpochSet(data []byte) (EpochSet, error) {
+	d := NewDecoder(data, tagEpochSet)
+	var s EpochSet
+	s.Epoch = d.Int()
+	s.Start = d.Int()
+	s.Seed = d.Hex()
+	s.TotalStake = Amount(d.Uint64())
+	n := d.Len()
+	for i := 0; i < n && d.Err() == nil; i++ {
+		s.Validators = append(s.Validators, ValidatorDef{Address: d.String(), Stake: Amount(d.Uint64())})
+	}
+	n = d.Len()
+	for i := 0; i < n && d.Err() == nil; i++ {
+		s.Jailed = append(s.Jailed, d.String())
+	}
+	return s, d.Finish()
+}
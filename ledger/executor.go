@@ -0,0 +1,716 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ================== Parallel executor (Block-STM) ==================
+//
+// Hasil eksekusi selalu sama dengan eksekusi serial dalam urutan blok:
+// TX i valid jika (terhadap state setelah TX 0..i-1) nonce = nonce+1 dan saldo
+// cukup; TX tidak valid dilewati tanpa efek.
+//
+// 1) cek stateless (envelope, binding pubkey, address tujuan) paralel penuh,
+//    lalu signature via SigCache + batch verify (lihat sigverify.go)
+// 2) bagian stateful dijalankan optimistik ala Block-STM: tiap TX membaca dari
+//    multi-version memory (tulisan TX sebelumnya atau state dasar) dan mencatat
+//    read set; validasi ulang read set menangkap konflik (termasuk dana yang
+//    diterima di blok yang sama) lalu TX dieksekusi ulang dengan incarnation baru.
+
+// Alasan penolakan TX saat eksekusi (selain error envelope/amount).
+var (
+	ErrTxSender    = errors.New("pubkey tidak cocok dengan address pengirim")
+	ErrTxRecipient = errors.New("address tujuan tidak valid")
+	ErrTxFee       = errors.New("fee di bawah fee minimal (ukuran × fee per byte)")
+	ErrTxSignature = errors.New("signature tidak valid")
+)
+
+// ExecuteTxs menjalankan txs di atas balances/nonces (dimutasi) dan
+// mengembalikan TX yang diterima, urut blok.
+func ExecuteTxs(txs []Transaction, height int, balances map[string]Amount, nonces map[string]int) []Transaction {
+	return AcceptedTxs(txs, ExecuteTxsResults(txs, height, balances, nonces))
+}
+
+// ExecuteTxsResults: seperti ExecuteTxs, tapi mengembalikan hasil per TX
+// (nil = diterima, selain itu alasan penolakan).
+func ExecuteTxsResults(txs []Transaction, height int, balances map[string]Amount, nonces map[string]int) []error {
+	pre := precheckTxs(txs, height)
+	return executeSTM(txs, pre, balances, nonces, runtime.NumCPU())
+}
+
+// ExecuteTxsSerial: eksekusi referensi satu-per-satu (dipakai test & benchmark).
+func ExecuteTxsSerial(txs []Transaction, height int, balances map[string]Amount, nonces map[string]int) []Transaction {
+	accepted := make([]Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if statelessCheck(tx, height) != nil {
+			continue
+		}
+		view := mapView{balances: balances, nonces: nonces}
+		if writes, err := execTxState(tx, view.read); err == nil {
+			view.apply(writes)
+			accepted = append(accepted, tx)
+		}
+	}
+	return accepted
+}
+
+// AcceptedTxs: TX dengan results[i] == nil, urutan dipertahankan.
+func AcceptedTxs(txs []Transaction, results []error) []Transaction {
+	out := make([]Transaction, 0, len(txs))
+	for i, err := range results {
+		if err == nil {
+			out = append(out, txs[i])
+		}
+	}
+	return out
+}
+
+// statelessCheck: semua aturan TX yang tidak bergantung state akun.
+func statelessCheck(tx Transaction, height int) error {
+	if err := txRulesCheck(tx, height); err != nil {
+		return err
+	}
+	if !VerifyTransaction(tx) {
+		return ErrTxSignature
+	}
+	return nil
+}
+
+// txRulesCheck: statelessCheck tanpa signature (signature dicek batch).
+func txRulesCheck(tx Transaction, height int) error {
+	if err := checkTxEnvelope(tx, height); err != nil {
+		return err
+	}
+	if !SenderMatchesPubKey(tx) {
+		return ErrTxSender
+	}
+	if wallet.ValidateAddress(tx.To) != nil {
+		return ErrTxRecipient
+	}
+	if tx.Fee < CalculateFee(tx) {
+		return ErrTxFee
+	}
+	return nil
+}
+
+// precheckTxs: hasil cek stateless per TX (nil = lolos).
+func precheckTxs(txs []Transaction, height int) []error {
+	errs := make([]error, len(txs))
+	parallelFor(len(txs), func(i int) {
+		errs[i] = txRulesCheck(txs[i], height)
+	})
+	verifyTxSigs(txs, errs)
+	return errs
+}
+
+// parallelFor: jalankan fn(0..n-1) di NumCPU goroutine.
+func parallelFor(n int, fn func(i int)) {
+	workers := min(runtime.NumCPU(), n)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ================== State access ==================
+
+type stateKind byte
+
+const (
+	keyBalance stateKind = iota
+	keyNonce
+)
+
+type stateKey struct {
+	kind stateKind
+	addr string
+}
+
+type stateWrite struct {
+	key   stateKey
+	value uint64
+}
+
+// errReadBlocked: read menunggu TX lain (khusus Block-STM, bukan hasil akhir).
+var errReadBlocked = errors.New("read blocked")
+
+// execTxState: logika stateful satu TX. read mengembalikan nilai terkini;
+// error = TX ditolak (tanpa write).
+func execTxState(tx Transaction, read func(stateKey) (uint64, bool)) ([]stateWrite, error) {
+	nonce, ok := read(stateKey{keyNonce, tx.From})
+	if !ok {
+		return nil, errReadBlocked
+	}
+	if int(nonce)+1 != tx.Nonce {
+		if tx.Nonce <= int(nonce) {
+			return nil, fmt.Errorf("%w (state=%d, tx=%d)", ErrNonceTooLow, nonce, tx.Nonce)
+		}
+		return nil, fmt.Errorf("%w (state=%d, tx=%d)", ErrNonceTooHigh, nonce, tx.Nonce)
+	}
+	cost, err := txCost(tx)
+	if err != nil {
+		return nil, err
+	}
+	bal, ok := read(stateKey{keyBalance, tx.From})
+	if !ok {
+		return nil, errReadBlocked
+	}
+	left, err := Amount(bal).Sub(cost)
+	if err != nil {
+		return nil, fmt.Errorf("%w: saldo %d < %d", ErrAmountUnderflow, bal, cost)
+	}
+	writes := []stateWrite{{stateKey{keyNonce, tx.From}, uint64(tx.Nonce)}}
+	if tx.To == tx.From {
+		// self-transfer: hanya fee yang berkurang
+		return append(writes, stateWrite{stateKey{keyBalance, tx.From}, uint64(left + tx.Amount)}), nil
+	}
+	toBal, ok := read(stateKey{keyBalance, tx.To})
+	if !ok {
+		return nil, errReadBlocked
+	}
+	credited, err := Amount(toBal).Add(tx.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return append(writes,
+		stateWrite{stateKey{keyBalance, tx.From}, uint64(left)},
+		stateWrite{stateKey{keyBalance, tx.To}, uint64(credited)},
+	), nil
+}
+
+type mapView struct {
+	balances map[string]Amount
+	nonces   map[string]int
+}
+
+func (v mapView) read(k stateKey) (uint64, bool) {
+	if k.kind == keyNonce {
+		return uint64(v.nonces[k.addr]), true
+	}
+	return uint64(v.balances[k.addr]), true
+}
+
+func (v mapView) apply(writes []stateWrite) {
+	for _, w := range writes {
+		if w.key.kind == keyNonce {
+			v.nonces[w.key.addr] = int(w.value)
+		} else {
+			v.balances[w.key.addr] = Amount(w.value)
+		}
+	}
+}
+
+// ================== Multi-version memory ==================
+
+type mvEntry struct {
+	incarnation int
+	value       uint64
+	estimate    bool // tulisan incarnation yang dibatalkan; pembaca harus menunggu
+}
+
+type mvCell struct {
+	mu      sync.Mutex
+	idx     []int // index TX penulis, terurut
+	entries map[int]*mvEntry
+}
+
+type readStatus int
+
+const (
+	readStorage readStatus = iota // tidak ada penulis sebelumnya → state dasar
+	readVersion
+	readBlocked // penulis sebelumnya berstatus estimate
+)
+
+type readResult struct {
+	status      readStatus
+	txIdx       int
+	incarnation int
+	value       uint64
+}
+
+type readDesc struct {
+	key stateKey
+	res readResult
+}
+
+type mvMemory struct {
+	mu    sync.RWMutex
+	cells map[stateKey]*mvCell
+
+	// per TX: lokasi yang ditulis & read set incarnation terakhir
+	txMu       []sync.Mutex
+	lastWrites [][]stateKey
+	lastReads  [][]readDesc
+}
+
+func newMVMemory(n int) *mvMemory {
+	return &mvMemory{
+		cells:      map[stateKey]*mvCell{},
+		txMu:       make([]sync.Mutex, n),
+		lastWrites: make([][]stateKey, n),
+		lastReads:  make([][]readDesc, n),
+	}
+}
+
+func (m *mvMemory) cell(k stateKey, create bool) *mvCell {
+	m.mu.RLock()
+	c := m.cells[k]
+	m.mu.RUnlock()
+	if c != nil || !create {
+		return c
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c = m.cells[k]; c == nil {
+		c = &mvCell{entries: map[int]*mvEntry{}}
+		m.cells[k] = c
+	}
+	return c
+}
+
+func (m *mvMemory) read(k stateKey, txIdx int) readResult {
+	c := m.cell(k, false)
+	if c == nil {
+		return readResult{status: readStorage}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// penulis terakhir dengan index < txIdx
+	pos := sort.SearchInts(c.idx, txIdx) - 1
+	if pos < 0 {
+		return readResult{status: readStorage}
+	}
+	j := c.idx[pos]
+	e := c.entries[j]
+	if e.estimate {
+		return readResult{status: readBlocked, txIdx: j}
+	}
+	return readResult{status: readVersion, txIdx: j, incarnation: e.incarnation, value: e.value}
+}
+
+// record menyimpan hasil incarnation; true jika menulis lokasi baru
+// (TX setelahnya perlu divalidasi ulang).
+func (m *mvMemory) record(txIdx, incarnation int, reads []readDesc, writes []stateWrite) bool {
+	newKeys := make(map[stateKey]struct{}, len(writes))
+	for _, w := range writes {
+		c := m.cell(w.key, true)
+		c.mu.Lock()
+		if _, exists := c.entries[txIdx]; !exists {
+			pos := sort.SearchInts(c.idx, txIdx)
+			c.idx = append(c.idx, 0)
+			copy(c.idx[pos+1:], c.idx[pos:])
+			c.idx[pos] = txIdx
+		}
+		c.entries[txIdx] = &mvEntry{incarnation: incarnation, value: w.value}
+		c.mu.Unlock()
+		newKeys[w.key] = struct{}{}
+	}
+
+	m.txMu[txIdx].Lock()
+	defer m.txMu[txIdx].Unlock()
+	wroteNew := false
+	prev := make(map[stateKey]struct{}, len(m.lastWrites[txIdx]))
+	for _, k := range m.lastWrites[txIdx] {
+		prev[k] = struct{}{}
+		if _, still := newKeys[k]; !still {
+			m.remove(k, txIdx)
+		}
+	}
+	keys := make([]stateKey, 0, len(newKeys))
+	for k := range newKeys {
+		keys = append(keys, k)
+		if _, had := prev[k]; !had {
+			wroteNew = true
+		}
+	}
+	m.lastWrites[txIdx] = keys
+	m.lastReads[txIdx] = reads
+	return wroteNew
+}
+
+func (m *mvMemory) remove(k stateKey, txIdx int) {
+	c := m.cell(k, false)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[txIdx]; !ok {
+		return
+	}
+	delete(c.entries, txIdx)
+	pos := sort.SearchInts(c.idx, txIdx)
+	c.idx = append(c.idx[:pos], c.idx[pos+1:]...)
+}
+
+func (m *mvMemory) markEstimates(txIdx int) {
+	m.txMu[txIdx].Lock()
+	keys := m.lastWrites[txIdx]
+	m.txMu[txIdx].Unlock()
+	for _, k := range keys {
+		if c := m.cell(k, false); c != nil {
+			c.mu.Lock()
+			if e := c.entries[txIdx]; e != nil {
+				e.estimate = true
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (m *mvMemory) validateReads(txIdx int) bool {
+	m.txMu[txIdx].Lock()
+	reads := m.lastReads[txIdx]
+	m.txMu[txIdx].Unlock()
+	for _, r := range reads {
+		cur := m.read(r.key, txIdx)
+		switch {
+		case cur.status == readBlocked:
+			return false
+		case cur.status != r.res.status:
+			return false
+		case cur.status == readVersion && (cur.txIdx != r.res.txIdx || cur.incarnation != r.res.incarnation):
+			return false
+		}
+	}
+	return true
+}
+
+// ================== Scheduler ==================
+
+type stmStatus int
+
+const (
+	stmReady stmStatus = iota
+	stmExecuting
+	stmExecuted
+	stmAborting
+)
+
+type stmTx struct {
+	mu          sync.Mutex
+	incarnation int
+	status      stmStatus
+	deps        []int
+}
+
+type taskKind int
+
+const (
+	taskNone taskKind = iota
+	taskExecute
+	taskValidate
+)
+
+type stmTask struct {
+	kind        taskKind
+	txIdx       int
+	incarnation int
+}
+
+type stmScheduler struct {
+	n             int
+	executionIdx  atomic.Int64
+	validationIdx atomic.Int64
+	decreaseCnt   atomic.Int64
+	numActive     atomic.Int64
+	done          atomic.Bool
+	txs           []stmTx
+}
+
+func newSTMScheduler(n int) *stmScheduler {
+	return &stmScheduler{n: n, txs: make([]stmTx, n)}
+}
+
+func (s *stmScheduler) decreaseExecutionIdx(target int) {
+	for {
+		cur := s.executionIdx.Load()
+		if cur <= int64(target) || s.executionIdx.CompareAndSwap(cur, int64(target)) {
+			break
+		}
+	}
+	s.decreaseCnt.Add(1)
+}
+
+func (s *stmScheduler) decreaseValidationIdx(target int) {
+	for {
+		cur := s.validationIdx.Load()
+		if cur <= int64(target) || s.validationIdx.CompareAndSwap(cur, int64(target)) {
+			break
+		}
+	}
+	s.decreaseCnt.Add(1)
+}
+
+func (s *stmScheduler) checkDone() {
+	observed := s.decreaseCnt.Load()
+	if min(s.executionIdx.Load(), s.validationIdx.Load()) >= int64(s.n) &&
+		s.numActive.Load() == 0 && observed == s.decreaseCnt.Load() {
+		s.done.Store(true)
+	}
+}
+
+func (s *stmScheduler) tryIncarnate(idx int) stmTask {
+	if idx < s.n {
+		t := &s.txs[idx]
+		t.mu.Lock()
+		if t.status == stmReady {
+			t.status = stmExecuting
+			inc := t.incarnation
+			t.mu.Unlock()
+			return stmTask{taskExecute, idx, inc}
+		}
+		t.mu.Unlock()
+	}
+	s.numActive.Add(-1)
+	return stmTask{}
+}
+
+func (s *stmScheduler) nextVersionToExecute() stmTask {
+	if s.executionIdx.Load() >= int64(s.n) {
+		s.checkDone()
+		return stmTask{}
+	}
+	s.numActive.Add(1)
+	idx := int(s.executionIdx.Add(1) - 1)
+	return s.tryIncarnate(idx)
+}
+
+func (s *stmScheduler) nextVersionToValidate() stmTask {
+	if s.validationIdx.Load() >= int64(s.n) {
+		s.checkDone()
+		return stmTask{}
+	}
+	s.numActive.Add(1)
+	idx := int(s.validationIdx.Add(1) - 1)
+	if idx < s.n {
+		t := &s.txs[idx]
+		t.mu.Lock()
+		status, inc := t.status, t.incarnation
+		t.mu.Unlock()
+		if status == stmExecuted {
+			return stmTask{taskValidate, idx, inc}
+		}
+	}
+	s.numActive.Add(-1)
+	return stmTask{}
+}
+
+func (s *stmScheduler) nextTask() stmTask {
+	if s.validationIdx.Load() < s.executionIdx.Load() {
+		return s.nextVersionToValidate()
+	}
+	return s.nextVersionToExecute()
+}
+
+// addDependency: txIdx menunggu blocking selesai dieksekusi ulang.
+// false jika blocking sudah executed (baca ulang saja).
+func (s *stmScheduler) addDependency(txIdx, blocking int) bool {
+	b := &s.txs[blocking]
+	b.mu.Lock()
+	if b.status == stmExecuted {
+		b.mu.Unlock()
+		return false
+	}
+	t := &s.txs[txIdx]
+	t.mu.Lock()
+	t.status = stmAborting
+	t.mu.Unlock()
+	b.deps = append(b.deps, txIdx)
+	b.mu.Unlock()
+	s.numActive.Add(-1)
+	return true
+}
+
+func (s *stmScheduler) setReady(idx int) {
+	t := &s.txs[idx]
+	t.mu.Lock()
+	t.incarnation++
+	t.status = stmReady
+	t.mu.Unlock()
+}
+
+func (s *stmScheduler) finishExecution(idx, incarnation int, wroteNew bool) stmTask {
+	t := &s.txs[idx]
+	t.mu.Lock()
+	t.status = stmExecuted
+	deps := t.deps
+	t.deps = nil
+	t.mu.Unlock()
+
+	if len(deps) > 0 {
+		minDep := deps[0]
+		for _, d := range deps {
+			s.setReady(d)
+			minDep = min(minDep, d)
+		}
+		s.decreaseExecutionIdx(minDep)
+	}
+	if s.validationIdx.Load() > int64(idx) {
+		if !wroteNew {
+			return stmTask{taskValidate, idx, incarnation}
+		}
+		s.decreaseValidationIdx(idx)
+	}
+	s.numActive.Add(-1)
+	return stmTask{}
+}
+
+func (s *stmScheduler) tryValidationAbort(idx, incarnation int) bool {
+	t := &s.txs[idx]
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.incarnation == incarnation && t.status == stmExecuted {
+		t.status = stmAborting
+		return true
+	}
+	return false
+}
+
+func (s *stmScheduler) finishValidation(idx int, aborted bool) stmTask {
+	if aborted {
+		s.setReady(idx)
+		s.decreaseValidationIdx(idx + 1)
+		if s.executionIdx.Load() > int64(idx) {
+			if task := s.tryIncarnate(idx); task.kind != taskNone {
+				return task
+			}
+			return stmTask{} // tryIncarnate sudah mengurangi numActive
+		}
+	}
+	s.numActive.Add(-1)
+	return stmTask{}
+}
+
+// ================== Executor ==================
+
+type stmRun struct {
+	txs      []Transaction
+	pre      []error
+	balances map[string]Amount
+	nonces   map[string]int
+	mv       *mvMemory
+	sched    *stmScheduler
+	results  []error
+}
+
+// executeSTM: pre[i] = hasil cek stateless. State dasar hanya dibaca selama
+// eksekusi; hasil akhir ditulis sekali di akhir.
+func executeSTM(txs []Transaction, pre []error, balances map[string]Amount, nonces map[string]int, workers int) []error {
+	n := len(txs)
+	if n == 0 {
+		return []error{}
+	}
+	r := &stmRun{
+		txs: txs, pre: pre, balances: balances, nonces: nonces,
+		mv: newMVMemory(n), sched: newSTMScheduler(n),
+		results: make([]error, n),
+	}
+	workers = max(1, min(workers, n))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.worker()
+		}()
+	}
+	wg.Wait()
+
+	// tulis versi terakhir tiap key ke state dasar
+	for k, c := range r.mv.cells {
+		if len(c.idx) == 0 {
+			continue
+		}
+		v := c.entries[c.idx[len(c.idx)-1]].value
+		if k.kind == keyNonce {
+			nonces[k.addr] = int(v)
+		} else {
+			balances[k.addr] = Amount(v)
+		}
+	}
+	return r.results
+}
+
+func (r *stmRun) worker() {
+	task := stmTask{}
+	for !r.sched.done.Load() {
+		switch task.kind {
+		case taskExecute:
+			task = r.tryExecute(task)
+		case taskValidate:
+			task = r.validate(task)
+		default:
+			task = r.sched.nextTask()
+			if task.kind == taskNone {
+				runtime.Gosched()
+			}
+		}
+	}
+}
+
+func (r *stmRun) tryExecute(task stmTask) stmTask {
+	for {
+		var reads []readDesc
+		blockedOn := -1
+		read := func(k stateKey) (uint64, bool) {
+			res := r.mv.read(k, task.txIdx)
+			switch res.status {
+			case readBlocked:
+				blockedOn = res.txIdx
+				return 0, false
+			case readStorage:
+				v, _ := mapView{r.balances, r.nonces}.read(k)
+				reads = append(reads, readDesc{k, res})
+				return v, true
+			default:
+				reads = append(reads, readDesc{k, res})
+				return res.value, true
+			}
+		}
+
+		var writes []stateWrite
+		err := r.pre[task.txIdx]
+		if err == nil {
+			writes, err = execTxState(r.txs[task.txIdx], read)
+		}
+		if blockedOn >= 0 {
+			if r.sched.addDependency(task.txIdx, blockedOn) {
+				return stmTask{}
+			}
+			continue // penulis sudah selesai; eksekusi ulang sekarang
+		}
+		r.results[task.txIdx] = err
+		wroteNew := r.mv.record(task.txIdx, task.incarnation, reads, writes)
+		return r.sched.finishExecution(task.txIdx, task.incarnation, wroteNew)
+	}
+}
+
+func (r *stmRun) validate(task stmTask) stmTask {
+	valid := r.mv.validateReads(task.txIdx)
+	aborted := !valid && r.sched.tryValidationAbort(task.txIdx, task.incarnation)
+	if aborted {
+		r.mv.markEstimates(task.txIdx)
+	}
+	return r.sched.finishValidation(task.txIdx, aborted)
+}
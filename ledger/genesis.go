@@ -0,0 +1,310 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ================== Genesis ==================
+//
+// genesis.json menentukan chain ID, waktu genesis (tetap), alokasi saldo awal,
+// validator awal beserta stake, dan parameter ekonomi. Semua node yang memuat
+// file yang sama menghasilkan hash genesis yang sama.
+
+const (
+	keyGenesis = "meta/genesis"
+	keyParams  = "meta/params"
+)
+
+// ChainParams: parameter ekonomi & batas blok yang disepakati semua node.
+type ChainParams struct {
+	BlockReward   Amount `json:"block_reward"`    // reward tetap per blok untuk proposer
+	FeePerByte    Amount `json:"fee_per_byte"`    // fee = ukuran encoding kanonik × ini
+	MaxBlockBytes int    `json:"max_block_bytes"` // ukuran EncodeBlock maksimal
+	MaxBlockTxs   int    `json:"max_block_txs"`   // jumlah TX maksimal per blok
+
+	PoHHashesPerTick uint64 `json:"poh_hashes_per_tick"` // SHA-256 berurutan per tick PoH
+	PoHMaxTicks      int    `json:"poh_max_ticks"`       // tick maksimal per blok (batas biaya verifikasi)
+
+	EpochLength         int `json:"epoch_length"`          // blok per epoch (seed VRF & active set)
+	MaxActiveValidators int `json:"max_active_validators"` // ukuran active set DPoS per epoch
+}
+
+func DefaultChainParams() ChainParams {
+	return ChainParams{
+		BlockReward:   5,
+		FeePerByte:    1,
+		MaxBlockBytes: 2 << 20,
+		MaxBlockTxs:   10000,
+
+		PoHHashesPerTick: 12500,
+		PoHMaxTicks:      512,
+
+		EpochLength:         32,
+		MaxActiveValidators: 5,
+	}
+}
+
+// Params aktif (di-load dari DB / genesis).
+var Params = DefaultChainParams()
+
+type GenesisValidator struct {
+	Address string `json:"address"`
+	Stake   Amount `json:"stake"`
+}
+
+type Genesis struct {
+	ChainID     string             `json:"chain_id"`
+	GenesisTime int64              `json:"genesis_time"` // unix seconds
+	Balances    map[string]Amount  `json:"balances"`
+	Validators  []GenesisValidator `json:"validators"`
+	Params      ChainParams        `json:"params"`
+	// LegacyKeys: address legacy → pubkey Ed25519 penuh (hex). Hanya address
+	// yang tercatat di sini boleh dimigrasi, dan hanya oleh kunci tersebut.
+	LegacyKeys map[string]string `json:"legacy_keys,omitempty"`
+}
+
+// LoadGenesisFile membaca & memvalidasi genesis.json.
+func LoadGenesisFile(path string) (Genesis, error) {
+	g := Genesis{Params: DefaultChainParams()} // field params yang tidak diisi → default
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return g, err
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return g, fmt.Errorf("genesis json: %w", err)
+	}
+	return g, g.Validate()
+}
+
+func (g Genesis) Validate() error {
+	if g.ChainID == "" {
+		return fmt.Errorf("genesis: chain_id kosong")
+	}
+	if g.GenesisTime <= 0 {
+		return fmt.Errorf("genesis: genesis_time wajib diisi (unix seconds)")
+	}
+	for addr := range g.Balances {
+		if err := wallet.ValidateAddress(addr); err != nil {
+			return fmt.Errorf("genesis: balance %s: %w", addr, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, v := range g.Validators {
+		if err := wallet.ValidateAddress(v.Address); err != nil {
+			return fmt.Errorf("genesis: validator %s: %w", v.Address, err)
+		}
+		if v.Stake == 0 {
+			return fmt.Errorf("genesis: stake validator %s harus > 0", v.Address)
+		}
+		if seen[v.Address] {
+			return fmt.Errorf("genesis: validator %s duplikat", v.Address)
+		}
+		seen[v.Address] = true
+	}
+	for addr, key := range g.LegacyKeys {
+		pub, err := hex.DecodeString(key)
+		if err != nil || len(pub) != ed25519.PublicKeySize || hex.EncodeToString(pub) != key {
+			return fmt.Errorf("genesis: legacy key %s bukan pubkey Ed25519 hex lowercase", addr)
+		}
+		if wallet.LegacyAddressFromPubKey(pub) != addr {
+			return fmt.Errorf("genesis: legacy key %s tidak menurunkan address tsb", addr)
+		}
+	}
+	if supply, err := totalSupply(g.Balances, genesisValidatorDefs(g)); err != nil || supply > MaxSupply {
+		return fmt.Errorf("genesis: total alokasi melebihi max supply %d", MaxSupply)
+	}
+	if g.Params.BlockReward > MaxSupply || g.Params.FeePerByte > MaxSupply {
+		return fmt.Errorf("genesis: params melebihi max supply")
+	}
+	if g.Params.MaxBlockBytes < minBlockBytes || g.Params.MaxBlockTxs < 1 {
+		return fmt.Errorf("genesis: max_block_bytes ≥ %d dan max_block_txs ≥ 1", minBlockBytes)
+	}
+	if g.Params.PoHHashesPerTick < 2 || g.Params.PoHMaxTicks < 1 {
+		return fmt.Errorf("genesis: poh_hashes_per_tick ≥ 2 dan poh_max_ticks ≥ 1")
+	}
+	if g.Params.EpochLength < 1 || g.Params.MaxActiveValidators < 1 {
+		return fmt.Errorf("genesis: epoch_length ≥ 1 dan max_active_validators ≥ 1")
+	}
+	return nil
+}
+
+// genesisBlock: deterministik (timestamp dari genesis, tanpa proposer).
+func genesisBlock(g Genesis) Block {
+	balances := make(map[string]Amount, len(g.Balances))
+	for k, v := range g.Balances {
+		balances[k] = v
+	}
+	b := Block{
+		Index:        0,
+		Timestamp:    g.GenesisTime,
+		PrevHash:     "0",
+		StateRoot:    rootState{balances: balances, validators: genesisValidatorDefs(g)}.rootHex(),
+		Transactions: []Transaction{},
+	}
+	b.Hash = hashGenesisHeader(g, b)
+	return b
+}
+
+// hashGenesisHeader: seperti hashBlockHeaderFor, ditambah encoding kanonik
+// params & tabel migrasi legacy. Node dengan params berbeda (reward, fee, batas
+// blok, PoH, epoch) atau tabel migrasi berbeda menghasilkan hash genesis
+// berbeda dan tidak saling menerima.
+func hashGenesisHeader(g Genesis, b Block) string {
+	e := &Encoder{buf: encodeHeaderForHash(g.ChainID, b)}
+	e.Raw(encodeChainParams(g.Params))
+	e.Raw(encodeLegacyKeys(g.LegacyKeys))
+	sum := sha256.Sum256(e.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// Hash: hash blok 0 yang dihasilkan genesis g.
+func (g Genesis) Hash() string {
+	return genesisBlock(g).Hash
+}
+
+func genesisValidatorDefs(g Genesis) []ValidatorDef {
+	out := make([]ValidatorDef, 0, len(g.Validators))
+	for _, v := range g.Validators {
+		out = append(out, ValidatorDef{Address: v.Address, Stake: v.Stake})
+	}
+	return out
+}
+
+// InitFromGenesis: terapkan genesis ke DB kosong (state awal + blok 0).
+func InitFromGenesis(g Genesis) (Block, error) {
+	if err := g.Validate(); err != nil {
+		return Block{}, err
+	}
+	if ChainHeight() > 0 {
+		return Block{}, fmt.Errorf("❌ chain sudah ada (height %d); hapus hyperlux_db untuk init ulang", ChainHeight())
+	}
+
+	BalanceMu.Lock()
+	Balances = map[string]Amount{}
+	for k, v := range g.Balances {
+		Balances[k] = v
+	}
+	BalanceMu.Unlock()
+	NonceTableMu.Lock()
+	NonceTable = map[string]int{}
+	NonceTableMu.Unlock()
+	setValidators(genesisValidatorDefs(g))
+	resetHeadStateTree()
+
+	genesis := genesisBlock(g)
+	if err := saveGenesisMeta(g); err != nil {
+		return Block{}, err
+	}
+	if err := AppendBlock(genesis); err != nil {
+		return Block{}, err
+	}
+	SaveAllData()
+	return genesis, nil
+}
+
+// devGenesis: genesis ad-hoc dari state lokal (perilaku lama `init` tanpa file).
+func devGenesis() Genesis {
+	chainID := DefaultChainID
+	if env := os.Getenv("HYPERLUX_CHAIN_ID"); env != "" {
+		chainID = env
+	}
+	g := Genesis{
+		ChainID:     chainID,
+		GenesisTime: time.Now().Unix(),
+		Balances:    map[string]Amount{},
+		Params:      Params,
+	}
+	BalanceMu.RLock()
+	for k, v := range Balances {
+		if v != 0 {
+			g.Balances[k] = v
+		}
+	}
+	BalanceMu.RUnlock()
+	for _, v := range GetValidators() {
+		g.Validators = append(g.Validators, GenesisValidator{Address: v.Address, Stake: v.Stake})
+	}
+	g.LegacyKeys = knownLegacyKeys(g)
+	return g
+}
+
+// createDevGenesis: dipakai saat chain kosong & tidak ada genesis.json.
+func createDevGenesis() (Block, error) {
+	g := devGenesis()
+	if err := saveGenesisMeta(g); err != nil {
+		return Block{}, err
+	}
+	b := genesisBlock(g)
+	return b, AppendBlock(b)
+}
+
+func saveGenesisMeta(g Genesis) error {
+	InitDB()
+	gj, _ := json.Marshal(g)
+	pj, _ := json.Marshal(g.Params)
+	if err := db.Put([]byte(keyChainID), []byte(g.ChainID), nil); err != nil {
+		return fmt.Errorf("❌ gagal simpan chain id: %w", err)
+	}
+	if err := db.Put([]byte(keyParams), pj, nil); err != nil {
+		return err
+	}
+	fmt.Println("🔗 Chain ID:", g.ChainID)
+	ChainID = g.ChainID
+	Params = g.Params
+	LegacyKeys = legacyKeysOf(g)
+	return db.Put([]byte(keyGenesis), gj, nil)
+}
+
+// LoadGenesis: genesis yang tersimpan di DB (untuk export).
+func LoadGenesis() (Genesis, bool) {
+	InitDB()
+	var g Genesis
+	data, err := db.Get([]byte(keyGenesis), nil)
+	if err != nil || json.Unmarshal(data, &g) != nil {
+		return g, false
+	}
+	return g, true
+}
+
+// LoadLegacyKeys: tabel migrasi address legacy dari genesis tersimpan (juga
+// hasil restore snapshot, yang membawa genesis-nya).
+func LoadLegacyKeys() {
+	if g, ok := LoadGenesis(); ok {
+		LegacyKeys = legacyKeysOf(g)
+	}
+}
+
+// LoadParams membaca parameter ekonomi dari DB.
+func LoadParams() {
+	InitDB()
+	data, err := db.Get([]byte(keyParams), nil)
+	if err != nil {
+		return
+	}
+	p := DefaultChainParams() // DB lama belum punya batas blok
+	if json.Unmarshal(data, &p) == nil {
+		Params = p
+	}
+}
+
+// ExportGenesis: genesis asli, atau (fromState) state saat ini sebagai genesis baru.
+func ExportGenesis(fromState bool) ([]byte, error) {
+	if fromState {
+		g := devGenesis()
+		g.ChainID = ChainID
+		return json.MarshalIndent(g, "", "  ")
+	}
+	g, ok := LoadGenesis()
+	if !ok {
+		return nil, fmt.Errorf("❌ genesis belum tersimpan di DB")
+	}
+	return json.MarshalIndent(g, "", "  ")
+}
@@ -0,0 +1,541 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ================== Versioned state (historical queries) ==================
+//
+// Setiap blok menyimpan versi akun yang berubah dibanding blok sebelumnya
+// (blok hasil import: akun yang disentuh blok; AppendBlock: diff seluruh state
+// global terhadap state terakhir yang dicatat):
+//
+//   h/a/<addr>/<height 8B>        → balance (8B) + nonce (8B) setelah blok height
+//   h/v/<height 8B>               → set validator (address + stake) bila berubah
+//   h/s/<height 8B>               → treasury (8B) + burned (8B) bila berubah
+//   h/q/<height 8B><kind><addr>   → height versi lama yang tergantikan di height ini
+//   h/start, h/pruned             → batas bawah history yang bisa di-query
+//
+// Query "state di height H" = versi terakhir dengan height ≤ H.
+//
+// Mode archive menyimpan semua versi. Mode default hanya menjamin query
+// untuk Retention blok terakhir: versi yang sudah tergantikan oleh versi di
+// bawah horizon (head − Retention) dihapus lewat antrian h/q/ (inkremental,
+// sebanding jumlah perubahan, bukan jumlah akun).
+
+const (
+	prefixHistAccount    = "h/a/"
+	prefixHistValidators = "h/v/"
+	prefixHistSupply     = "h/s/"
+	prefixHistPrune      = "h/q/"
+	keyHistStart         = "h/start"
+	keyHistPruned        = "h/pruned"
+	histKindAccount      = 'a'
+	histKindValidators   = 'v'
+	histKindSupply       = 's'
+	DefaultHistoryDepth  = 1024
+)
+
+var (
+	ErrHistoryUnavailable = errors.New("state history tidak tersedia untuk height ini")
+	ErrHeightInFuture     = errors.New("height melebihi head")
+)
+
+// HistoryConfig: Archive = simpan semua versi; selain itu Retention blok terakhir.
+type HistoryConfig struct {
+	Archive   bool
+	Retention int
+}
+
+// HistoryConfigFromEnv: HYPERLUX_ARCHIVE=1 untuk archive node,
+// HYPERLUX_HISTORY_RETENTION=<blok> untuk mode default.
+func HistoryConfigFromEnv() HistoryConfig {
+	cfg := HistoryConfig{Retention: DefaultHistoryDepth}
+	if v, err := strconv.ParseBool(os.Getenv("HYPERLUX_ARCHIVE")); err == nil {
+		cfg.Archive = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_HISTORY_RETENTION")); err == nil && v > 0 {
+		cfg.Retention = v
+	}
+	return cfg
+}
+
+var History = HistoryConfigFromEnv()
+
+// state terakhir yang sudah dicatat sebagai versi (basis diff blok berikutnya)
+var (
+	histMu         sync.Mutex
+	histBalances   map[string]Amount
+	histNonces     map[string]int
+	histValidators []byte
+	histSupply     []byte
+)
+
+// ================== Keys & encoding ==================
+
+func histAccountPrefix(addr string) []byte {
+	return []byte(prefixHistAccount + addr + "/")
+}
+
+func histAccountKey(addr string, height int) []byte {
+	return binary.BigEndian.AppendUint64(histAccountPrefix(addr), uint64(height))
+}
+
+func histValidatorsKey(height int) []byte {
+	return binary.BigEndian.AppendUint64([]byte(prefixHistValidators), uint64(height))
+}
+
+func histSupplyKey(height int) []byte {
+	return binary.BigEndian.AppendUint64([]byte(prefixHistSupply), uint64(height))
+}
+
+func histPruneKey(height int, kind byte, addr string) []byte {
+	k := binary.BigEndian.AppendUint64([]byte(prefixHistPrune), uint64(height))
+	return append(append(k, kind), addr...)
+}
+
+func encodeAccountVersion(bal Amount, nonce int) []byte {
+	v := binary.BigEndian.AppendUint64(nil, uint64(bal))
+	return binary.BigEndian.AppendUint64(v, uint64(nonce))
+}
+
+func encodeSupplyVersion(treasury, burned Amount) []byte {
+	v := binary.BigEndian.AppendUint64(nil, uint64(treasury))
+	return binary.BigEndian.AppendUint64(v, uint64(burned))
+}
+
+func encodeValidatorSet(vals []ValidatorDef) []byte {
+	sorted := append([]ValidatorDef(nil), vals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Address < sorted[j].Address })
+	e := NewEncoder(tagValidatorSet)
+	e.Len(len(sorted))
+	var jailed []ValidatorDef
+	for _, v := range sorted {
+		e.String(v.Address)
+		e.Uint64(uint64(v.Stake))
+		if v.JailedUntil != 0 {
+			jailed = append(jailed, v)
+		}
+	}
+	// section jail opsional di akhir: set tanpa jail tetap sama dengan format lama
+	if len(jailed) > 0 {
+		e.Len(len(jailed))
+		for _, v := range jailed {
+			e.String(v.Address)
+			e.Int(v.JailedUntil)
+		}
+	}
+	return e.Bytes()
+}
+
+func decodeValidatorSet(data []byte) ([]ValidatorDef, error) {
+	if len(data) > 0 && data[0] == tagValidatorSetLegacy {
+		data = append([]byte{tagValidatorSet}, data[1:]...)
+	}
+	d := NewDecoder(data, tagValidatorSet)
+	n := d.Len()
+	var out []ValidatorDef
+	for i := 0; i < n && d.Err() == nil; i++ {
+		out = append(out, ValidatorDef{Address: d.String(), Stake: Amount(d.Uint64())})
+	}
+	if d.More() {
+		idx := make(map[string]int, len(out))
+		for i, v := range out {
+			idx[v.Address] = i
+		}
+		n = d.Len()
+		if n == 0 && d.Err() == nil {
+			return nil, fmt.Errorf("%w: section jail kosong", ErrNonCanonical)
+		}
+		for i := 0; i < n && d.Err() == nil; i++ {
+			addr, until := d.String(), d.Int()
+			j, ok := idx[addr]
+			if d.Err() == nil && (!ok || until == 0 || out[j].JailedUntil != 0) {
+				return nil, fmt.Errorf("%w: entry jail %q", ErrNonCanonical, addr)
+			}
+			if ok {
+				out[j].JailedUntil = until
+			}
+		}
+	}
+	return out, d.Finish()
+}
+
+// ================== Recording ==================
+
+// stateVersion: perubahan state untuk satu blok, ditulis di batch AppendBlock.
+type stateVersion struct {
+	height     int
+	accounts   map[string][2]uint64 // addr → {balance, nonce}
+	validators []byte               // nil = tidak berubah
+	supply     []byte               // nil = tidak berubah
+}
+
+// prepareStateVersion membandingkan state global dengan versi terakhir.
+// Dipanggil sebelum AppendBlock memegang chainMu.
+func prepareStateVersion(height int) *stateVersion {
+	BalanceMu.RLock()
+	NonceTableMu.RLock()
+	defer NonceTableMu.RUnlock()
+	defer BalanceMu.RUnlock()
+	return prepareStateVersionOf(height, Balances, NonceTable, GetValidators(), true)
+}
+
+// prepareStateVersionOf: versi untuk balances/nonces/validators yang diberikan.
+// full=false: balances/nonces hanya berisi akun yang disentuh blok; akun lain
+// dianggap tidak berubah (tanpa memindai seluruh akun).
+func prepareStateVersionOf(height int, balances map[string]Amount, nonces map[string]int, validators []ValidatorDef, full bool) *stateVersion {
+	histMu.Lock()
+	defer histMu.Unlock()
+	if histBalances == nil { // initHistory belum jalan (DB baru tanpa LoadAllData)
+		histBalances, histNonces = map[string]Amount{}, map[string]int{}
+	}
+	sv := &stateVersion{height: height, accounts: map[string][2]uint64{}}
+
+	check := func(addr string) {
+		bal, n := balances[addr], nonces[addr]
+		if histBalances[addr] != bal || histNonces[addr] != n {
+			sv.accounts[addr] = [2]uint64{uint64(bal), uint64(n)} // akun hilang → versi nol
+		}
+	}
+	for addr := range balances {
+		check(addr)
+	}
+	for addr := range nonces {
+		check(addr)
+	}
+	if full {
+		for addr := range histBalances {
+			check(addr)
+		}
+		for addr := range histNonces {
+			check(addr)
+		}
+	}
+
+	if enc := encodeValidatorSet(validators); !bytes.Equal(enc, histValidators) {
+		sv.validators = enc
+	}
+	if enc := encodeSupplyVersion(Supply()); !bytes.Equal(enc, histSupply) {
+		sv.supply = enc
+	}
+	return sv
+}
+
+// write menambahkan versi (dan pruning versi lama) ke batch.
+func (sv *stateVersion) write(batch *leveldb.Batch) {
+	if sv.height == 0 {
+		batch.Put([]byte(keyHistStart), encodeHeight(0))
+	}
+	for addr, v := range sv.accounts {
+		batch.Put(histAccountKey(addr, sv.height), encodeAccountVersion(Amount(v[0]), int(v[1])))
+		if !History.Archive {
+			if prev, ok := latestVersionHeight(histAccountPrefix(addr), sv.height-1); ok {
+				batch.Put(histPruneKey(sv.height, histKindAccount, addr), encodeHeight(prev))
+			}
+		}
+	}
+	if sv.validators != nil {
+		batch.Put(histValidatorsKey(sv.height), sv.validators)
+		if !History.Archive {
+			if prev, ok := latestVersionHeight([]byte(prefixHistValidators), sv.height-1); ok {
+				batch.Put(histPruneKey(sv.height, histKindValidators, ""), encodeHeight(prev))
+			}
+		}
+	}
+	if sv.supply != nil {
+		batch.Put(histSupplyKey(sv.height), sv.supply)
+		if !History.Archive {
+			if prev, ok := latestVersionHeight([]byte(prefixHistSupply), sv.height-1); ok {
+				batch.Put(histPruneKey(sv.height, histKindSupply, ""), encodeHeight(prev))
+			}
+		}
+	}
+	if !History.Archive && History.Retention > 0 && !pruneActive() { // mode prune: lihat prune.go
+		pruneHistory(batch, sv.height-History.Retention)
+	}
+}
+
+// dropHistoryAbove: hapus versi & antrian prune untuk height > h (rollback head).
+func dropHistoryAbove(batch *leveldb.Batch, h int) {
+	for _, prefix := range []string{prefixHistAccount, prefixHistValidators, prefixHistSupply, prefixHistPrune} {
+		it := db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for it.Next() {
+			key := it.Key()
+			if len(key) < len(prefix)+8 {
+				continue
+			}
+			at := key[len(key)-8:] // h/a/<addr>/<height>, h/v/<height>, h/s/<height>
+			if prefix == prefixHistPrune {
+				at = key[len(prefix) : len(prefix)+8] // h/q/<height><kind><addr>
+			}
+			if v, _ := decodeHeight(at); v > h {
+				batch.Delete(append([]byte(nil), key...))
+			}
+		}
+		it.Release()
+	}
+}
+
+// commit: versi sudah tersimpan → jadikan basis diff berikutnya.
+func (sv *stateVersion) commit() {
+	histMu.Lock()
+	defer histMu.Unlock()
+	for addr, v := range sv.accounts {
+		if v[0] == 0 && v[1] == 0 {
+			delete(histBalances, addr)
+			delete(histNonces, addr)
+			continue
+		}
+		histBalances[addr] = Amount(v[0])
+		histNonces[addr] = int(v[1])
+	}
+	if sv.validators != nil {
+		histValidators = sv.validators
+	}
+	if sv.supply != nil {
+		histSupply = sv.supply
+	}
+}
+
+// pruneHistory menghapus versi yang sudah tergantikan di height ≤ horizon.
+// Mengembalikan jumlah versi & perkiraan byte (key + value) yang dihapus.
+func pruneHistory(batch *leveldb.Batch, horizon int) (versions int, bytes int64) {
+	if horizon <= 0 {
+		return 0, 0
+	}
+	end := binary.BigEndian.AppendUint64([]byte(prefixHistPrune), uint64(horizon+1))
+	it := db.NewIterator(&util.Range{Start: []byte(prefixHistPrune), Limit: end}, nil)
+	defer it.Release()
+	for it.Next() {
+		key := it.Key()
+		rest := key[len(prefixHistPrune)+8:]
+		prev, ok := decodeHeight(it.Value())
+		if len(rest) == 0 || !ok {
+			continue
+		}
+		var vk []byte
+		switch rest[0] {
+		case histKindAccount:
+			vk = histAccountKey(string(rest[1:]), prev)
+			bytes += 16
+		case histKindValidators:
+			vk = histValidatorsKey(prev)
+			if v, err := db.Get(vk, nil); err == nil {
+				bytes += int64(len(v))
+			}
+		case histKindSupply:
+			vk = histSupplyKey(prev)
+			bytes += 16
+		default:
+			continue
+		}
+		batch.Delete(vk)
+		batch.Delete(append([]byte(nil), key...))
+		versions++
+		bytes += int64(len(vk) + len(key) + len(it.Value()))
+	}
+	if horizon > historyFloor() {
+		batch.Put([]byte(keyHistPruned), encodeHeight(horizon))
+	}
+	return versions, bytes
+}
+
+// latestVersionHeight: height versi terakhir ≤ maxHeight di bawah prefix.
+func latestVersionHeight(prefix []byte, maxHeight int) (int, bool) {
+	_, h, ok := latestVersion(prefix, maxHeight)
+	return h, ok
+}
+
+// latestVersion: nilai & height versi terakhir ≤ maxHeight di bawah prefix.
+func latestVersion(prefix []byte, maxHeight int) ([]byte, int, bool) {
+	if maxHeight < 0 {
+		return nil, 0, false
+	}
+	it := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+	seek := binary.BigEndian.AppendUint64(append([]byte(nil), prefix...), uint64(maxHeight)+1)
+	var ok bool
+	if it.Seek(seek) {
+		ok = it.Prev()
+	} else {
+		ok = it.Last()
+	}
+	if !ok || len(it.Key()) != len(prefix)+8 {
+		return nil, 0, false
+	}
+	h, _ := decodeHeight(it.Key()[len(prefix):])
+	return append([]byte(nil), it.Value()...), h, true
+}
+
+// initHistory: dipanggil setelah state dimuat. Chain lama tanpa history
+// mendapat satu versi penuh di head (history dimulai dari sana).
+func initHistory() {
+	InitDB()
+	histMu.Lock()
+	histBalances = map[string]Amount{}
+	histNonces = map[string]int{}
+	histValidators = nil
+	histSupply = nil
+	histMu.Unlock()
+
+	head := ChainHeight() - 1
+	if head < 0 {
+		return
+	}
+	if _, err := db.Get([]byte(keyHistStart), nil); err == leveldb.ErrNotFound {
+		sv := prepareStateVersion(head)
+		batch := new(leveldb.Batch)
+		sv.write(batch)
+		batch.Put([]byte(keyHistStart), encodeHeight(head))
+		if err := db.Write(batch, nil); err != nil {
+			fmt.Println("⚠️ gagal inisialisasi state history:", err)
+			return
+		}
+		sv.commit()
+		fmt.Printf("🗂  State history dimulai di block %d\n", head)
+		return
+	}
+
+	// basis diff = state menurut history di head, jadi perubahan di luar blok
+	// sejak itu ikut tercatat di versi blok berikutnya (recoverState bergantung
+	// pada history yang sama dengan state root header)
+	if st, err := stateAt(head); err == nil {
+		histMu.Lock()
+		histBalances, histNonces, histValidators = st.balances, st.nonces, encodeValidatorSet(st.validators)
+		if v, _, ok := latestVersion([]byte(prefixHistSupply), head); ok {
+			histSupply = v // history sebelum supply dicatat: versi ditulis di blok berikutnya
+		}
+		histMu.Unlock()
+		return
+	}
+	histMu.Lock()
+	defer histMu.Unlock()
+	BalanceMu.RLock()
+	for k, v := range Balances {
+		histBalances[k] = v
+	}
+	BalanceMu.RUnlock()
+	NonceTableMu.RLock()
+	for k, v := range NonceTable {
+		histNonces[k] = v
+	}
+	NonceTableMu.RUnlock()
+	histValidators = encodeValidatorSet(GetValidators())
+	histSupply = encodeSupplyVersion(Supply())
+}
+
+// ================== Queries ==================
+
+func historyFloor() int {
+	floor := 0
+	for _, k := range []string{keyHistStart, keyHistPruned} {
+		if data, err := db.Get([]byte(k), nil); err == nil {
+			if h, ok := decodeHeight(data); ok && h > floor {
+				floor = h
+			}
+		}
+	}
+	return floor
+}
+
+// HistoryRange: rentang height yang bisa di-query (from..to).
+func HistoryRange() (from, to int) {
+	InitDB()
+	return historyFloor(), ChainHeight() - 1
+}
+
+func checkHistoryHeight(height int) error {
+	from, to := HistoryRange()
+	if height > to {
+		return fmt.Errorf("%w: %d > %d", ErrHeightInFuture, height, to)
+	}
+	if height < from {
+		return fmt.Errorf("%w: %d (tersedia %d..%d)", ErrHistoryUnavailable, height, from, to)
+	}
+	return nil
+}
+
+// GetAccountAt: balance & nonce addr setelah blok height.
+func GetAccountAt(addr string, height int) (Amount, int, error) {
+	if err := checkHistoryHeight(height); err != nil {
+		return 0, 0, err
+	}
+	v, _, ok := latestVersion(histAccountPrefix(addr), height)
+	if !ok || len(v) != 16 {
+		return 0, 0, nil
+	}
+	return Amount(binary.BigEndian.Uint64(v[:8])), int(binary.BigEndian.Uint64(v[8:])), nil
+}
+
+// GetBalanceAt: balance addr setelah blok height.
+func GetBalanceAt(addr string, height int) (Amount, error) {
+	bal, _, err := GetAccountAt(addr, height)
+	return bal, err
+}
+
+// GetValidatorsAt: set validator (address + stake) setelah blok height.
+func GetValidatorsAt(height int) ([]ValidatorDef, error) {
+	if err := checkHistoryHeight(height); err != nil {
+		return nil, err
+	}
+	v, _, ok := latestVersion([]byte(prefixHistValidators), height)
+	if !ok {
+		return nil, nil
+	}
+	return decodeValidatorSet(v)
+}
+
+// stateAt merekonstruksi state lengkap (akun, validator, supply & set epoch
+// berikutnya) setelah blok height (versi terakhir ≤ height per akun; dipakai
+// recoverState).
+func stateAt(height int) (rootState, error) {
+	if err := checkHistoryHeight(height); err != nil {
+		return rootState{}, err
+	}
+	balances, nonces := map[string]Amount{}, map[string]int{}
+	it := db.NewIterator(util.BytesPrefix([]byte(prefixHistAccount)), nil)
+	defer it.Release()
+	for it.Next() {
+		key, v := it.Key(), it.Value()
+		if len(key) < len(prefixHistAccount)+9 || len(v) != 16 {
+			continue
+		}
+		if h, _ := decodeHeight(key[len(key)-8:]); h > height {
+			continue
+		}
+		// versi per address urut height naik: yang terakhir ≤ height menang
+		addr := string(key[len(prefixHistAccount) : len(key)-9])
+		bal, n := Amount(binary.BigEndian.Uint64(v[:8])), int(binary.BigEndian.Uint64(v[8:]))
+		delete(balances, addr)
+		delete(nonces, addr)
+		if bal != 0 {
+			balances[addr] = bal
+		}
+		if n != 0 {
+			nonces[addr] = n
+		}
+	}
+	if err := it.Error(); err != nil {
+		return rootState{}, err
+	}
+	vals, err := GetValidatorsAt(height)
+	if err != nil {
+		return rootState{}, err
+	}
+	st := rootState{balances: balances, nonces: nonces, validators: vals, epoch: nextEpochMembers(height, vals)}
+	if v, _, ok := latestVersion([]byte(prefixHistSupply), height); ok && len(v) == 16 {
+		st.treasury, st.burned = Amount(binary.BigEndian.Uint64(v[:8])), Amount(binary.BigEndian.Uint64(v[8:]))
+	}
+	return st, nil
+}
@@ -0,0 +1,199 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ================== Jail (state chain) ==================
+//
+// Jail adalah state chain: ValidatorDef.JailedUntil = height pertama validator
+// boleh masuk active set lagi (0 = tidak pernah di-jail). Di-set oleh blok
+// yang memuat bukti equivocation (dua vote bertanda tangan validator yang sama
+// untuk height/round/tipe sama dengan hash berbeda), berakhir sendiri menurut
+// height, dan ikut state root (leaf stake). Active set epoch membuang
+// validator yang masih di-jail di height awal set, jadi hasilnya sama di semua
+// node. Suspend lokal (validator.go) hanya memengaruhi vote & proposal node
+// itu sendiri.
+
+const (
+	JailBlocks       = 1024 // lama jail sejak height equivocation
+	MaxBlockEvidence = 16   // bukti equivocation per blok
+)
+
+var ErrEvidence = errors.New("bukti equivocation tidak valid")
+
+// Evidence: dua vote bertentangan, urut BlockHash (A < B) supaya satu
+// pelanggaran hanya punya satu bentuk.
+type Evidence struct {
+	A Vote `json:"a"`
+	B Vote `json:"b"`
+}
+
+func (ev Evidence) Offender() string { return ev.A.Validator }
+
+// JailUntil: height pertama offender bebas lagi.
+func (ev Evidence) JailUntil() int { return ev.A.Height + 1 + JailBlocks }
+
+func (ev Evidence) key() string {
+	return fmt.Sprintf("%s/%d/%d/%d", ev.A.Validator, ev.A.Height, ev.A.Round, ev.A.Type)
+}
+
+// NewEvidence: bukti dari dua vote bertentangan (urutan bebas).
+func NewEvidence(a, b Vote) Evidence {
+	if b.BlockHash < a.BlockHash {
+		a, b = b, a
+	}
+	return Evidence{A: a, B: b}
+}
+
+// verifyEvidenceVotes: dua vote sah dari validator yang sama untuk
+// height/round/tipe yang sama dengan hash berbeda.
+func verifyEvidenceVotes(ev Evidence) error {
+	a, b := ev.A, ev.B
+	if a.Validator != b.Validator || a.Type != b.Type || a.Height != b.Height || a.Round != b.Round {
+		return fmt.Errorf("%w: vote bukan untuk slot yang sama", ErrEvidence)
+	}
+	if a.BlockHash >= b.BlockHash {
+		return fmt.Errorf("%w: hash vote sama / tidak urut", ErrEvidence)
+	}
+	for _, v := range []Vote{a, b} {
+		if err := VerifyVote(v); err != nil {
+			return fmt.Errorf("%w: %v", ErrEvidence, err)
+		}
+	}
+	return nil
+}
+
+// applyEvidence: salinan validators setelah bukti di blok height diterapkan
+// berurutan. Bukti harus lebih tua dari blok, belum kedaluwarsa, offender
+// anggota active set di height bukti, dan jail-nya memang bertambah (bukti
+// yang sama tidak bisa dipakai dua kali).
+func applyEvidence(validators []ValidatorDef, evs []Evidence, height int) ([]ValidatorDef, error) {
+	if len(evs) == 0 {
+		return validators, nil
+	}
+	out := append([]ValidatorDef(nil), validators...)
+	for i, ev := range evs {
+		if err := verifyEvidenceVotes(ev); err != nil {
+			return nil, fmt.Errorf("bukti %d: %w", i, err)
+		}
+		if ev.A.Height >= height || height >= ev.JailUntil() {
+			return nil, fmt.Errorf("bukti %d: %w: height vote %d di luar jendela blok %d", i, ErrEvidence, ev.A.Height, height)
+		}
+		if !inValidatorSet(ActiveValidators(ev.A.Height), ev.Offender()) {
+			return nil, fmt.Errorf("bukti %d: %w: %s bukan active set height %d", i, ErrEvidence, ev.Offender(), ev.A.Height)
+		}
+		j := -1
+		for k := range out {
+			if out[k].Address == ev.Offender() {
+				j = k
+			}
+		}
+		if j < 0 || out[j].JailedUntil >= ev.JailUntil() {
+			return nil, fmt.Errorf("bukti %d: %w: %s sudah di-jail / tidak terdaftar", i, ErrEvidence, ev.Offender())
+		}
+		out[j].JailedUntil = ev.JailUntil()
+	}
+	return out, nil
+}
+
+// ================== Evidence pool ==================
+//
+// Bukti yang dilihat consensus lokal menunggu dimasukkan proposer berikutnya.
+
+var (
+	evidenceMu   sync.Mutex
+	evidencePool = map[string]Evidence{}
+)
+
+// ReportEquivocation: dipanggil consensus saat vote bertentangan terdeteksi.
+func ReportEquivocation(a, b Vote) error {
+	ev := NewEvidence(a, b)
+	if err := verifyEvidenceVotes(ev); err != nil {
+		return err
+	}
+	evidenceMu.Lock()
+	evidencePool[ev.key()] = ev
+	evidenceMu.Unlock()
+	fmt.Printf("🚨 Bukti equivocation %s height %d round %d menunggu blok berikutnya\n", ev.Offender(), ev.A.Height, ev.A.Round)
+	return nil
+}
+
+// pendingEvidence: bukti yang masih berlaku untuk blok height di atas
+// validators (maks MaxBlockEvidence & maxBytes byte section evidence, urut
+// deterministik) & validators hasilnya. Bukti yang sudah tidak berlaku
+// dibuang dari pool.
+func pendingEvidence(height int, validators []ValidatorDef, maxBytes int) ([]Evidence, []ValidatorDef) {
+	evidenceMu.Lock()
+	defer evidenceMu.Unlock()
+	keys := make([]string, 0, len(evidencePool))
+	for k := range evidencePool {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var out []Evidence
+	for _, k := range keys {
+		if len(out) == MaxBlockEvidence {
+			break
+		}
+		ev := evidencePool[k]
+		if evidenceBytes(append(out, ev)) > maxBytes {
+			continue
+		}
+		next, err := applyEvidence(validators, []Evidence{ev}, height)
+		if err != nil {
+			if ev.A.Height < height {
+				delete(evidencePool, k)
+			}
+			continue
+		}
+		out, validators = append(out, ev), next
+	}
+	return out, validators
+}
+
+// removeIncludedEvidence: bukti yang sudah masuk blok keluar dari pool.
+func removeIncludedEvidence(evs []Evidence) {
+	if len(evs) == 0 {
+		return
+	}
+	evidenceMu.Lock()
+	for _, ev := range evs {
+		delete(evidencePool, ev.key())
+	}
+	evidenceMu.Unlock()
+}
+
+// ================== Codec ==================
+
+func encodeEvidence(e *Encoder, evs []Evidence) {
+	e.Len(len(evs))
+	for _, ev := range evs {
+		e.Raw(EncodeVote(ev.A))
+		e.Raw(EncodeVote(ev.B))
+	}
+}
+
+func decodeEvidence(d *Decoder) ([]Evidence, error) {
+	n := d.Len()
+	var out []Evidence
+	for i := 0; i < n && d.Err() == nil; i++ {
+		rawA, rawB := d.Raw(), d.Raw()
+		if d.Err() != nil {
+			break
+		}
+		a, err := DecodeVote(rawA)
+		if err != nil {
+			return nil, fmt.Errorf("evidence %d: %w", i, err)
+		}
+		b, err := DecodeVote(rawB)
+		if err != nil {
+			return nil, fmt.Errorf("evidence %d: %w", i, err)
+		}
+		out = append(out, Evidence{A: a, B: b})
+	}
+	return out, nil
+}
@@ -1,56 +1,136 @@
 package ledger
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
 
-// InitLedger: init storage + load state + buat genesis jika kosong
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// InitLedger: init storage + load state + buat genesis ad-hoc jika kosong
 func InitLedger() {
 	InitDB()
-	LoadBalances()
-	LoadBlockchain()
-	LoadMempool()
-	LoadNonceTable()
-	LoadValidators() // didefinisikan di validator.go
+	LoadAllData()
 
-	if len(Blockchain) == 0 {
-		genesis := NewBlock(0, []Transaction{}, "0", nil)
-		Blockchain = append(Blockchain, genesis)
-		SaveBlockchain()
-		fmt.Println("✅ Genesis block created")
+	if ChainHeight() == 0 {
+		genesis, err := createDevGenesis()
+		if err != nil {
+			fmt.Println("❌ gagal simpan genesis:", err)
+			return
+		}
+		fmt.Println("✅ Genesis block created:", genesis.Hash)
 	} else {
 		fmt.Println("✅ Storage engine initialized")
 	}
 }
 
+// InitLedgerWithGenesis: seperti InitLedger tapi genesis dari file (deterministik).
+func InitLedgerWithGenesis(path string) error {
+	g, err := LoadGenesisFile(path)
+	if err != nil {
+		return fmt.Errorf("❌ genesis %s: %w", path, err)
+	}
+	InitDB()
+	LoadAllData()
+
+	if head, ok := GetBlockByHeight(0); ok {
+		// node sudah di-init: pastikan genesis yang sama
+		if want := g.Hash(); head.Hash != want {
+			return fmt.Errorf("❌ DB berisi genesis lain (%s), file menghasilkan %s", head.Hash, want)
+		}
+		fmt.Println("✅ Storage engine initialized (genesis cocok)")
+		return nil
+	}
+	if ChainHeight() > 0 && ChainBase() > 0 {
+		// node hasil restore snapshot: blok 0 tidak ada, cocokkan genesis tersimpan
+		stored, ok := LoadGenesis()
+		if want := g.Hash(); !ok || stored.Hash() != want {
+			return fmt.Errorf("❌ snapshot di DB bukan dari genesis %s", want)
+		}
+		fmt.Printf("✅ Storage engine initialized (dari snapshot block #%d)\n", ChainBase())
+		return nil
+	}
+	genesis, err := InitFromGenesis(g)
+	if err != nil {
+		return err
+	}
+	fmt.Println("✅ Genesis block created:", genesis.Hash)
+	return nil
+}
+
 // Utilities
 
-func GetBalance(addr string) int {
+func GetBalance(addr string) Amount {
 	BalanceMu.RLock()
 	defer BalanceMu.RUnlock()
 	return Balances[addr]
 }
 
-func Airdrop(addr string, amount int) {
+// ErrChainStarted: airdrop & slash manual mengubah state yang diikat state
+// root di luar blok, jadi hanya boleh sebelum genesis (state lokal lalu masuk
+// genesis dev saat init). Setelah ada blok, node lain tidak akan ikut berubah.
+var ErrChainStarted = errors.New("chain sudah punya blok, state hanya berubah lewat blok")
+
+func requirePreGenesis(op string) error {
+	if h := ChainHeight(); h > 0 {
+		return fmt.Errorf("❌ %s: %w (height %d)", op, ErrChainStarted, h)
+	}
+	return nil
+}
+
+// Airdrop (dev, sebelum genesis): mint ke addr, ditolak bila total supply
+// melewati MaxSupply.
+func Airdrop(addr string, amount Amount) error {
+	if err := requirePreGenesis("airdrop"); err != nil {
+		return err
+	}
 	BalanceMu.Lock()
+	supply, err := totalSupply(Balances, GetValidators())
+	if err == nil {
+		supply, err = supply.Add(amount)
+	}
+	if err != nil || supply > MaxSupply {
+		BalanceMu.Unlock()
+		return fmt.Errorf("❌ airdrop %d melebihi max supply", amount)
+	}
 	Balances[addr] += amount
 	BalanceMu.Unlock()
 	SaveBalances()
 	fmt.Printf("💸 Airdropped %d HYLUX to %s\n", amount, addr)
+	return nil
 }
 
 // Load/Save everything
 
 func LoadAllData() {
+	LoadChainID()
+	LoadParams()
+	LoadLegacyKeys()
 	LoadBalances()
 	LoadBlockchain()
-	LoadMempool()
 	LoadNonceTable()
 	LoadValidators()
+	LoadEconomy()
+	LoadValidatorStatus()
+	recoverState()       // state tersimpan harus = efek head
+	resetHeadStateTree() // pohon state dibangun ulang dari state yang dimuat
+	LoadMempool()        // setelah nonce table: TX dengan nonce basi dibuang
+	initHistory()        // basis versi state = state yang baru dimuat
 }
 
-func SaveAllData() {
-	SaveBalances()
-	SaveBlockchain()
-	SaveMempool()
-	SaveNonceTable()
-	SaveValidators()
+// SaveAllData: state + mempool dalam satu batch.
+func SaveAllData() error {
+	InitDB()
+	st, err := encodeState()
+	if err != nil {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	st.put(batch)
+	batch.Put([]byte(keyMempool), Pool.encode())
+	if err := db.Write(batch, syncWrite); err != nil {
+		fmt.Println("⚠️ gagal simpan state:", err)
+		return err
+	}
+	return nil
 }
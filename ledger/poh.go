@@ -0,0 +1,681 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ================== Proof of History ==================
+//
+// Rantai SHA-256 sekuensial (tidak bisa diparalelkan saat dibuat):
+//   - hash murni: h = sha256(h)
+//   - mixin TX:   h = sha256(h || txHash)
+// Tick = tepat Params.PoHHashesPerTick hash sejak tick sebelumnya (mixin ikut
+// dihitung, hash terakhir tick selalu hash murni). Generator berjalan terus di
+// goroutine sendiri dan mencampur hash TX saat masuk mempool. Tiap blok merekam
+// entry sejak akhir PoH parent, diakhiri tick; ringkasan (hash akhir, jumlah
+// hash, tick, root entry) ikut hash header, entry = body (dipangkas bersama TX).
+// Verifikasi: tiap entry hanya butuh hash entry sebelumnya, jadi segmen dicek
+// paralel di beberapa core.
+
+const (
+	keyPoHStream = "poh/stream" // stream generator sejak akhir PoH head
+	pohChunk     = 1024         // hash per pegangan lock generator
+)
+
+type PoHEntry struct {
+	NumHashes uint64 `json:"num_hashes"` // hash sejak entry sebelumnya (termasuk mixin)
+	Hash      string `json:"hash"`
+	Mixin     string `json:"mixin,omitempty"` // hash TX; "" = tick
+}
+
+// PoHRecord: segmen PoH satu blok. Semua field kecuali Entries ikut hash header
+// dan tetap ada setelah body blok dipangkas.
+type PoHRecord struct {
+	Hash    string     `json:"hash"`   // hash terakhir segmen
+	Hashes  uint64     `json:"hashes"` // total hash segmen
+	Ticks   int        `json:"ticks"`
+	Root    string     `json:"root"` // sha256 encoding entries
+	Entries []PoHEntry `json:"entries,omitempty"`
+}
+
+// PoHConfig: pacing generator & paralelisme verifier (lokal node; jumlah hash
+// per tick & batas tick per blok adalah parameter chain).
+type PoHConfig struct {
+	TickInterval  time.Duration // target durasi satu tick; sisa waktu tidur (0 = hash tanpa jeda)
+	VerifyWorkers int
+	PersistTicks  int // stream disimpan ke DB tiap N tick
+}
+
+// PoHConfigFromEnv: HYPERLUX_POH_TICK_MS, HYPERLUX_POH_VERIFY_WORKERS,
+// HYPERLUX_POH_PERSIST_TICKS.
+func PoHConfigFromEnv() PoHConfig {
+	cfg := PoHConfig{
+		TickInterval:  10 * time.Millisecond,
+		VerifyWorkers: runtime.NumCPU(),
+		PersistTicks:  64,
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_POH_TICK_MS")); err == nil && v >= 0 {
+		cfg.TickInterval = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_POH_VERIFY_WORKERS")); err == nil && v > 0 {
+		cfg.VerifyWorkers = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_POH_PERSIST_TICKS")); err == nil && v > 0 {
+		cfg.PersistTicks = v
+	}
+	return cfg
+}
+
+var PoH = PoHConfigFromEnv()
+
+// ================== Hashing ==================
+
+type pohEntry struct {
+	n     uint64
+	hash  [32]byte
+	mixin [32]byte
+	mixed bool // false = tick
+}
+
+func (e pohEntry) export() PoHEntry {
+	out := PoHEntry{NumHashes: e.n, Hash: hex.EncodeToString(e.hash[:])}
+	if e.mixed {
+		out.Mixin = hex.EncodeToString(e.mixin[:])
+	}
+	return out
+}
+
+func parsePoHEntry(e PoHEntry) (pohEntry, error) {
+	out := pohEntry{n: e.NumHashes}
+	var ok bool
+	if out.hash, ok = hash32(e.Hash); !ok {
+		return out, fmt.Errorf("hash %q bukan 32 byte hex", e.Hash)
+	}
+	if e.Mixin != "" {
+		if out.mixin, ok = hash32(e.Mixin); !ok {
+			return out, fmt.Errorf("mixin %q bukan 32 byte hex", e.Mixin)
+		}
+		out.mixed = true
+	}
+	return out, nil
+}
+
+func hash32(s string) ([32]byte, bool) {
+	var h [32]byte
+	b, ok := canonicalHex(s)
+	if !ok || len(b) != len(h) {
+		return h, false
+	}
+	copy(h[:], b)
+	return h, true
+}
+
+func pohMix(h, mixin [32]byte) [32]byte {
+	var buf [64]byte
+	copy(buf[:32], h[:])
+	copy(buf[32:], mixin[:])
+	return sha256.Sum256(buf[:])
+}
+
+// run: hasil entry e bila dimulai dari start.
+func (e pohEntry) run(start [32]byte) [32]byte {
+	h := start
+	pure := e.n
+	if e.mixed {
+		pure--
+	}
+	for i := uint64(0); i < pure; i++ {
+		h = sha256.Sum256(h[:])
+	}
+	if e.mixed {
+		h = pohMix(h, e.mixin)
+	}
+	return h
+}
+
+// PoHStart: awal segmen PoH blok di atas parent (akhir PoH parent, atau
+// turunan hash parent untuk genesis / blok sebelum PoH).
+func PoHStart(parent Block) string {
+	if parent.PoH != nil {
+		return parent.PoH.Hash
+	}
+	e := NewEncoder(tagPoHStart)
+	e.String(ChainID)
+	e.Hex(parent.Hash)
+	sum := sha256.Sum256(e.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+func pohRoot(entries []PoHEntry) string {
+	e := NewEncoder(tagPoHEntries)
+	encodePoHEntries(e, entries)
+	sum := sha256.Sum256(e.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// ================== Verifier ==================
+
+// checkPoHRecord: aturan tick (sekuensial, murah) & ringkasan cocok dengan entry.
+func checkPoHRecord(r *PoHRecord, perTick uint64, maxTicks int) error {
+	if len(r.Entries) == 0 {
+		return fmt.Errorf("segmen PoH kosong")
+	}
+	var total, sinceTick uint64
+	ticks := 0
+	for i, en := range r.Entries {
+		if en.NumHashes == 0 || en.NumHashes > perTick {
+			return fmt.Errorf("entry %d: num_hashes %d di luar 1..%d", i, en.NumHashes, perTick)
+		}
+		total += en.NumHashes
+		sinceTick += en.NumHashes
+		if en.Mixin == "" {
+			if sinceTick != perTick {
+				return fmt.Errorf("tick entry %d: %d hash sejak tick sebelumnya, harus %d", i, sinceTick, perTick)
+			}
+			ticks++
+			sinceTick = 0
+		} else if sinceTick >= perTick {
+			return fmt.Errorf("mixin entry %d melewati batas tick", i)
+		}
+	}
+	if sinceTick != 0 {
+		return fmt.Errorf("segmen tidak diakhiri tick")
+	}
+	if ticks > maxTicks {
+		return fmt.Errorf("%d tick > batas %d per blok", ticks, maxTicks)
+	}
+	last := r.Entries[len(r.Entries)-1]
+	if r.Hash != last.Hash || r.Hashes != total || r.Ticks != ticks {
+		return fmt.Errorf("ringkasan PoH (%.12s, %d hash, %d tick) ≠ entry (%.12s, %d hash, %d tick)",
+			r.Hash, r.Hashes, r.Ticks, last.Hash, total, ticks)
+	}
+	if root := pohRoot(r.Entries); r.Root != root {
+		return fmt.Errorf("root entry PoH %.12s ≠ %.12s", r.Root, root)
+	}
+	return nil
+}
+
+// VerifyPoH: entries berantai dari start. Awal tiap entry = hash entry
+// sebelumnya, jadi entry dibagi ke `workers` goroutine; error menyebut entry
+// gagal dengan index terkecil.
+func VerifyPoH(start string, entries []PoHEntry, workers int) error {
+	prev, ok := hash32(start)
+	if !ok {
+		return fmt.Errorf("hash awal PoH %q invalid", start)
+	}
+	parsed := make([]pohEntry, len(entries))
+	starts := make([][32]byte, len(entries))
+	for i, en := range entries {
+		p, err := parsePoHEntry(en)
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		if p.n == 0 {
+			return fmt.Errorf("entry %d: num_hashes 0", i)
+		}
+		parsed[i], starts[i], prev = p, prev, p.hash
+	}
+
+	workers = max(1, min(workers, len(entries)))
+	var next, bad atomic.Int64
+	bad.Store(int64(len(entries)))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if i >= int64(len(entries)) || i > bad.Load() {
+					return
+				}
+				if parsed[i].run(starts[i]) == parsed[i].hash {
+					continue
+				}
+				for cur := bad.Load(); i < cur && !bad.CompareAndSwap(cur, i); cur = bad.Load() {
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if i := bad.Load(); i < int64(len(entries)) {
+		return fmt.Errorf("entry %d: hash %.12s tidak cocok dengan %d hash dari %.12s",
+			i, entries[i].Hash, entries[i].NumHashes, hex.EncodeToString(starts[i][:]))
+	}
+	return nil
+}
+
+// verifyBlockPoH: segmen blok valid, menyambung ke parent & memuat semua TX blok.
+func verifyBlockPoH(b, parent Block) error {
+	if b.PoH == nil {
+		return fmt.Errorf("blok tanpa PoH")
+	}
+	if err := checkPoHRecord(b.PoH, Params.PoHHashesPerTick, Params.PoHMaxTicks); err != nil {
+		return err
+	}
+	mixed := make(map[string]bool, len(b.PoH.Entries))
+	for _, en := range b.PoH.Entries {
+		if en.Mixin != "" {
+			mixed[en.Mixin] = true
+		}
+	}
+	if len(mixed) > Params.MaxBlockTxs {
+		return fmt.Errorf("%d mixin > batas %d per blok", len(mixed), Params.MaxBlockTxs)
+	}
+	if err := VerifyPoH(PoHStart(parent), b.PoH.Entries, PoH.VerifyWorkers); err != nil {
+		return err
+	}
+	for i, tx := range b.Transactions {
+		if h := HashTransaction(tx); !mixed[h] {
+			return fmt.Errorf("tx %d (%.12s) tidak tercampur di PoH", i, h)
+		}
+	}
+	return nil
+}
+
+// VerifyStoredPoH: cek ulang segmen PoH blok tersimpan terhadap parent-nya.
+func VerifyStoredPoH(height int) (*PoHRecord, error) {
+	b, ok := GetBlockByHeight(height)
+	if !ok {
+		return nil, fmt.Errorf("❌ block #%d tidak ditemukan", height)
+	}
+	if b.PoH == nil {
+		return nil, fmt.Errorf("❌ block #%d tidak punya PoH (genesis / blok sebelum PoH)", height)
+	}
+	if len(b.PoH.Entries) == 0 {
+		return b.PoH, fmt.Errorf("❌ entry PoH block #%d sudah dipangkas", height)
+	}
+	parent, ok := GetBlockByHeight(height - 1)
+	if !ok {
+		return b.PoH, fmt.Errorf("❌ parent block #%d tidak ditemukan", height-1)
+	}
+	if err := checkPoHRecord(b.PoH, Params.PoHHashesPerTick, Params.PoHMaxTicks); err != nil {
+		return b.PoH, fmt.Errorf("❌ %w", err)
+	}
+	if err := VerifyPoH(PoHStart(parent), b.PoH.Entries, PoH.VerifyWorkers); err != nil {
+		return b.PoH, fmt.Errorf("❌ %w", err)
+	}
+	return b.PoH, nil
+}
+
+// ================== Generator ==================
+
+// pohRecorder: stream PoH lokal sejak base (= akhir PoH head).
+type pohRecorder struct {
+	mu         sync.Mutex
+	ready      bool
+	running    bool
+	base       [32]byte
+	hash       [32]byte
+	sinceEntry uint64 // hash murni yang belum masuk entry
+	sinceTick  uint64
+	ticks      int // tick sejak base
+	entries    []pohEntry
+	mixed      map[[32]byte]bool
+
+	started     time.Time
+	totalHashes uint64
+	totalTicks  uint64
+}
+
+var (
+	recorder = &pohRecorder{}
+	pohOnce  sync.Once
+)
+
+// reset memulai stream baru dari base. p.mu dipegang.
+func (p *pohRecorder) reset(base [32]byte) {
+	p.ready = true
+	p.base, p.hash = base, base
+	p.sinceEntry, p.sinceTick, p.ticks = 0, 0, 0
+	p.entries = nil
+	p.mixed = map[[32]byte]bool{}
+}
+
+// hashN: n hash murni. p.mu dipegang.
+func (p *pohRecorder) hashN(n uint64) {
+	h := p.hash
+	for i := uint64(0); i < n; i++ {
+		h = sha256.Sum256(h[:])
+	}
+	p.hash = h
+	p.sinceEntry += n
+	p.sinceTick += n
+	p.totalHashes += n
+}
+
+// finishTick: hash murni sampai batas tick lalu catat entry tick. p.mu dipegang.
+func (p *pohRecorder) finishTick() {
+	p.hashN(Params.PoHHashesPerTick - p.sinceTick)
+	p.entries = append(p.entries, pohEntry{n: p.sinceEntry, hash: p.hash})
+	p.sinceEntry, p.sinceTick = 0, 0
+	p.ticks++
+	p.totalTicks++
+}
+
+// mixin mencampur hash TX (sekali per stream). p.mu dipegang.
+func (p *pohRecorder) mixin(tx [32]byte) {
+	if p.mixed[tx] {
+		return
+	}
+	if p.sinceTick+1 >= Params.PoHHashesPerTick {
+		p.finishTick() // hash terakhir tick harus hash murni
+	}
+	p.hash = pohMix(p.hash, tx)
+	p.entries = append(p.entries, pohEntry{n: p.sinceEntry + 1, hash: p.hash, mixin: tx, mixed: true})
+	p.sinceEntry = 0
+	p.sinceTick++
+	p.totalHashes++
+	p.mixed[tx] = true
+}
+
+// step: lanjutkan stream sampai satu tick selesai. false = segmen sudah
+// penuh (Params.PoHMaxTicks − 1, sisa satu untuk tick penutup blok).
+func (p *pohRecorder) step() bool {
+	for {
+		p.mu.Lock()
+		if !p.ready || p.ticks >= Params.PoHMaxTicks-1 {
+			p.mu.Unlock()
+			return false
+		}
+		perTick := Params.PoHHashesPerTick
+		if left := perTick - p.sinceTick; left > 1 {
+			p.hashN(min(pohChunk, left-1))
+			p.mu.Unlock()
+			continue
+		}
+		p.finishTick()
+		persist := p.ticks%PoH.PersistTicks == 0
+		p.mu.Unlock()
+		if persist {
+			p.persist()
+		}
+		return true
+	}
+}
+
+func (p *pohRecorder) run() {
+	for {
+		start := time.Now()
+		pause := PoH.TickInterval
+		if !p.step() {
+			pause = max(pause, 10*time.Millisecond) // segmen penuh: tunggu blok berikutnya
+		}
+		if d := pause - time.Since(start); d > 0 {
+			time.Sleep(d)
+		}
+	}
+}
+
+// record: segmen PoH blok baru di atas parent. Stream dipindah ke akhir PoH
+// parent bila perlu, hash TX blok yang belum tercampur dicampur, lalu ditutup
+// tick. Stream tetap berlanjut sesudahnya (blok bisa saja tidak di-commit).
+func (p *pohRecorder) record(parent Block, txs []Transaction) (*PoHRecord, error) {
+	start, ok := hash32(PoHStart(parent))
+	if !ok {
+		return nil, fmt.Errorf("❌ awal PoH parent invalid")
+	}
+	p.mu.Lock()
+	if !p.ready || p.base != start {
+		p.reset(start)
+	}
+	build := func() {
+		for _, tx := range txs {
+			h, _ := hash32(HashTransaction(tx))
+			p.mixin(h)
+		}
+		if p.sinceTick > 0 || len(p.entries) == 0 {
+			p.finishTick()
+		}
+	}
+	build()
+	if p.ticks > Params.PoHMaxTicks || len(p.mixed) > Params.MaxBlockTxs {
+		// stream terlalu panjang (banyak mixin TX mempool): mulai ulang dari
+		// parent, hanya TX blok yang dicampur
+		p.reset(start)
+		build()
+	}
+	if p.ticks > Params.PoHMaxTicks {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("❌ %d TX butuh %d tick PoH > batas %d", len(txs), p.ticks, Params.PoHMaxTicks)
+	}
+	rec := &PoHRecord{Ticks: p.ticks, Entries: make([]PoHEntry, len(p.entries))}
+	for i, en := range p.entries {
+		rec.Entries[i] = en.export()
+		rec.Hashes += en.n
+	}
+	p.mu.Unlock()
+
+	rec.Hash = rec.Entries[len(rec.Entries)-1].Hash
+	rec.Root = pohRoot(rec.Entries)
+	p.persist()
+	return rec, nil
+}
+
+// committed: blok b masuk chain. Bila segmen b bagian dari stream lokal, stream
+// berlanjut dari akhir b; selain itu mulai ulang dari akhir PoH b.
+func (p *pohRecorder) committed(b Block) {
+	next, ok := hash32(PoHStart(b))
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	if !p.ready || p.base == next {
+		p.mu.Unlock()
+		return
+	}
+	keep := -1
+	for i, en := range p.entries {
+		if en.hash == next {
+			keep = i + 1
+			break
+		}
+	}
+	if keep < 0 {
+		p.reset(next)
+	} else {
+		rest := append([]pohEntry(nil), p.entries[keep:]...)
+		p.base, p.entries, p.ticks = next, rest, 0
+		p.mixed = map[[32]byte]bool{}
+		for _, en := range rest {
+			if en.mixed {
+				p.mixed[en.mixin] = true
+			} else {
+				p.ticks++
+			}
+		}
+	}
+	p.mu.Unlock()
+	p.persist()
+}
+
+// mixTx: hash TX yang baru masuk mempool (hanya bila generator berjalan).
+func (p *pohRecorder) mixTx(txHash string) {
+	h, ok := hash32(txHash)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	if p.running && p.ready {
+		p.mixin(h)
+	}
+	p.mu.Unlock()
+}
+
+// ================== Persistence ==================
+
+func (p *pohRecorder) persist() {
+	p.mu.Lock()
+	if !p.ready {
+		p.mu.Unlock()
+		return
+	}
+	e := NewEncoder(tagPoHStream)
+	e.Hex(hex.EncodeToString(p.base[:]))
+	e.Hex(hex.EncodeToString(p.hash[:]))
+	e.Uint64(p.sinceEntry)
+	e.Uint64(p.sinceTick)
+	entries := make([]PoHEntry, len(p.entries))
+	for i, en := range p.entries {
+		entries[i] = en.export()
+	}
+	encodePoHEntries(e, entries)
+	p.mu.Unlock()
+
+	InitDB()
+	if err := db.Put([]byte(keyPoHStream), e.Bytes(), nil); err != nil {
+		fmt.Println("⚠️ gagal simpan stream PoH:", err)
+	}
+}
+
+// load memulihkan stream tersimpan bila masih menyambung ke base; selain itu
+// stream baru dari base.
+func (p *pohRecorder) load(base [32]byte) bool {
+	InitDB()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset(base)
+	data, err := db.Get([]byte(keyPoHStream), nil)
+	if err != nil {
+		return false
+	}
+	d := NewDecoder(data, tagPoHStream)
+	storedBase, _ := hash32(d.Hex())
+	hash, _ := hash32(d.Hex())
+	sinceEntry, sinceTick := d.Uint64(), d.Uint64()
+	entries := decodePoHEntries(d)
+	if d.Finish() != nil || storedBase != base || sinceTick >= Params.PoHHashesPerTick {
+		return false
+	}
+	prev := base
+	for _, en := range entries {
+		pe, err := parsePoHEntry(en)
+		if err != nil {
+			p.reset(base)
+			return false
+		}
+		p.entries = append(p.entries, pe)
+		if pe.mixed {
+			p.mixed[pe.mixin] = true
+		} else {
+			p.ticks++
+		}
+		prev = pe.hash
+	}
+	if len(entries) > 0 && VerifyPoH(hex.EncodeToString(base[:]), entries, PoH.VerifyWorkers) != nil {
+		p.reset(base)
+		return false
+	}
+	// hash murni yang belum masuk entry dicek ulang dari entry terakhir
+	if (pohEntry{n: sinceEntry, hash: hash}).run(prev) != hash {
+		p.reset(base)
+		return false
+	}
+	p.hash, p.sinceEntry, p.sinceTick = hash, sinceEntry, sinceTick
+	return true
+}
+
+// StartPoH: jalankan generator (sekali) dari akhir PoH head.
+func StartPoH() {
+	pohOnce.Do(func() {
+		head := ensureGenesis()
+		base, _ := hash32(PoHStart(head))
+		restored := recorder.load(base)
+		recorder.mu.Lock()
+		recorder.running = true
+		recorder.started = time.Now()
+		entries, ticks := len(recorder.entries), recorder.ticks
+		recorder.mu.Unlock()
+		if restored && entries > 0 {
+			fmt.Printf("⏱️ PoH stream dipulihkan: %d entry (%d tick) sejak block #%d\n", entries, ticks, head.Index)
+		}
+		fmt.Printf("✅ PoH generator running (%d hash/tick, target %s/tick, max %d tick/blok)\n",
+			Params.PoHHashesPerTick, PoH.TickInterval, Params.PoHMaxTicks)
+		go recorder.run()
+	})
+}
+
+// PoHStats: posisi & laju generator lokal.
+type PoHStats struct {
+	Running     bool
+	Hash        string
+	Ticks       int // tick sejak akhir PoH head
+	Entries     int
+	TotalTicks  uint64
+	TotalHashes uint64
+	HashRate    float64 // hash/detik sejak start
+}
+
+func PoHStatus() PoHStats {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	st := PoHStats{
+		Running:     recorder.running,
+		Hash:        hex.EncodeToString(recorder.hash[:]),
+		Ticks:       recorder.ticks,
+		Entries:     len(recorder.entries),
+		TotalTicks:  recorder.totalTicks,
+		TotalHashes: recorder.totalHashes,
+	}
+	if recorder.running {
+		if dt := time.Since(recorder.started).Seconds(); dt > 0 {
+			st.HashRate = float64(recorder.totalHashes) / dt
+		}
+	}
+	return st
+}
+
+// ================== Codec ==================
+
+func encodePoHEntries(e *Encoder, entries []PoHEntry) {
+	e.Len(len(entries))
+	for _, en := range entries {
+		e.Uint64(en.NumHashes)
+		e.Hex(en.Hash)
+		e.Hex(en.Mixin)
+	}
+}
+
+func decodePoHEntries(d *Decoder) []PoHEntry {
+	n := d.Len()
+	var out []PoHEntry
+	for i := 0; i < n && d.Err() == nil; i++ {
+		var en PoHEntry
+		en.NumHashes = d.Uint64()
+		en.Hash = d.Hex()
+		en.Mixin = d.Hex()
+		out = append(out, en)
+	}
+	return out
+}
+
+// encodePoHSummary: bagian header (ikut hash blok).
+func encodePoHSummary(e *Encoder, r *PoHRecord) {
+	e.Hex(r.Hash)
+	e.Uint64(r.Hashes)
+	e.Int(r.Ticks)
+	e.Hex(r.Root)
+}
+
+func encodePoH(e *Encoder, r *PoHRecord) {
+	encodePoHSummary(e, r)
+	encodePoHEntries(e, r.Entries)
+}
+
+func decodePoH(d *Decoder) *PoHRecord {
+	r := &PoHRecord{}
+	r.Hash = d.Hex()
+	r.Hashes = d.Uint64()
+	r.Ticks = d.Int()
+	r.Root = d.Hex()
+	r.Entries = decodePoHEntries(d)
+	return r
+}
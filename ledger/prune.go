@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ================== Pruning (block body & state history) ==================
+//
+// Mode prune (HYPERLUX_PRUNE=1) untuk validator yang tidak butuh history penuh.
+// Di bawah horizon = head − Depth:
+//   - body TX & entry PoH blok dihapus, header (hash, root, signature,
+//     ringkasan PoH) tetap disimpan dengan tanda Pruned (ditolak validateBlock,
+//     jadi tidak pernah dianggap blok utuh)
+//   - versi state yang sudah tergantikan dihapus (antrian h/q/, lihat history.go)
+// Horizon body tidak melewati snapshot terbaru: blok sesudah snapshot tetap
+// utuh supaya node lain bisa bootstrap dari snapshot + blok sesudahnya.
+// Belum ada snapshot → diambil dulu; bila gagal body tidak dipangkas.
+// Receipt & index address tetap ada (tx-show menampilkan status tanpa body).
+//
+// Pruner jalan di goroutine sendiri; AppendBlock hanya memberi sinyal.
+
+const (
+	keyBodiesPruned   = "b/pruned" // height tertinggi yang body-nya sudah dihapus
+	DefaultPruneDepth = 10000
+	pruneChunk        = 256 // blok per batch
+)
+
+// PruneConfig: Enabled = pruning background aktif, Depth = jumlah blok
+// terakhir yang disimpan utuh.
+type PruneConfig struct {
+	Enabled bool
+	Depth   int
+}
+
+// PruneConfigFromEnv: HYPERLUX_PRUNE=1, HYPERLUX_PRUNE_DEPTH=<blok>.
+func PruneConfigFromEnv() PruneConfig {
+	cfg := PruneConfig{Depth: DefaultPruneDepth}
+	if v, err := strconv.ParseBool(os.Getenv("HYPERLUX_PRUNE")); err == nil {
+		cfg.Enabled = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_PRUNE_DEPTH")); err == nil && v > 0 {
+		cfg.Depth = v
+	}
+	return cfg
+}
+
+var Pruning = PruneConfigFromEnv()
+
+// pruneActive: archive node tidak pernah memangkas.
+func pruneActive() bool {
+	return Pruning.Enabled && !History.Archive
+}
+
+// PruneResult: hasil satu putaran pruning.
+type PruneResult struct {
+	From, To int // rentang height body yang dipangkas (To < From = tidak ada)
+	Blocks   int
+	Versions int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// PruneStats: akumulasi sejak node start.
+type PruneStats struct {
+	Runs           int
+	BlocksPruned   int
+	VersionsPruned int
+	BytesReclaimed int64
+	PrunedTo       int // body blok ≤ height ini sudah dihapus (-1 = belum ada)
+	LastRun        time.Time
+}
+
+var (
+	pruneMu      sync.Mutex // satu putaran pada satu waktu
+	pruneStatsMu sync.Mutex
+	pruneStats   PruneStats
+	pruneKick    = make(chan struct{}, 1)
+	pruneOnce    sync.Once
+)
+
+// schedulePrune membangunkan pruner tanpa menunggu (dipanggil AppendBlock).
+func schedulePrune() {
+	if !pruneActive() {
+		return
+	}
+	pruneOnce.Do(func() { go pruneLoop() })
+	select {
+	case pruneKick <- struct{}{}:
+	default: // sudah ada sinyal yang menunggu
+	}
+}
+
+func pruneLoop() {
+	for range pruneKick {
+		if _, err := PruneNow(); err != nil {
+			fmt.Println("⚠️ prune gagal:", err)
+		}
+	}
+}
+
+// BodiesPrunedTo: height tertinggi yang body TX-nya sudah dihapus (-1 = belum ada).
+func BodiesPrunedTo() int {
+	InitDB()
+	if data, err := db.Get([]byte(keyBodiesPruned), nil); err == nil {
+		if h, ok := decodeHeight(data); ok {
+			return h
+		}
+	}
+	return -1
+}
+
+// IsBodyPruned: blok di height tinggal header.
+func IsBodyPruned(height int) bool {
+	return height <= BodiesPrunedTo()
+}
+
+// latestSnapshotHeight: height snapshot terbaru di Snapshots.Dir.
+func latestSnapshotHeight() (int, bool) {
+	paths, err := snapshotFiles()
+	if err != nil || len(paths) == 0 {
+		return 0, false
+	}
+	var h int
+	if _, err := fmt.Sscanf(filepath.Base(paths[len(paths)-1]), "snapshot-%d", &h); err != nil {
+		return 0, false
+	}
+	return h, true
+}
+
+// PruneNow menjalankan satu putaran pruning dengan Pruning.Depth
+// (dipakai pruner background dan command `prune`).
+func PruneNow() (PruneResult, error) {
+	InitDB()
+	if History.Archive {
+		return PruneResult{}, fmt.Errorf("❌ archive node tidak dipangkas (HYPERLUX_ARCHIVE)")
+	}
+	pruneMu.Lock()
+	defer pruneMu.Unlock()
+	start := time.Now()
+
+	head := ChainHeight() - 1
+	bodyHorizon := head - Pruning.Depth
+	snap, ok := latestSnapshotHeight()
+	if !ok && bodyHorizon > BodiesPrunedTo() {
+		if info, err := CreateSnapshot(); err == nil {
+			snap = info.Height
+		} else {
+			fmt.Println("⚠️ prune: snapshot gagal, body tidak dipangkas:", err)
+			snap = BodiesPrunedTo()
+		}
+	}
+	bodyHorizon = min(bodyHorizon, snap)
+	histHorizon := head - Pruning.Depth
+	if History.Retention > 0 && History.Retention < Pruning.Depth {
+		histHorizon = head - History.Retention
+	}
+
+	res := PruneResult{From: BodiesPrunedTo() + 1, To: bodyHorizon}
+	for from := res.From; from <= bodyHorizon; from += pruneChunk {
+		to := min(from+pruneChunk-1, bodyHorizon)
+		blocks, bytes, err := pruneBodies(from, to)
+		res.Blocks += blocks
+		res.Bytes += bytes
+		if err != nil {
+			return res, err
+		}
+	}
+
+	batch := new(leveldb.Batch)
+	versions, bytes := pruneHistory(batch, histHorizon)
+	if err := db.Write(batch, nil); err != nil {
+		return res, err
+	}
+	res.Versions += versions
+	res.Bytes += bytes
+
+	if res.Bytes > 0 { // hapus fisik dari file LevelDB
+		_ = db.CompactRange(util.Range{Start: []byte(prefixBlockByHeight), Limit: heightKey(max(bodyHorizon, 0) + 1)})
+		_ = db.CompactRange(*util.BytesPrefix([]byte("h/")))
+	}
+	res.Duration = time.Since(start)
+
+	pruneStatsMu.Lock()
+	pruneStats.Runs++
+	pruneStats.BlocksPruned += res.Blocks
+	pruneStats.VersionsPruned += res.Versions
+	pruneStats.BytesReclaimed += res.Bytes
+	pruneStats.LastRun = start
+	pruneStatsMu.Unlock()
+
+	if res.Blocks > 0 || res.Versions > 0 {
+		fmt.Printf("✂️  Pruned body block %d..%d (%d blok) + %d versi state → %d bytes reclaimed (%.2f MiB) in %s\n",
+			res.From, res.To, res.Blocks, res.Versions, res.Bytes, float64(res.Bytes)/(1<<20), res.Duration.Round(time.Millisecond))
+	}
+	return res, nil
+}
+
+// pruneBodies menulis ulang blok from..to tanpa TX dalam satu batch.
+func pruneBodies(from, to int) (blocks int, bytes int64, err error) {
+	batch := new(leveldb.Batch)
+	for h := from; h <= to; h++ {
+		data, err := db.Get(heightKey(h), nil)
+		if err != nil {
+			continue // di bawah base snapshot
+		}
+		b, err := decodeStoredBlock(data)
+		if err != nil || (len(b.Transactions) == 0 && (b.PoH == nil || len(b.PoH.Entries) == 0)) {
+			continue
+		}
+		b.Transactions = nil
+		b.Pruned = true
+		if b.PoH != nil {
+			summary := *b.PoH
+			summary.Entries = nil
+			b.PoH = &summary
+		}
+		header := EncodeBlock(b)
+		batch.Put(heightKey(h), header)
+		blocks++
+		bytes += int64(len(data) - len(header))
+	}
+	batch.Put([]byte(keyBodiesPruned), encodeHeight(to))
+	if err := db.Write(batch, nil); err != nil {
+		return 0, 0, err
+	}
+	for h := from; h <= to; h++ {
+		blockCache.remove(h)
+	}
+	return blocks, bytes, nil
+}
+
+// PruneStatus: statistik pruning sejak start.
+func PruneStatus() PruneStats {
+	pruneStatsMu.Lock()
+	st := pruneStats
+	pruneStatsMu.Unlock()
+	st.PrunedTo = BodiesPrunedTo()
+	return st
+}
@@ -0,0 +1,322 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ================== Receipts & TX index ==================
+//
+// Layout di LevelDB:
+//   i/t/<txhash>                             → receipt (encoding kanonik)
+//   i/a/<addr>/<height 8B><index 4B><txhash> → arah (1 byte) + status (1 byte)
+//   i/height                                 → height terakhir yang sudah diindex
+//
+// Receipt sukses ditulis di batch yang sama dengan blok (AppendBlock), jadi
+// tersedia di semua node. Receipt gagal hanya dicatat node yang mencoba
+// memasukkan TX ke blok (proposer); TX-nya tidak masuk blok, index = -1.
+
+const (
+	prefixReceipt   = "i/t/"
+	prefixAddrIndex = "i/a/"
+	keyIndexHeight  = "i/height"
+
+	failedTxIndex = 0xFFFFFFFF // posisi index TX gagal: setelah semua TX blok di height yang sama
+)
+
+type ReceiptStatus byte
+
+const (
+	ReceiptSuccess ReceiptStatus = 1
+	ReceiptFailed  ReceiptStatus = 2
+)
+
+func (s ReceiptStatus) String() string {
+	switch s {
+	case ReceiptSuccess:
+		return "success"
+	case ReceiptFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// TxDirection: arah TX dilihat dari satu address (bitmask).
+type TxDirection byte
+
+const (
+	DirSent     TxDirection = 1
+	DirReceived TxDirection = 2
+	DirAll                  = DirSent | DirReceived
+)
+
+func (d TxDirection) String() string {
+	switch d {
+	case DirSent:
+		return "sent"
+	case DirReceived:
+		return "received"
+	case DirAll:
+		return "self"
+	}
+	return "-"
+}
+
+type Receipt struct {
+	TxHash    string
+	Status    ReceiptStatus
+	Height    int // blok yang memuat TX (gagal: height saat dicoba)
+	Index     int // posisi di blok, -1 untuk TX gagal
+	BlockHash string
+	FeePaid   Amount
+	Error     string // alasan gagal
+	Timestamp int64
+	Tx        Transaction
+	Pruned    bool // body blok sudah dipangkas: Tx kosong
+}
+
+// AccountTx: satu entry riwayat address (detail lengkap via GetReceipt).
+type AccountTx struct {
+	TxHash    string
+	Height    int
+	Index     int
+	Direction TxDirection
+	Status    ReceiptStatus
+}
+
+// ================== Encoding & keys ==================
+
+// encodeReceipt: TX hanya disimpan untuk receipt gagal; TX sukses diambil dari blok.
+func encodeReceipt(r Receipt) []byte {
+	e := NewEncoder(tagReceipt)
+	e.Hex(r.TxHash)
+	e.Uint64(uint64(r.Status))
+	e.Int(r.Height)
+	e.Int(r.Index)
+	e.Hex(r.BlockHash)
+	e.Uint64(uint64(r.FeePaid))
+	e.String(r.Error)
+	e.Int64(r.Timestamp)
+	if r.Status == ReceiptFailed {
+		e.Raw(EncodeTransaction(r.Tx))
+	} else {
+		e.Raw(nil)
+	}
+	return e.Bytes()
+}
+
+func decodeReceipt(data []byte) (Receipt, error) {
+	d := NewDecoder(data, tagReceipt)
+	var r Receipt
+	r.TxHash = d.Hex()
+	r.Status = ReceiptStatus(d.Uint64())
+	r.Height = d.Int()
+	r.Index = d.Int()
+	r.BlockHash = d.Hex()
+	r.FeePaid = Amount(d.Uint64())
+	r.Error = d.String()
+	r.Timestamp = d.Int64()
+	rawTx := d.Raw()
+	if err := d.Finish(); err != nil {
+		return Receipt{}, err
+	}
+	if len(rawTx) > 0 {
+		tx, err := DecodeTransaction(rawTx)
+		if err != nil {
+			return Receipt{}, err
+		}
+		r.Tx = tx
+	}
+	return r, nil
+}
+
+func receiptKey(txHash string) []byte {
+	return []byte(prefixReceipt + txHash)
+}
+
+func addrIndexPrefix(addr string) []byte {
+	return []byte(prefixAddrIndex + addr + "/")
+}
+
+func addrIndexKey(addr string, height, index int, txHash string) []byte {
+	k := addrIndexPrefix(addr)
+	k = binary.BigEndian.AppendUint64(k, uint64(height))
+	k = binary.BigEndian.AppendUint32(k, uint32(index))
+	return append(k, txHash...)
+}
+
+// ================== Indexing ==================
+
+// indexBlock menambahkan receipt sukses + index address semua TX blok ke batch.
+func indexBlock(batch *leveldb.Batch, b Block) {
+	for i, tx := range b.Transactions {
+		hash := HashTransaction(tx)
+		batch.Put(receiptKey(hash), encodeReceipt(Receipt{
+			TxHash:    hash,
+			Status:    ReceiptSuccess,
+			Height:    b.Index,
+			Index:     i,
+			BlockHash: b.Hash,
+			FeePaid:   tx.Fee,
+			Timestamp: b.Timestamp,
+		}))
+		val := []byte{byte(DirSent), byte(ReceiptSuccess)}
+		if tx.To == tx.From {
+			val[0] = byte(DirAll)
+		} else {
+			batch.Put(addrIndexKey(tx.To, b.Index, i, hash), []byte{byte(DirReceived), byte(ReceiptSuccess)})
+		}
+		batch.Put(addrIndexKey(tx.From, b.Index, i, hash), val)
+	}
+	batch.Put([]byte(keyIndexHeight), encodeHeight(b.Index))
+}
+
+// unindexBlock: kebalikan indexBlock untuk blok yang dibuang (rollback head).
+func unindexBlock(batch *leveldb.Batch, b Block) {
+	for i, tx := range b.Transactions {
+		hash := HashTransaction(tx)
+		batch.Delete(receiptKey(hash))
+		batch.Delete(addrIndexKey(tx.To, b.Index, i, hash))
+		batch.Delete(addrIndexKey(tx.From, b.Index, i, hash))
+	}
+}
+
+// reindexTxs: index blok yang belum punya receipt (chain dari versi sebelum index ada).
+func reindexTxs(head int) {
+	from := 0
+	if data, err := db.Get([]byte(keyIndexHeight), nil); err == nil {
+		if h, ok := decodeHeight(data); ok {
+			from = h + 1
+		}
+	}
+	if from > head {
+		return
+	}
+	for h := from; h <= head; h++ {
+		b, ok := GetBlockByHeight(h)
+		if !ok {
+			fmt.Printf("⚠️ reindex tx: block %d tidak ditemukan\n", h)
+			return
+		}
+		batch := new(leveldb.Batch)
+		indexBlock(batch, b)
+		if err := db.Write(batch, nil); err != nil {
+			fmt.Println("⚠️ reindex tx gagal:", err)
+			return
+		}
+	}
+	fmt.Printf("🔁 Indexed receipts for blocks %d..%d\n", from, head)
+}
+
+// RecordFailedTxs mencatat receipt gagal untuk TX yang ditolak executor saat
+// membangun blok di height tsb (results dari ProcessTxListResults), lalu
+// membuangnya dari mempool. TX yang sudah punya receipt sukses dilewati.
+func RecordFailedTxs(height int, txs []Transaction, results []error) {
+	InitDB()
+	batch := new(leveldb.Batch)
+	var failed []Transaction
+	now := time.Now().Unix()
+	for i, err := range results {
+		if err == nil {
+			continue
+		}
+		tx := txs[i]
+		failed = append(failed, tx)
+		hash := HashTransaction(tx)
+		if r, ok := GetReceipt(hash); ok && r.Status == ReceiptSuccess {
+			continue
+		}
+		batch.Put(receiptKey(hash), encodeReceipt(Receipt{
+			TxHash:    hash,
+			Status:    ReceiptFailed,
+			Height:    height,
+			Index:     -1,
+			Error:     err.Error(),
+			Timestamp: now,
+			Tx:        tx,
+		}))
+		batch.Put(addrIndexKey(tx.From, height, failedTxIndex, hash), []byte{byte(DirSent), byte(ReceiptFailed)})
+	}
+	if len(failed) == 0 {
+		return
+	}
+	if err := db.Write(batch, nil); err != nil {
+		fmt.Println("⚠️ gagal simpan receipt:", err)
+	}
+	Pool.Remove(failed)
+}
+
+// ================== Public API ==================
+
+// GetReceipt: receipt TX berdasarkan hash (Tx terisi dari blok untuk TX sukses,
+// kecuali body blok sudah dipangkas).
+func GetReceipt(txHash string) (Receipt, bool) {
+	InitDB()
+	data, err := db.Get(receiptKey(txHash), nil)
+	if err != nil {
+		return Receipt{}, false
+	}
+	r, err := decodeReceipt(data)
+	if err != nil {
+		fmt.Printf("⚠️ receipt %.12s corrupt: %v\n", txHash, err)
+		return Receipt{}, false
+	}
+	if r.Status == ReceiptSuccess {
+		if IsBodyPruned(r.Height) {
+			r.Pruned = true
+			return r, true
+		}
+		b, ok := GetBlockByHeight(r.Height)
+		if !ok || r.Index >= len(b.Transactions) {
+			return Receipt{}, false
+		}
+		r.Tx = b.Transactions[r.Index]
+	}
+	return r, true
+}
+
+// AccountHistory: TX yang melibatkan addr (terbaru dulu), difilter arah dir.
+// offset/limit untuk paginasi (limit <= 0 = semua); more = masih ada entry setelah halaman ini.
+func AccountHistory(addr string, dir TxDirection, offset, limit int) (txs []AccountTx, more bool) {
+	InitDB()
+	prefix := addrIndexPrefix(addr)
+	it := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	skipped := 0
+	for ok := it.Last(); ok; ok = it.Prev() {
+		key, val := it.Key(), it.Value()
+		if len(val) != 2 || len(key) < len(prefix)+12 || TxDirection(val[0])&dir == 0 {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		if limit > 0 && len(txs) == limit {
+			return txs, true
+		}
+		rest := key[len(prefix):]
+		index := int(binary.BigEndian.Uint32(rest[8:12]))
+		if index == failedTxIndex {
+			index = -1
+		}
+		txs = append(txs, AccountTx{
+			TxHash:    string(rest[12:]),
+			Height:    int(binary.BigEndian.Uint64(rest[:8])),
+			Index:     index,
+			Direction: TxDirection(val[0]),
+			Status:    ReceiptStatus(val[1]),
+		})
+	}
+	return txs, false
+}
+
+// PendingTx: TX yang masih menunggu di mempool.
+func PendingTx(txHash string) (Transaction, bool) {
+	return Pool.Get(txHash)
+}
@@ -0,0 +1,346 @@
+package ledger
+
+import (
+	"container/list"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"filippo.io/edwards25519"
+)
+
+// ================== Signature verification ==================
+//
+// Aturan konsensus untuk signature ed25519 TX (cofactored, seperti ZIP-215):
+//
+//	[8]·(s·B − k·A − R) == O,  k = SHA512(R || A || M)
+//
+// Verifikasi tunggal dan batch memakai persamaan yang sama, jadi hasil batch
+// selalu sama dengan verifikasi satu-per-satu, tidak peduli bagaimana node
+// membagi TX ke batch (tergantung jumlah CPU). Signature dari wallet normal
+// juga lolos di ed25519.Verify biasa.
+//
+// TX yang signature-nya sudah terbukti valid disimpan di SigCache (LRU, key =
+// hash encoding kanonik penuh termasuk pubkey & signature), sehingga TX yang
+// dicek saat masuk mempool tidak diverifikasi ulang saat commit blok.
+
+const (
+	DefaultSigCacheSize = 100000
+	sigBatchMin         = 8   // di bawah ini batch tidak lebih cepat
+	sigBatchMax         = 128 // batas per batch; sisanya dibagi ke worker lain
+)
+
+// ===================== Metrics =====================
+
+var (
+	sigSingleVerifies atomic.Uint64
+	sigBatches        atomic.Uint64
+	sigBatchedSigs    atomic.Uint64
+	sigBatchFallbacks atomic.Uint64
+)
+
+// SigVerifyStats: ringkasan cache & batch verifier (per proses).
+type SigVerifyStats struct {
+	CacheSize      int
+	CacheCap       int
+	CacheHits      uint64
+	CacheMisses    uint64
+	CacheEvictions uint64
+	SingleVerifies uint64 // verifikasi di luar batch (mempool, fallback)
+	Batches        uint64
+	BatchedSigs    uint64
+	BatchFallbacks uint64 // batch gagal → dicek satu-per-satu
+}
+
+func (s SigVerifyStats) HitRate() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+func SigStats() SigVerifyStats {
+	st := SigCache.stats()
+	st.SingleVerifies = sigSingleVerifies.Load()
+	st.Batches = sigBatches.Load()
+	st.BatchedSigs = sigBatchedSigs.Load()
+	st.BatchFallbacks = sigBatchFallbacks.Load()
+	return st
+}
+
+// ResetSigStats: nolkan counter (cache tidak dikosongkan).
+func ResetSigStats() {
+	SigCache.mu.Lock()
+	SigCache.hits, SigCache.misses, SigCache.evictions = 0, 0, 0
+	SigCache.mu.Unlock()
+	sigSingleVerifies.Store(0)
+	sigBatches.Store(0)
+	sigBatchedSigs.Store(0)
+	sigBatchFallbacks.Store(0)
+}
+
+// ===================== Verified-signature cache =====================
+
+type sigKey [32]byte
+
+type VerifiedSigCache struct {
+	mu    sync.Mutex
+	cap   int
+	ll    *list.List // depan = paling baru dipakai
+	items map[sigKey]*list.Element
+
+	hits, misses, evictions uint64
+}
+
+// SigCache: ukuran bisa diatur via HYPERLUX_SIGCACHE_SIZE (0 = nonaktif).
+var SigCache = NewVerifiedSigCache(sigCacheSizeFromEnv())
+
+func sigCacheSizeFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_SIGCACHE_SIZE")); err == nil && v >= 0 {
+		return v
+	}
+	return DefaultSigCacheSize
+}
+
+func NewVerifiedSigCache(capacity int) *VerifiedSigCache {
+	return &VerifiedSigCache{cap: capacity, ll: list.New(), items: map[sigKey]*list.Element{}}
+}
+
+func txSigKey(tx Transaction) sigKey {
+	return sha256.Sum256(EncodeTransaction(tx))
+}
+
+func (c *VerifiedSigCache) contains(k sigKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		c.ll.MoveToFront(el)
+		c.hits++
+		return true
+	}
+	c.misses++
+	return false
+}
+
+func (c *VerifiedSigCache) add(k sigKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cap <= 0 {
+		return
+	}
+	if el, ok := c.items[k]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[k] = c.ll.PushFront(k)
+	c.evictLocked()
+}
+
+func (c *VerifiedSigCache) evictLocked() {
+	for c.ll.Len() > max(c.cap, 0) {
+		old := c.ll.Back()
+		c.ll.Remove(old)
+		delete(c.items, old.Value.(sigKey))
+		c.evictions++
+	}
+}
+
+// SetCapacity: ubah kapasitas (entry paling lama dibuang bila perlu).
+func (c *VerifiedSigCache) SetCapacity(capacity int) {
+	c.mu.Lock()
+	c.cap = capacity
+	c.evictLocked()
+	c.mu.Unlock()
+}
+
+func (c *VerifiedSigCache) Clear() {
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = map[sigKey]*list.Element{}
+	c.mu.Unlock()
+}
+
+func (c *VerifiedSigCache) stats() SigVerifyStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return SigVerifyStats{
+		CacheSize:      c.ll.Len(),
+		CacheCap:       c.cap,
+		CacheHits:      c.hits,
+		CacheMisses:    c.misses,
+		CacheEvictions: c.evictions,
+	}
+}
+
+// ===================== Single & batch verify =====================
+
+// sigInput: signature yang sudah di-parse, siap untuk persamaan verifikasi.
+type sigInput struct {
+	A, R *edwards25519.Point
+	s, k *edwards25519.Scalar
+}
+
+// txSigInput: parse pubkey/signature TX; false = format tidak valid.
+func txSigInput(tx Transaction) (sigInput, bool) {
+	// hex wajib kanonik (lowercase) supaya hash TX tidak bisa diubah tanpa mengubah isi
+	pub, ok1 := canonicalHex(tx.PubKey)
+	sig, ok2 := canonicalHex(tx.Signature)
+	payload := TxSigningBytes(tx)
+	if !ok1 || !ok2 || payload == nil {
+		return sigInput{}, false
+	}
+	return parseSig(pub, payload, sig)
+}
+
+func parseSig(pub, msg, sig []byte) (sigInput, bool) {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return sigInput{}, false
+	}
+	A, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return sigInput{}, false
+	}
+	R, err := new(edwards25519.Point).SetBytes(sig[:32])
+	if err != nil {
+		return sigInput{}, false
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return sigInput{}, false
+	}
+	h := sha512.New()
+	h.Write(sig[:32])
+	h.Write(pub)
+	h.Write(msg)
+	k, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	return sigInput{A: A, R: R, s: s, k: k}, true
+}
+
+func (in sigInput) verify() bool {
+	sigSingleVerifies.Add(1)
+	// R' = s·B − k·A; valid jika [8]·(R' − R) = O
+	minusA := new(edwards25519.Point).Negate(in.A)
+	p := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(in.k, minusA, in.s)
+	p.Subtract(p, in.R)
+	return p.MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1
+}
+
+// verifyBatch: satu multi-scalar multiplication untuk semua signature,
+//
+//	[8]·(−(Σ zᵢsᵢ)·B + Σ zᵢ·Rᵢ + Σ (zᵢkᵢ)·Aᵢ) == O
+//
+// dengan zᵢ acak 128-bit. Jika gagal, cari yang salah satu-per-satu.
+func verifyBatch(ins []sigInput) []bool {
+	ok := make([]bool, len(ins))
+	if len(ins) < sigBatchMin {
+		for i, in := range ins {
+			ok[i] = in.verify()
+		}
+		return ok
+	}
+	sigBatches.Add(1)
+	sigBatchedSigs.Add(uint64(len(ins)))
+
+	scalars := make([]*edwards25519.Scalar, 0, 2*len(ins)+1)
+	points := make([]*edwards25519.Point, 0, 2*len(ins)+1)
+	bCoeff := edwards25519.NewScalar()
+	var zb [32]byte
+	for _, in := range ins {
+		if _, err := rand.Read(zb[:16]); err != nil {
+			panic(err) // crypto/rand tidak pernah gagal di platform yang didukung
+		}
+		z, _ := edwards25519.NewScalar().SetCanonicalBytes(zb[:]) // < 2^128, selalu kanonik
+		bCoeff.MultiplyAdd(z, in.s, bCoeff)
+		scalars = append(scalars, z, edwards25519.NewScalar().Multiply(z, in.k))
+		points = append(points, in.R, in.A)
+	}
+	scalars = append(scalars, bCoeff.Negate(bCoeff))
+	points = append(points, edwards25519.NewGeneratorPoint())
+
+	p := new(edwards25519.Point).VarTimeMultiScalarMult(scalars, points)
+	if p.MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		for i := range ok {
+			ok[i] = true
+		}
+		return ok
+	}
+	sigBatchFallbacks.Add(1)
+	for i, in := range ins {
+		ok[i] = in.verify()
+	}
+	return ok
+}
+
+// VerifyTransaction: cek signature TX (memakai & mengisi SigCache).
+func VerifyTransaction(tx Transaction) bool {
+	key := txSigKey(tx)
+	if SigCache.contains(key) {
+		return true
+	}
+	in, ok := txSigInput(tx)
+	if !ok || !in.verify() {
+		return false
+	}
+	SigCache.add(key)
+	return true
+}
+
+// verifyTxSigs: signature banyak TX sekaligus (paralel, batch per worker).
+// Hanya TX dengan errs[i] == nil yang dicek; yang gagal diisi ErrTxSignature.
+func verifyTxSigs(txs []Transaction, errs []error) {
+	keys := make([]sigKey, len(txs))
+	ins := make([]sigInput, len(txs))
+	parsed := make([]bool, len(txs))
+
+	parallelFor(len(txs), func(i int) {
+		if errs[i] != nil {
+			return
+		}
+		keys[i] = txSigKey(txs[i])
+		if SigCache.contains(keys[i]) {
+			return
+		}
+		if ins[i], parsed[i] = txSigInput(txs[i]); !parsed[i] {
+			errs[i] = ErrTxSignature
+		}
+	})
+
+	var pending []int
+	for i, p := range parsed {
+		if p {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	workers := runtime.NumCPU()
+	size := min(max((len(pending)+workers-1)/workers, sigBatchMin), sigBatchMax)
+	var chunks [][]int
+	for len(pending) > 0 {
+		n := min(size, len(pending))
+		chunks = append(chunks, pending[:n])
+		pending = pending[n:]
+	}
+	parallelFor(len(chunks), func(c int) {
+		idx := chunks[c]
+		batch := make([]sigInput, len(idx))
+		for j, i := range idx {
+			batch[j] = ins[i]
+		}
+		for j, valid := range verifyBatch(batch) {
+			if valid {
+				SigCache.add(keys[idx[j]])
+			} else {
+				errs[idx[j]] = ErrTxSignature
+			}
+		}
+	})
+}
@@ -0,0 +1,606 @@
+package ledger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ================== State snapshots & checkpoint restore ==================
+//
+// Snapshot = state lengkap setelah blok Height (balances, nonce, validator,
+// treasury, burned) + blok di height itu + metadata chain (chain ID, params,
+// genesis). Node baru cukup restore snapshot lalu mengimpor blok sesudahnya
+// (ImportBlock) tanpa replay dari genesis.
+//
+// File: <Dir>/snapshot-<height 12 digit>.hls
+//   = encoding kanonik (tag 0x22) + sha256(encoding) 32 byte sebagai checksum.
+// State root snapshot dihitung ulang saat load dan harus sama dengan
+// StateRoot blok di height tsb, jadi isi state terikat ke hash blok. Saat
+// restore, genesis snapshot harus sama dengan genesis lokal dan hash blok
+// harus dari sumber tepercaya (trust hash / sertifikat commit, SnapshotTrust).
+
+const (
+	snapshotVersion = 3 // v2: + parameter PoH, v3: + parameter & active set epoch
+	snapshotExt     = ".hls"
+
+	keyChainBase = "b/base" // height blok terendah yang tersimpan (setelah restore)
+
+	DefaultSnapshotInterval = 1000
+	DefaultSnapshotKeep     = 3
+	DefaultSnapshotDir      = "hyperlux_snapshots"
+)
+
+var (
+	ErrSnapshotNotFound  = errors.New("snapshot tidak ditemukan")
+	ErrSnapshotCorrupt   = errors.New("snapshot corrupt")
+	ErrSnapshotStateRoot = errors.New("state root snapshot tidak cocok")
+	ErrChainNotEmpty     = errors.New("chain sudah ada")
+	ErrSnapshotGenesis   = errors.New("snapshot bukan dari genesis lokal")
+	ErrSnapshotUntrusted = errors.New("blok snapshot tidak terikat sumber tepercaya")
+)
+
+// SnapshotConfig: Interval = snapshot otomatis tiap N blok (0 = nonaktif),
+// Keep = jumlah snapshot terbaru yang disimpan (0 = semua).
+type SnapshotConfig struct {
+	Interval int
+	Keep     int
+	Dir      string
+}
+
+// SnapshotConfigFromEnv: HYPERLUX_SNAPSHOT_INTERVAL, HYPERLUX_SNAPSHOT_KEEP,
+// HYPERLUX_SNAPSHOT_DIR.
+func SnapshotConfigFromEnv() SnapshotConfig {
+	cfg := SnapshotConfig{Interval: DefaultSnapshotInterval, Keep: DefaultSnapshotKeep, Dir: DefaultSnapshotDir}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_SNAPSHOT_INTERVAL")); err == nil && v >= 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_SNAPSHOT_KEEP")); err == nil && v >= 0 {
+		cfg.Keep = v
+	}
+	if v := os.Getenv("HYPERLUX_SNAPSHOT_DIR"); v != "" {
+		cfg.Dir = v
+	}
+	return cfg
+}
+
+var Snapshots = SnapshotConfigFromEnv()
+
+type Snapshot struct {
+	Height     int
+	StateRoot  string
+	CreatedAt  int64
+	ChainID    string
+	Params     ChainParams
+	Genesis    []byte // genesis.json asli dari DB (wajib untuk restore)
+	Block      Block  // blok di Height, jadi head setelah restore
+	Balances   map[string]Amount
+	Nonces     map[string]int
+	Validators []ValidatorDef
+	Treasury   Amount
+	Burned     Amount
+	Epoch      *EpochSet // active set untuk blok Height+1 (nil = dihitung ulang saat restore)
+}
+
+// SnapshotInfo: ringkasan untuk listing.
+type SnapshotInfo struct {
+	Height    int
+	BlockHash string
+	StateRoot string
+	CreatedAt int64
+	Accounts  int
+	Size      int64
+	Path      string
+}
+
+func (s *Snapshot) info(path string, size int64) SnapshotInfo {
+	return SnapshotInfo{
+		Height:    s.Height,
+		BlockHash: s.Block.Hash,
+		StateRoot: s.StateRoot,
+		CreatedAt: s.CreatedAt,
+		Accounts:  len(s.Balances),
+		Size:      size,
+		Path:      path,
+	}
+}
+
+// SnapshotPath: lokasi file snapshot untuk height di Snapshots.Dir.
+func SnapshotPath(height int) string {
+	return filepath.Join(Snapshots.Dir, fmt.Sprintf("snapshot-%012d%s", height, snapshotExt))
+}
+
+// ================== Encoding ==================
+
+func encodeSnapshot(s *Snapshot) []byte {
+	e := NewEncoder(tagSnapshot)
+	e.Uint64(snapshotVersion)
+	e.String(s.ChainID)
+	e.Int(s.Height)
+	e.Hex(s.StateRoot)
+	e.Int64(s.CreatedAt)
+	e.Uint64(uint64(s.Params.BlockReward))
+	e.Uint64(uint64(s.Params.FeePerByte))
+	e.Int(s.Params.MaxBlockBytes)
+	e.Int(s.Params.MaxBlockTxs)
+	e.Uint64(s.Params.PoHHashesPerTick)
+	e.Int(s.Params.PoHMaxTicks)
+	e.Int(s.Params.EpochLength)
+	e.Int(s.Params.MaxActiveValidators)
+	e.Raw(s.Genesis)
+	e.Raw(EncodeBlock(s.Block))
+	e.Uint64(uint64(s.Treasury))
+	e.Uint64(uint64(s.Burned))
+
+	// akun urut address; akun nol dilewati (sama seperti state root)
+	seen := make(map[string]struct{}, len(s.Balances)+len(s.Nonces))
+	for addr := range s.Balances {
+		seen[addr] = struct{}{}
+	}
+	for addr := range s.Nonces {
+		seen[addr] = struct{}{}
+	}
+	addrs := make([]string, 0, len(seen))
+	for addr := range seen {
+		if s.Balances[addr] != 0 || s.Nonces[addr] != 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	e.Len(len(addrs))
+	for _, addr := range addrs {
+		e.String(addr)
+		e.Uint64(uint64(s.Balances[addr]))
+		e.Int(s.Nonces[addr])
+	}
+	e.Raw(encodeValidatorSet(s.Validators))
+	if s.Epoch != nil {
+		e.Raw(encodeEpochSet(*s.Epoch))
+	} else {
+		e.Raw(nil)
+	}
+	return e.Bytes()
+}
+
+func decodeSnapshot(data []byte) (*Snapshot, error) {
+	d := NewDecoder(data, tagSnapshot)
+	v := d.Uint64()
+	if d.Err() == nil && (v < 1 || v > snapshotVersion) {
+		return nil, fmt.Errorf("versi snapshot %d tidak dikenal", v)
+	}
+	s := &Snapshot{Balances: map[string]Amount{}, Nonces: map[string]int{}}
+	s.ChainID = d.String()
+	s.Height = d.Int()
+	s.StateRoot = d.Hex()
+	s.CreatedAt = d.Int64()
+	s.Params.BlockReward = Amount(d.Uint64())
+	s.Params.FeePerByte = Amount(d.Uint64())
+	s.Params.MaxBlockBytes = d.Int()
+	s.Params.MaxBlockTxs = d.Int()
+	s.Params.PoHHashesPerTick, s.Params.PoHMaxTicks = DefaultChainParams().PoHHashesPerTick, DefaultChainParams().PoHMaxTicks
+	if v >= 2 {
+		s.Params.PoHHashesPerTick = d.Uint64()
+		s.Params.PoHMaxTicks = d.Int()
+	}
+	s.Params.EpochLength, s.Params.MaxActiveValidators = DefaultChainParams().EpochLength, DefaultChainParams().MaxActiveValidators
+	if v >= 3 {
+		s.Params.EpochLength = d.Int()
+		s.Params.MaxActiveValidators = d.Int()
+	}
+	s.Genesis = d.Raw()
+	rawBlock := d.Raw()
+	s.Treasury = Amount(d.Uint64())
+	s.Burned = Amount(d.Uint64())
+	n := d.Len()
+	for i := 0; i < n && d.Err() == nil; i++ {
+		addr := d.String()
+		if bal := Amount(d.Uint64()); bal != 0 {
+			s.Balances[addr] = bal
+		}
+		if nonce := d.Int(); nonce != 0 {
+			s.Nonces[addr] = nonce
+		}
+	}
+	rawVals := d.Raw()
+	var rawEpoch []byte
+	if v >= 3 {
+		rawEpoch = d.Raw()
+	}
+	if err := d.Finish(); err != nil {
+		return nil, err
+	}
+	b, err := DecodeBlock(rawBlock)
+	if err != nil {
+		return nil, fmt.Errorf("block: %w", err)
+	}
+	s.Block = b
+	if s.Validators, err = decodeValidatorSet(rawVals); err != nil {
+		return nil, fmt.Errorf("validators: %w", err)
+	}
+	if len(rawEpoch) > 0 {
+		ep, err := decodeEpochSet(rawEpoch)
+		if err != nil {
+			return nil, fmt.Errorf("epoch: %w", err)
+		}
+		s.Epoch = &ep
+	}
+	return s, nil
+}
+
+// rootState: isi snapshot yang di-commit state root blok (termasuk treasury,
+// burned & active set blok berikutnya).
+func (s *Snapshot) rootState() rootState {
+	return rootState{
+		balances:   s.Balances,
+		nonces:     s.Nonces,
+		validators: s.Validators,
+		treasury:   s.Treasury,
+		burned:     s.Burned,
+		epoch:      s.Epoch,
+	}
+}
+
+// Verify: blok cocok dengan height & hash header (chain ID snapshot), state
+// root dihitung ulang dari isi snapshot (akun, stake, supply, set epoch) sama
+// dengan StateRoot blok, dan seed set epoch diturunkan dari blok snapshot.
+func (s *Snapshot) Verify() error {
+	if s.Block.Index != s.Height {
+		return fmt.Errorf("%w: block #%d ≠ height %d", ErrSnapshotCorrupt, s.Block.Index, s.Height)
+	}
+	if h := hashBlockHeaderFor(s.ChainID, s.Block); h != s.Block.Hash {
+		return fmt.Errorf("%w: hash block %.12s ≠ %.12s", ErrSnapshotCorrupt, s.Block.Hash, h)
+	}
+	root := s.rootState().rootHex()
+	if root != s.StateRoot || (s.Block.StateRoot != "" && root != s.Block.StateRoot) {
+		return fmt.Errorf("%w: state %.12s, snapshot %.12s, block %.12s", ErrSnapshotStateRoot, root, s.StateRoot, s.Block.StateRoot)
+	}
+	ep := s.Epoch
+	if ep == nil {
+		return nil
+	}
+	if s.Params.EpochLength < 1 || ep.Epoch != (s.Height+1)/s.Params.EpochLength || ep.Start > s.Height+1 {
+		return fmt.Errorf("%w: set epoch %d (mulai #%d) bukan untuk block #%d", ErrSnapshotCorrupt, ep.Epoch, ep.Start, s.Height+1)
+	}
+	// blok tanpa VRF (genesis / dev) di tengah epoch mewarisi seed dari blok
+	// sebelumnya, jadi hanya bisa dicek di boundary
+	inherited := s.Block.VRF == nil && s.Height/s.Params.EpochLength == ep.Epoch
+	if !inherited && ep.Seed != epochSeedFor(s.ChainID, s.Params.EpochLength, s.Block, s.Height+1) {
+		return fmt.Errorf("%w: seed set epoch %.12s tidak diturunkan dari block #%d", ErrSnapshotCorrupt, ep.Seed, s.Height)
+	}
+	return nil
+}
+
+// VerifyGenesis: genesis di snapshot harus menghasilkan genesisHash (genesis
+// lokal / yang dikonfigurasi), dengan chain ID & params yang sama.
+func (s *Snapshot) VerifyGenesis(genesisHash string) (Genesis, error) {
+	var g Genesis
+	if len(s.Genesis) == 0 || json.Unmarshal(s.Genesis, &g) != nil {
+		return g, fmt.Errorf("%w: snapshot tanpa genesis yang valid", ErrSnapshotGenesis)
+	}
+	if h := g.Hash(); h != genesisHash {
+		return g, fmt.Errorf("%w: genesis snapshot %.12s ≠ %.12s", ErrSnapshotGenesis, h, genesisHash)
+	}
+	if g.ChainID != s.ChainID || g.Params != s.Params {
+		return g, fmt.Errorf("%w: chain ID / params snapshot berbeda dari genesis", ErrSnapshotGenesis)
+	}
+	return g, nil
+}
+
+// ================== Create / list / load ==================
+
+// AddCheckpoint: snapshot otomatis tiap Snapshots.Interval blok (dipanggil
+// setelah blok di-commit, state global = state setelah b).
+func AddCheckpoint(b Block) {
+	if Snapshots.Interval <= 0 || b.Index == 0 || b.Index%Snapshots.Interval != 0 {
+		return
+	}
+	if _, err := CreateSnapshot(); err != nil {
+		fmt.Println("⚠️ snapshot gagal:", err)
+	}
+}
+
+// CreateSnapshot menulis snapshot state saat ini di head. Ditolak bila state
+// sudah berubah di luar blok sejak head (root tidak cocok StateRoot head).
+func CreateSnapshot() (SnapshotInfo, error) {
+	InitDB()
+	head, ok := HeadBlock()
+	if !ok {
+		return SnapshotInfo{}, fmt.Errorf("❌ chain kosong")
+	}
+	balances, nonces := copyAccountState()
+	treasury, burned := Supply()
+	s := &Snapshot{
+		Height:     head.Index,
+		CreatedAt:  time.Now().Unix(),
+		ChainID:    ChainID,
+		Params:     Params,
+		Block:      head,
+		Balances:   balances,
+		Nonces:     nonces,
+		Validators: append([]ValidatorDef(nil), GetValidators()...),
+		Treasury:   treasury,
+		Burned:     burned,
+	}
+	if ep, ok := epochSetFor(head.Index + 1); ok {
+		s.Epoch = &ep
+	}
+	s.StateRoot = s.rootState().rootHex()
+	if head.StateRoot != "" && s.StateRoot != head.StateRoot {
+		return SnapshotInfo{}, fmt.Errorf("❌ %w: state %.12s ≠ block #%d %.12s (state berubah di luar blok, commit blok dulu)",
+			ErrSnapshotStateRoot, s.StateRoot, head.Index, head.StateRoot)
+	}
+	if g, err := db.Get([]byte(keyGenesis), nil); err == nil {
+		s.Genesis = g
+	}
+
+	if err := os.MkdirAll(Snapshots.Dir, 0755); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("❌ gagal buat dir snapshot: %w", err)
+	}
+	enc := encodeSnapshot(s)
+	sum := sha256.Sum256(enc)
+	path := SnapshotPath(s.Height)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(enc, sum[:]...), 0644); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("❌ gagal tulis snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("❌ gagal tulis snapshot: %w", err)
+	}
+	fmt.Printf("📸 Snapshot block #%d → %s\n", s.Height, path)
+	pruneSnapshots()
+	return s.info(path, int64(len(enc)+len(sum))), nil
+}
+
+// LoadSnapshot membaca file snapshot dan memverifikasi checksum + state root.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < sha256.Size {
+		return nil, fmt.Errorf("%w: %s terlalu pendek", ErrSnapshotCorrupt, path)
+	}
+	enc, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if want := sha256.Sum256(enc); !bytes.Equal(sum, want[:]) {
+		return nil, fmt.Errorf("%w: checksum %s", ErrSnapshotCorrupt, path)
+	}
+	s, err := decodeSnapshot(enc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+	}
+	if err := s.Verify(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListSnapshots: snapshot valid di Snapshots.Dir, urut height naik.
+func ListSnapshots() ([]SnapshotInfo, error) {
+	paths, err := snapshotFiles()
+	if err != nil {
+		return nil, err
+	}
+	var out []SnapshotInfo
+	for _, path := range paths {
+		s, err := LoadSnapshot(path)
+		if err != nil {
+			fmt.Println("⚠️", err)
+			continue
+		}
+		var size int64
+		if st, err := os.Stat(path); err == nil {
+			size = st.Size()
+		}
+		out = append(out, s.info(path, size))
+	}
+	return out, nil
+}
+
+// snapshotFiles: path file snapshot, urut height naik (nama file zero-padded).
+func snapshotFiles() ([]string, error) {
+	entries, err := os.ReadDir(Snapshots.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, "snapshot-") && strings.HasSuffix(name, snapshotExt) {
+			paths = append(paths, filepath.Join(Snapshots.Dir, name))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// pruneSnapshots menyisakan Snapshots.Keep file terbaru.
+func pruneSnapshots() {
+	if Snapshots.Keep <= 0 {
+		return
+	}
+	paths, err := snapshotFiles()
+	if err != nil || len(paths) <= Snapshots.Keep {
+		return
+	}
+	for _, path := range paths[:len(paths)-Snapshots.Keep] {
+		if err := os.Remove(path); err != nil {
+			fmt.Println("⚠️ gagal hapus snapshot lama:", err)
+		}
+	}
+}
+
+// ================== Restore ==================
+
+// ChainBase: height blok terendah yang tersimpan (0, atau height snapshot
+// bila node di-bootstrap dari snapshot).
+func ChainBase() int {
+	InitDB()
+	if data, err := db.Get([]byte(keyChainBase), nil); err == nil {
+		if h, ok := decodeHeight(data); ok {
+			return h
+		}
+	}
+	return 0
+}
+
+// SnapshotTrust: sumber tepercaya untuk restore. GenesisHash = genesis lokal /
+// file yang dikonfigurasi (wajib). BlockHash = hash blok snapshot dari sumber
+// tepercaya (explorer / node lain); bila kosong, sertifikat commit blok harus
+// lolos terhadap set epoch yang tercatat di chain lokal (genesis sama).
+type SnapshotTrust struct {
+	GenesisHash string
+	BlockHash   string
+}
+
+// LocalGenesisHash: hash genesis chain lokal (blok 0, atau genesis tersimpan
+// untuk node hasil restore snapshot).
+func LocalGenesisHash() (string, bool) {
+	if b, ok := GetBlockByHeight(0); ok {
+		return b.Hash, true
+	}
+	if g, ok := LoadGenesis(); ok {
+		return g.Hash(), true
+	}
+	return "", false
+}
+
+// verifySnapshotBlock: blok snapshot terikat trust.BlockHash atau sertifikat
+// commit yang lolos terhadap set epoch lokal. Dipanggil sebelum DB ditimpa.
+func verifySnapshotBlock(s *Snapshot, trust SnapshotTrust) error {
+	if trust.BlockHash != "" {
+		if s.Block.Hash != trust.BlockHash {
+			return fmt.Errorf("%w: hash block #%d %.12s ≠ trust hash %.12s", ErrSnapshotUntrusted, s.Height, s.Block.Hash, trust.BlockHash)
+		}
+		return nil
+	}
+	if local, ok := LocalGenesisHash(); !ok || local != trust.GenesisHash {
+		return fmt.Errorf("%w: chain lokal tidak punya set validator untuk block #%d, pakai trust hash", ErrSnapshotUntrusted, s.Height)
+	}
+	set, ok := epochSetFor(s.Height)
+	if !ok {
+		return fmt.Errorf("%w: set epoch %d belum tercatat di chain lokal, pakai trust hash", ErrSnapshotUntrusted, EpochOf(s.Height))
+	}
+	if err := VerifyCommit(s.Block, set.Validators); err != nil {
+		return fmt.Errorf("%w: %v", ErrSnapshotUntrusted, err)
+	}
+	return nil
+}
+
+// RestoreSnapshot menjadikan snapshot sebagai state & head node. Snapshot harus
+// berasal dari genesis trust.GenesisHash dan bloknya terikat sumber tepercaya
+// (lihat SnapshotTrust). DB harus kosong kecuali force (seluruh isi DB dihapus
+// dulu). Blok setelah snapshot lalu diimpor biasa lewat ImportBlock.
+func RestoreSnapshot(s *Snapshot, trust SnapshotTrust, force bool) error {
+	InitDB()
+	if err := s.Verify(); err != nil {
+		return err
+	}
+	if trust.GenesisHash == "" {
+		return fmt.Errorf("%w: hash genesis lokal wajib diisi", ErrSnapshotGenesis)
+	}
+	g, err := s.VerifyGenesis(trust.GenesisHash)
+	if err != nil {
+		return err
+	}
+	if err := verifySnapshotBlock(s, trust); err != nil {
+		return err
+	}
+	if h := ChainHeight(); h > 0 {
+		if !force {
+			return fmt.Errorf("❌ %w (height %d); pakai --force untuk menimpa", ErrChainNotEmpty, h)
+		}
+		if err := wipeDB(); err != nil {
+			return fmt.Errorf("❌ gagal kosongkan DB: %w", err)
+		}
+	}
+
+	pj, _ := json.Marshal(s.Params)
+	b := s.Block
+
+	ChainID = s.ChainID
+	Params = s.Params
+	LegacyKeys = legacyKeysOf(g)
+	BalanceMu.Lock()
+	Balances = s.Balances
+	BalanceMu.Unlock()
+	NonceTableMu.Lock()
+	NonceTable = s.Nonces
+	NonceTableMu.Unlock()
+	setValidators(append([]ValidatorDef(nil), s.Validators...))
+	setSupply(s.Treasury, s.Burned)
+	ValidatorStatusMu.Lock()
+	ValidatorStatus = map[string]*ValidatorRuntime{}
+	ValidatorStatusMu.Unlock()
+	ClearMempool()
+	st, err := encodeState()
+	if err != nil {
+		return err
+	}
+
+	// meta + blok + state dalam satu batch (lihat recoverState)
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(keyChainID), []byte(s.ChainID))
+	batch.Put([]byte(keyParams), pj)
+	batch.Put([]byte(keyGenesis), s.Genesis)
+	batch.Put(heightKey(b.Index), EncodeBlock(b))
+	batch.Put(hashKey(b.Hash), encodeHeight(b.Index))
+	batch.Put([]byte(keyChainHead), encodeHeight(b.Index))
+	batch.Put([]byte(keyChainBase), encodeHeight(b.Index))
+	indexBlock(batch, b)
+	st.put(batch)
+	batch.Put([]byte(keyStateHeight), encodeHeight(b.Index))
+	batch.Put([]byte(keyMempool), EncodeTxList(nil))
+	if s.Epoch != nil {
+		putEpochSet(batch, *s.Epoch)
+	}
+	if err := db.Write(batch, syncWrite); err != nil {
+		return err
+	}
+
+	chainMu.Lock()
+	headHeight = b.Index
+	headBlock = b
+	chainMu.Unlock()
+	blockCache.clear()
+	blockCache.add(b.Index, b)
+	clearEpochCache()
+	resetHeadStateTree()
+	if s.Epoch != nil {
+		epochPublished(*s.Epoch)
+	}
+
+	initHistory() // history dimulai di height snapshot
+	fmt.Printf("✅ Restored snapshot block #%d (%.12s), %d akun, %d validator\n",
+		b.Index, b.Hash, len(s.Balances), len(s.Validators))
+	return nil
+}
+
+// wipeDB menghapus semua key (restore --force).
+func wipeDB() error {
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		batch.Delete(append([]byte(nil), it.Key()...))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return db.Write(batch, syncWrite)
+}
@@ -9,7 +9,7 @@ import (
 
 // Account balances
 var (
-	Balances  = map[string]int{}
+	Balances  = map[string]Amount{}
 	BalanceMu sync.RWMutex
 )
 
@@ -19,17 +19,26 @@ var (
 	NonceTableMu sync.RWMutex
 )
 
-// Mempool
+// Monetary sinks (disimpan di DB bersama state, lihat storage.go). Diganti
+// saat import blok / restore; goroutine lain membaca lewat Supply.
 var (
-	Mempool   []Transaction
-	MempoolMu sync.RWMutex
+	TreasuryBalance Amount
+	BurnedSupply    Amount
+	SupplyMu        sync.RWMutex
 )
 
-// Monetary sinks
-var (
-	TreasuryBalance int
-	BurnedSupply    int
-)
+// Supply: treasury & burned supply saat ini.
+func Supply() (treasury, burned Amount) {
+	SupplyMu.RLock()
+	defer SupplyMu.RUnlock()
+	return TreasuryBalance, BurnedSupply
+}
+
+func setSupply(treasury, burned Amount) {
+	SupplyMu.Lock()
+	TreasuryBalance, BurnedSupply = treasury, burned
+	SupplyMu.Unlock()
+}
 
 // ================= Validator runtime status =================
 
@@ -43,8 +52,8 @@ const (
 )
 
 type ValidatorRuntime struct {
-	SuspendedUntil int64
-	SuspendScope   SuspensionScope
+	SuspendedUntil int64           `json:"suspended_until"` // unix seconds
+	SuspendScope   SuspensionScope `json:"scope"`
 }
 
 var (
@@ -55,7 +64,5 @@ var (
 func nowUnix() int64 { return time.Now().Unix() }
 
 func GetMempoolSize() int {
-	MempoolMu.RLock()
-	defer MempoolMu.RUnlock()
-	return len(Mempool)
+	return Pool.Len()
 }
@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/soden46/hyperlux-chain/crypto"
+)
+
+// ================== State Root (Sparse Merkle Tree) ==================
+//
+// Leaf per akun:
+//   "acct/<addr>"  → "<balance>|<nonce>"
+//   "stake/<addr>" → "<stake>" atau "<stake>|<jailed_until>" bila pernah di-jail
+// Leaf global:
+//   "supply"       → "<treasury>|<burned>" (dilewati bila keduanya nol)
+//   "epoch"        → sha256(active set untuk blok berikutnya, tanpa seed)
+// Akun dengan balance & nonce nol dianggap tidak ada, supaya map yang
+// kebetulan berisi entry 0 tetap menghasilkan root yang sama. Seed epoch
+// tidak ikut: diturunkan dari header blok (EpochSeed), dan untuk blok
+// pertama epoch bergantung pada hash / VRF blok itu sendiri.
+
+var (
+	supplyLeafKey = []byte("supply")
+	epochLeafKey  = []byte("epoch")
+)
+
+func accountLeafKey(addr string) []byte { return []byte("acct/" + addr) }
+func stakeLeafKey(addr string) []byte   { return []byte("stake/" + addr) }
+
+func accountLeafValue(balance Amount, nonce int) []byte {
+	return []byte(fmt.Sprintf("%d|%d", balance, nonce))
+}
+
+func stakeLeafValue(v ValidatorDef) []byte {
+	if v.JailedUntil != 0 {
+		return []byte(fmt.Sprintf("%d|%d", v.Stake, v.JailedUntil))
+	}
+	return []byte(fmt.Sprintf("%d", v.Stake))
+}
+
+func supplyLeafValue(treasury, burned Amount) []byte {
+	return []byte(fmt.Sprintf("%d|%d", treasury, burned))
+}
+
+func epochLeafValue(s EpochSet) []byte {
+	s.Seed = ""
+	sum := sha256.Sum256(encodeEpochSet(s))
+	return sum[:]
+}
+
+// rootState: isi state yang di-commit state root blok.
+type rootState struct {
+	balances         map[string]Amount
+	nonces           map[string]int
+	validators       []ValidatorDef
+	treasury, burned Amount
+	epoch            *EpochSet // set untuk blok berikutnya (nil: genesis)
+}
+
+// tree membangun pohon dari snapshot state yang diberikan.
+func (s rootState) tree() *crypto.SparseMerkleTree {
+	return s.updateTree(crypto.NewSparseMerkleTree(), nil)
+}
+
+// updateTree: salinan base dengan leaf akun di s.balances/s.nonces (cukup akun
+// yang berubah), stake, supply & epoch diganti sesuai s. prevValidators = set
+// validator di base: stake validator yang keluar dari set dihapus.
+func (s rootState) updateTree(base *crypto.SparseMerkleTree, prevValidators []ValidatorDef) *crypto.SparseMerkleTree {
+	t := base.Clone()
+	seen := make(map[string]struct{}, len(s.balances)+len(s.nonces))
+	for addr := range s.balances {
+		seen[addr] = struct{}{}
+	}
+	for addr := range s.nonces {
+		seen[addr] = struct{}{}
+	}
+	for addr := range seen {
+		bal, n := s.balances[addr], s.nonces[addr]
+		if bal == 0 && n == 0 {
+			t.Delete(accountLeafKey(addr))
+			continue
+		}
+		t.Update(accountLeafKey(addr), accountLeafValue(bal, n))
+	}
+	for _, v := range prevValidators {
+		if !inValidatorSet(s.validators, v.Address) {
+			t.Delete(stakeLeafKey(v.Address))
+		}
+	}
+	for _, v := range s.validators {
+		t.Update(stakeLeafKey(v.Address), stakeLeafValue(v))
+	}
+	if s.treasury != 0 || s.burned != 0 {
+		t.Update(supplyLeafKey, supplyLeafValue(s.treasury, s.burned))
+	} else {
+		t.Delete(supplyLeafKey)
+	}
+	if s.epoch != nil {
+		t.Update(epochLeafKey, epochLeafValue(*s.epoch))
+	} else {
+		t.Delete(epochLeafKey)
+	}
+	return t
+}
+
+// rootHex: root hex atas snapshot state (tanpa menyentuh state global).
+func (s rootState) rootHex() string {
+	root := s.tree().Root()
+	return hex.EncodeToString(root[:])
+}
+
+// rootStateAfter: state global dengan balances/nonces/validators yang
+// diberikan sebagai state setelah blok height.
+func rootStateAfter(height int, balances map[string]Amount, nonces map[string]int, validators []ValidatorDef) rootState {
+	treasury, burned := Supply()
+	return rootState{
+		balances:   balances,
+		nonces:     nonces,
+		validators: validators,
+		treasury:   treasury,
+		burned:     burned,
+		epoch:      nextEpochMembers(height, validators),
+	}
+}
+
+// liveStateTree: pohon dari state global saat ini sebagai state setelah blok height.
+func liveStateTree(height int) *crypto.SparseMerkleTree {
+	BalanceMu.RLock()
+	NonceTableMu.RLock()
+	defer NonceTableMu.RUnlock()
+	defer BalanceMu.RUnlock()
+	return rootStateAfter(height, Balances, NonceTable, GetValidators()).tree()
+}
+
+// ComputeStateRoot: root hex state saat ini (= StateRoot head bila tidak ada
+// perubahan di luar blok).
+func ComputeStateRoot() string {
+	return StateRootForBlock(ChainHeight() - 1)
+}
+
+// StateRootForBlock: root hex state saat ini sebagai state setelah blok
+// height (blok baru di atas head: height = head + 1).
+func StateRootForBlock(height int) string {
+	root := liveStateTree(height).Root()
+	return hex.EncodeToString(root[:])
+}
+
+// ================== Head state tree ==================
+//
+// Pohon state setelah head disimpan di memori supaya blok berikutnya (proposal,
+// validasi & import) cukup meng-clone lalu mengganti leaf akun yang disentuh
+// blok: node subtree lain beserta hash-nya dipakai bersama. Cache dikaitkan ke
+// hash head; setelah state dimuat ulang (load, restore, rollback) dibangun ulang
+// dari state global.
+
+var (
+	headTreeMu   sync.Mutex
+	headTree     *crypto.SparseMerkleTree
+	headTreeHash string
+)
+
+// headStateTree: pohon state setelah head (jangan diubah; pakai updateTree).
+func headStateTree(head Block) *crypto.SparseMerkleTree {
+	headTreeMu.Lock()
+	t, hash := headTree, headTreeHash
+	headTreeMu.Unlock()
+	if t != nil && hash == head.Hash {
+		return t
+	}
+	// dibangun di luar headTreeMu: membaca lock state & chain
+	t = liveStateTree(head.Index)
+	setHeadStateTree(head.Hash, t)
+	return t
+}
+
+// setHeadStateTree: pohon state setelah blok hash menjadi basis blok berikutnya.
+func setHeadStateTree(hash string, t *crypto.SparseMerkleTree) {
+	t.Root() // hash node dihitung sebelum pohon dibagi
+	headTreeMu.Lock()
+	headTree, headTreeHash = t, hash
+	headTreeMu.Unlock()
+}
+
+// stateTreeAfter: pohon state setelah blok height di atas head; balances/nonces
+// cukup berisi akun yang disentuh blok, prev = set validator setelah head.
+func stateTreeAfter(head Block, height int, balances map[string]Amount, nonces map[string]int, prev, validators []ValidatorDef) *crypto.SparseMerkleTree {
+	return rootStateAfter(height, balances, nonces, validators).updateTree(headStateTree(head), prev)
+}
+
+func treeRootHex(t *crypto.SparseMerkleTree) string {
+	root := t.Root()
+	return hex.EncodeToString(root[:])
+}
+
+// resetHeadStateTree: state global diganti di luar blok → bangun ulang saat dipakai.
+func resetHeadStateTree() {
+	headTreeMu.Lock()
+	headTree, headTreeHash = nil, ""
+	headTreeMu.Unlock()
+}
+
+// ================== Light-client proofs ==================
+
+type AccountProof struct {
+	Address   string             `json:"address"`
+	Balance   Amount             `json:"balance"`
+	Nonce     int                `json:"nonce"`
+	StateRoot string             `json:"state_root"`
+	Proof     crypto.MerkleProof `json:"proof"`
+}
+
+// ProveAccount membuat proof balance+nonce akun terhadap state root saat ini.
+func ProveAccount(addr string) AccountProof {
+	head := ChainHeight() - 1
+	BalanceMu.RLock()
+	NonceTableMu.RLock()
+	bal, n := Balances[addr], NonceTable[addr]
+	t := rootStateAfter(head, Balances, NonceTable, GetValidators()).tree()
+	NonceTableMu.RUnlock()
+	BalanceMu.RUnlock()
+
+	root := t.Root()
+	return AccountProof{
+		Address:   addr,
+		Balance:   bal,
+		Nonce:     n,
+		StateRoot: hex.EncodeToString(root[:]),
+		Proof:     t.Prove(accountLeafKey(addr)),
+	}
+}
+
+// VerifyAccountProof: cek proof terhadap state root (mis. dari header blok).
+func VerifyAccountProof(stateRoot string, p AccountProof) bool {
+	rb, err := hex.DecodeString(stateRoot)
+	if err != nil || len(rb) != 32 {
+		return false
+	}
+	var root [32]byte
+	copy(root[:], rb)
+	if p.Balance == 0 && p.Nonce == 0 {
+		return crypto.VerifyNonInclusion(root, accountLeafKey(p.Address), p.Proof)
+	}
+	return crypto.VerifyMerkleProof(root, accountLeafKey(p.Address), accountLeafValue(p.Balance, p.Nonce), p.Proof)
+}
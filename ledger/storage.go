@@ -1,12 +1,17 @@
 package ledger
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
@@ -29,68 +34,324 @@ func InitDB() {
 	fmt.Println("✅ Storage engine initialized")
 }
 
-// ===== BALANCES =====
-func SaveBalances() {
+// DB: handle LevelDB ledger (tool pemeliharaan & test yang menyiapkan DB lama).
+func DB() *leveldb.DB {
 	InitDB()
+	return db
+}
+
+// ===== STATE =====
+// Balances, nonce, validator, treasury, burned & status suspend validator
+// ditulis bersama dalam satu batch (SaveState, AppendBlock + state/height),
+// jadi efek blok / slash tidak pernah tersimpan setengah (lihat recoverState).
+// Satu-satunya tulisan terpisah: SuspendValidator tanpa slash hanya menulis
+// status suspend (saveValidatorStatus).
+//
+// Akun disimpan per key (state/a/<addr> → balance 8B + nonce 8B), jadi blok
+// hasil import cukup menulis akun yang disentuhnya. Node lama menyimpan semua
+// akun di blob JSON balances & nonce_table; dipindah sekali saat load.
+
+const (
+	keyBalances        = "balances"    // format lama, lihat migrateAccountBlobs
+	keyNonceTable      = "nonce_table" // format lama, lihat migrateAccountBlobs
+	keyMempool         = "mempool"
+	prefixStateAccount = "state/a/"
+	keyValidators      = "state/validators"
+	keyTreasury        = "state/treasury"
+	keyBurned          = "state/burned"
+	keyValStatus       = "state/validator_status"
+	keyStateHeight     = "state/height" // blok terakhir yang efeknya sudah ada di state tersimpan
+
+	legacyEconomyFile = "economy.json" // diimpor sekali bila DB belum punya treasury/burned
+)
+
+// fsync per commit: batch sudah di disk sebelum blok diumumkan ke peer
+var syncWrite = &opt.WriteOptions{Sync: true}
+
+func accountStateKey(addr string) []byte {
+	return []byte(prefixStateAccount + addr)
+}
+
+// encodedState: state dalam bentuk siap tulis.
+type encodedState struct {
+	accounts           map[string][]byte // addr → balance+nonce; nil = akun nol (dihapus)
+	full               bool              // akun tersimpan yang tidak ada di accounts ikut dihapus
+	validators, status []byte
+	treasury, burned   Amount
+}
+
+// encodeState: seluruh state global.
+func encodeState() (encodedState, error) {
 	BalanceMu.RLock()
+	NonceTableMu.RLock()
+	defer NonceTableMu.RUnlock()
 	defer BalanceMu.RUnlock()
-	data, _ := json.Marshal(Balances)
-	_ = db.Put([]byte("balances"), data, nil)
+	st, err := encodeStateOf(Balances, NonceTable, GetValidators())
+	st.full = true
+	return st, err
+}
+
+// encodeStateOf: hanya akun di balances/nonces (akun yang disentuh blok hasil
+// re-eksekusi yang belum dipasang) plus validators & supply.
+func encodeStateOf(balances map[string]Amount, nonces map[string]int, validators []ValidatorDef) (encodedState, error) {
+	st := encodedState{accounts: make(map[string][]byte, len(balances)+len(nonces))}
+	add := func(addr string) {
+		if bal, n := balances[addr], nonces[addr]; bal != 0 || n != 0 {
+			st.accounts[addr] = encodeAccountVersion(bal, n)
+		} else {
+			st.accounts[addr] = nil
+		}
+	}
+	for addr := range balances {
+		add(addr)
+	}
+	for addr := range nonces {
+		add(addr)
+	}
+	var err error
+	if st.validators, err = json.Marshal(validators); err != nil {
+		return st, err
+	}
+	if st.status, err = encodeValidatorStatus(); err != nil {
+		return st, err
+	}
+	st.treasury, st.burned = Supply()
+	return st, nil
 }
 
+func (st encodedState) put(batch *leveldb.Batch) {
+	if st.full {
+		it := db.NewIterator(util.BytesPrefix([]byte(prefixStateAccount)), nil)
+		for it.Next() {
+			if _, ok := st.accounts[string(it.Key()[len(prefixStateAccount):])]; !ok {
+				batch.Delete(append([]byte(nil), it.Key()...))
+			}
+		}
+		it.Release()
+	}
+	for addr, v := range st.accounts {
+		if v == nil {
+			batch.Delete(accountStateKey(addr))
+		} else {
+			batch.Put(accountStateKey(addr), v)
+		}
+	}
+	batch.Put([]byte(keyValidators), st.validators)
+	batch.Put([]byte(keyValStatus), st.status)
+	batch.Put([]byte(keyTreasury), binary.BigEndian.AppendUint64(nil, uint64(st.treasury)))
+	batch.Put([]byte(keyBurned), binary.BigEndian.AppendUint64(nil, uint64(st.burned)))
+}
+
+// SaveState menulis seluruh state dalam satu batch (perubahan di luar blok:
+// airdrop, slash, migrasi address).
+func SaveState() error {
+	InitDB()
+	st, err := encodeState()
+	if err != nil {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	st.put(batch)
+	return db.Write(batch, syncWrite)
+}
+
+func saveStateWarn() {
+	if err := SaveState(); err != nil {
+		fmt.Println("⚠️ gagal simpan state:", err)
+	}
+}
+
+// SaveBalances / SaveNonceTable / SaveValidators: nama lama, semuanya
+// menulis state lengkap supaya tidak ada state campuran di disk.
+func SaveBalances()   { saveStateWarn() }
+func SaveNonceTable() { saveStateWarn() }
+
 func LoadBalances() {
 	InitDB()
-	data, _ := db.Get([]byte("balances"), nil)
-	if len(data) > 0 {
-		_ = json.Unmarshal(data, &Balances)
+	migrateAccountBlobs()
+	BalanceMu.Lock()
+	defer BalanceMu.Unlock()
+	loadAccounts(func(addr string, bal Amount, _ int) {
+		if bal != 0 {
+			Balances[addr] = bal
+		}
+	})
+}
+
+// loadAccounts memanggil fn untuk setiap akun tersimpan.
+func loadAccounts(fn func(addr string, bal Amount, nonce int)) {
+	it := db.NewIterator(util.BytesPrefix([]byte(prefixStateAccount)), nil)
+	defer it.Release()
+	for it.Next() {
+		v := it.Value()
+		if len(v) != 16 {
+			continue
+		}
+		fn(string(it.Key()[len(prefixStateAccount):]), Amount(binary.BigEndian.Uint64(v[:8])), int(binary.BigEndian.Uint64(v[8:])))
 	}
 }
 
-// ===== BLOCKCHAIN =====
-func SaveBlockchain() {
+// migrateAccountBlobs: blob JSON balances & nonce_table (format lama) → key per
+// akun, dalam satu batch.
+func migrateAccountBlobs() {
+	var balances map[string]Amount
+	var nonces map[string]int
+	found := false
+	if data, err := db.Get([]byte(keyBalances), nil); err == nil {
+		found = true
+		if len(data) > 0 {
+			_ = json.Unmarshal(data, &balances)
+		}
+	}
+	if data, err := db.Get([]byte(keyNonceTable), nil); err == nil {
+		found = true
+		if len(data) > 0 {
+			_ = json.Unmarshal(data, &nonces)
+		}
+	}
+	if !found {
+		return
+	}
+	st, err := encodeStateOf(balances, nonces, nil)
+	if err != nil {
+		fmt.Println("⚠️ migrasi state akun gagal:", err)
+		return
+	}
+	batch := new(leveldb.Batch)
+	for addr, v := range st.accounts {
+		if v != nil {
+			batch.Put(accountStateKey(addr), v)
+		}
+	}
+	batch.Delete([]byte(keyBalances))
+	batch.Delete([]byte(keyNonceTable))
+	if err := db.Write(batch, syncWrite); err != nil {
+		fmt.Println("⚠️ migrasi state akun gagal:", err)
+		return
+	}
+	fmt.Printf("🔁 Migrated %d akun ke state per akun\n", len(st.accounts))
+}
+
+// LoadEconomy: treasury & burned supply (node lama: dari economy.json).
+func LoadEconomy() {
+	InitDB()
+	found := false
+	treasury, burned := Supply()
+	for key, dst := range map[string]*Amount{keyTreasury: &treasury, keyBurned: &burned} {
+		if data, err := db.Get([]byte(key), nil); err == nil && len(data) == 8 {
+			*dst = Amount(binary.BigEndian.Uint64(data))
+			found = true
+		}
+	}
+	if found {
+		setSupply(treasury, burned)
+		return
+	}
+	data, err := os.ReadFile(legacyEconomyFile)
+	if err != nil {
+		return
+	}
+	var econ struct {
+		Treasury Amount `json:"treasury"`
+		Burned   Amount `json:"burned"`
+	}
+	if err := json.Unmarshal(data, &econ); err != nil {
+		fmt.Println("⚠️ economy.json warn:", err)
+		return
+	}
+	setSupply(econ.Treasury, econ.Burned)
+}
+
+// ===== VALIDATOR STATUS =====
+// Suspensi berlaku lintas proses (CLI suspend/slash → node yang sedang jalan
+// setelah restart). Entry yang sudah lewat masa suspend tidak disimpan.
+
+func encodeValidatorStatus() ([]byte, error) {
+	now := nowUnix()
+	ValidatorStatusMu.RLock()
+	defer ValidatorStatusMu.RUnlock()
+	active := make(map[string]ValidatorRuntime, len(ValidatorStatus))
+	for addr, rt := range ValidatorStatus {
+		if rt != nil && rt.SuspendedUntil > now {
+			active[addr] = *rt
+		}
+	}
+	return json.Marshal(active)
+}
+
+func saveValidatorStatus() {
 	InitDB()
-	data, _ := json.Marshal(Blockchain)
-	_ = db.Put([]byte("blockchain"), data, nil)
+	data, err := encodeValidatorStatus()
+	if err == nil {
+		err = db.Put([]byte(keyValStatus), data, syncWrite)
+	}
+	if err != nil {
+		fmt.Println("⚠️ gagal simpan status validator:", err)
+	}
 }
 
-func LoadBlockchain() {
+func LoadValidatorStatus() {
 	InitDB()
-	data, _ := db.Get([]byte("blockchain"), nil)
-	if len(data) > 0 {
-		_ = json.Unmarshal(data, &Blockchain)
+	data, err := db.Get([]byte(keyValStatus), nil)
+	if err != nil {
+		return
+	}
+	var stored map[string]ValidatorRuntime
+	if err := json.Unmarshal(data, &stored); err != nil {
+		fmt.Println("⚠️ LoadValidatorStatus warn:", err)
+		return
+	}
+	now := nowUnix()
+	ValidatorStatusMu.Lock()
+	defer ValidatorStatusMu.Unlock()
+	ValidatorStatus = make(map[string]*ValidatorRuntime, len(stored))
+	for addr, rt := range stored {
+		if rt.SuspendedUntil > now {
+			ValidatorStatus[addr] = &rt
+		}
 	}
 }
 
+// ===== BLOCKCHAIN =====
+// Blok disimpan per-height, lihat blockstore.go (AppendBlock / LoadBlockchain).
+
 // ===== MEMPOOL =====
 func SaveMempool() {
 	InitDB()
-	MempoolMu.RLock()
-	defer MempoolMu.RUnlock()
-	data, _ := json.Marshal(Mempool)
-	_ = db.Put([]byte("mempool"), data, nil)
+	if err := db.Put([]byte(keyMempool), Pool.encode(), nil); err != nil {
+		fmt.Println("⚠️ gagal simpan mempool:", err)
+	}
 }
 
 func LoadMempool() {
 	InitDB()
-	data, _ := db.Get([]byte("mempool"), nil)
-	if len(data) > 0 {
-		_ = json.Unmarshal(data, &Mempool)
+	data, _ := db.Get([]byte(keyMempool), nil)
+	if len(data) == 0 {
+		return
 	}
+	var txs []Transaction
+	var added []time.Time
+	switch {
+	case data[0] == tagMempool:
+		txs, added, _ = decodeMempool(data)
+	case isBinaryEncoded(data): // format lama tanpa waktu masuk
+		txs, _ = DecodeTxList(data)
+	default:
+		_ = json.Unmarshal(data, &txs)
+	}
+	Pool.restore(txs, added)
+	Pool.PruneStale()
 }
 
 // ===== NONCE TABLE =====
-func SaveNonceTable() {
-	InitDB()
-	NonceTableMu.RLock()
-	defer NonceTableMu.RUnlock()
-	data, _ := json.Marshal(NonceTable)
-	_ = db.Put([]byte("nonce_table"), data, nil)
-}
-
 func LoadNonceTable() {
 	InitDB()
-	data, _ := db.Get([]byte("nonce_table"), nil)
-	if len(data) > 0 {
-		_ = json.Unmarshal(data, &NonceTable)
-	}
+	migrateAccountBlobs()
+	NonceTableMu.Lock()
+	defer NonceTableMu.Unlock()
+	loadAccounts(func(addr string, _ Amount, n int) {
+		if n != 0 {
+			NonceTable[addr] = n
+		}
+	})
 }
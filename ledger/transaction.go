@@ -4,7 +4,6 @@ import (
 	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"runtime"
 	"sort"
@@ -15,116 +14,241 @@ import (
 // ===================== Data Types =====================
 
 type Transaction struct {
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Amount    int    `json:"amount"`
-	Fee       int    `json:"fee"`
-	Nonce     int    `json:"nonce"`
-	Signature string `json:"signature"`
-	PubKey    string `json:"pubkey"`
+	Version    int    `json:"version"`
+	ChainID    string `json:"chain_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Amount     Amount `json:"amount"`
+	Fee        Amount `json:"fee"`
+	Nonce      int    `json:"nonce"`
+	ValidUntil int    `json:"valid_until"` // height terakhir TX boleh masuk blok
+	Memo       string `json:"memo,omitempty"`
+	Signature  string `json:"signature"`
+	PubKey     string `json:"pubkey"`
 }
 
+const (
+	TxVersion = 1
+
+	DefaultTxValidity = 10000 // blok
+	MaxMemoLen        = 256
+)
+
 // ===================== TX Construction =====================
 
-func NewTransaction(w *wallet.Wallet, to string, amount int) Transaction {
-	nonce := GetNextNonce(w.AddressEd)
+type TxOptions struct {
+	Memo     string
+	ValidFor int    // jumlah blok dari head; 0 → DefaultTxValidity
+	Fee      Amount // 0 atau < fee minimal → fee minimal (CalculateFee); lebih tinggi = prioritas / RBF
+	Nonce    int    // 0 → nonce berikutnya (termasuk TX pending di mempool)
+}
 
-	data := fmt.Sprintf("%s|%s|%d|%d", w.AddressEd, to, amount, nonce)
-	sig := w.SignEd([]byte(data))
+func NewTransaction(w *wallet.Wallet, to string, amount Amount) Transaction {
+	return NewTransactionWithOpts(w, to, amount, TxOptions{})
+}
+
+func NewTransactionWithOpts(w *wallet.Wallet, to string, amount Amount, opts TxOptions) Transaction {
+	validFor := opts.ValidFor
+	if validFor <= 0 {
+		validFor = DefaultTxValidity
+	}
 
 	tx := Transaction{
-		From:      w.AddressEd,
-		To:        to,
-		Amount:    amount,
-		Nonce:     nonce,
-		Signature: hex.EncodeToString(sig),
-		PubKey:    hex.EncodeToString(w.PubEd),
-	}
-	tx.Fee = CalculateFee(tx)
+		Version:    TxVersion,
+		ChainID:    ChainID,
+		From:       w.AddressEd,
+		To:         to,
+		Amount:     amount,
+		Nonce:      GetNextNonce(w.AddressEd),
+		ValidUntil: ChainHeight() + validFor,
+		Memo:       opts.Memo,
+		PubKey:     hex.EncodeToString(w.PubEd),
+	}
+	// fee dihitung dari ukuran dengan placeholder signature (ukurannya tetap),
+	// lalu ikut ditandatangani
+	if opts.Nonce > 0 {
+		tx.Nonce = opts.Nonce
+	}
+	tx.Signature = placeholderSignature
+	tx.Fee = max(CalculateFee(tx), opts.Fee)
+	tx.Signature = hex.EncodeToString(w.SignEd(TxSigningBytes(tx)))
 	return tx
 }
 
+var placeholderSignature = hex.EncodeToString(make([]byte, ed25519.SignatureSize))
+
+// TxSigningBytes: payload ber-versi yang ditandatangani pengirim.
+// v1 = encoding kanonik semua field kecuali signature (lihat codec.go).
+func TxSigningBytes(tx Transaction) []byte {
+	switch tx.Version {
+	case 1:
+		return encodeTxSigning(tx)
+	default:
+		return nil
+	}
+}
+
+// checkTxEnvelope: versi, chain ID, memo & expiry untuk blok di height tsb.
+func checkTxEnvelope(tx Transaction, height int) error {
+	if tx.Version != TxVersion {
+		return fmt.Errorf("❌ versi tx %d tidak didukung", tx.Version)
+	}
+	if tx.ChainID != ChainID {
+		return fmt.Errorf("❌ chain id %q tidak cocok (node: %q)", tx.ChainID, ChainID)
+	}
+	if len(tx.Memo) > MaxMemoLen {
+		return fmt.Errorf("❌ memo terlalu panjang (%d > %d)", len(tx.Memo), MaxMemoLen)
+	}
+	// hex uppercase lolos verifikasi tapi tidak bisa di-decode peer (codec.go)
+	if _, ok := canonicalHex(tx.PubKey); !ok {
+		return fmt.Errorf("❌ pubkey harus hex lowercase")
+	}
+	if _, ok := canonicalHex(tx.Signature); !ok {
+		return fmt.Errorf("❌ signature harus hex lowercase")
+	}
+	if height > tx.ValidUntil {
+		return fmt.Errorf("❌ tx kedaluwarsa (valid_until=%d, height=%d)", tx.ValidUntil, height)
+	}
+	if _, err := txCost(tx); err != nil {
+		return fmt.Errorf("❌ %v", err)
+	}
+	return nil
+}
+
+// txCost: amount + fee yang didebit dari pengirim. Amount 0 ditolak dan
+// amount/fee dibatasi MaxSupply, jadi penjumlahan tidak bisa overflow.
+func txCost(tx Transaction) (Amount, error) {
+	if tx.Amount == 0 {
+		return 0, ErrAmountZero
+	}
+	if tx.Amount > MaxSupply || tx.Fee > MaxSupply {
+		return 0, fmt.Errorf("%w: melebihi max supply", ErrAmountInvalid)
+	}
+	return tx.Amount.Add(tx.Fee)
+}
+
+// GetNextNonce: nonce berikutnya untuk addr, melewati TX yang masih pending di mempool.
 func GetNextNonce(addr string) int {
-	NonceTableMu.RLock()
-	defer NonceTableMu.RUnlock()
-	return NonceTable[addr] + 1
+	return Pool.PendingNonce(addr, stateNonceOf(addr))
 }
 
 // ===================== Mempool Ingest =====================
 
+// ValidateAndAddToMempool: cek stateless (envelope, binding, address, signature)
+// lalu Pool.Add (nonce, saldo kumulatif, RBF, kapasitas).
 func ValidateAndAddToMempool(tx Transaction) error {
 	// quick snapshot
-	NonceTableMu.RLock()
-	expected := NonceTable[tx.From] + 1
-	NonceTableMu.RUnlock()
+	stateNonce := stateNonceOf(tx.From)
 
 	BalanceMu.RLock()
 	balance := Balances[tx.From]
 	BalanceMu.RUnlock()
 
-	if tx.Nonce != expected {
-		return fmt.Errorf("❌ invalid nonce (expected %d, got %d)", expected, tx.Nonce)
+	if err := checkTxEnvelope(tx, ChainHeight()); err != nil {
+		return err
 	}
-	if balance < tx.Amount+tx.Fee {
-		return fmt.Errorf("❌ insufficient balance")
+	if !SenderMatchesPubKey(tx) {
+		return fmt.Errorf("❌ pubkey tidak cocok dengan address pengirim %s", tx.From)
+	}
+	if err := wallet.ValidateAddress(tx.To); err != nil {
+		return fmt.Errorf("❌ address tujuan %q: %v", tx.To, err)
 	}
 	if !VerifyTransaction(tx) {
 		return fmt.Errorf("❌ invalid signature")
 	}
-
-	MempoolMu.Lock()
-	Mempool = append(Mempool, tx)
-	MempoolMu.Unlock()
+	if err := Pool.Add(tx, stateNonce, balance); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	recorder.mixTx(HashTransaction(tx)) // urutan kedatangan TX tercatat di PoH
 	return nil
 }
 
 // ===================== Mempool Helpers =====================
 
+// MempoolSnapshot: TX executable urut fee per byte (lihat TxPool.Pending).
 func MempoolSnapshot() []Transaction {
-	MempoolMu.RLock()
-	defer MempoolMu.RUnlock()
-	out := make([]Transaction, len(Mempool))
-	copy(out, Mempool)
-	return out
+	return Pool.Pending(0)
 }
 
+// RemoveCommittedFromMempool: buang TX yang sudah masuk blok + TX lain yang nonce-nya basi.
 func RemoveCommittedFromMempool(committed []Transaction) {
 	if len(committed) == 0 {
 		return
 	}
-	toDelete := make(map[string]struct{}, len(committed))
-	for _, tx := range committed {
-		toDelete[HashTransaction(tx)] = struct{}{}
-	}
-
-	MempoolMu.Lock()
-	defer MempoolMu.Unlock()
-
-	if len(Mempool) == 0 {
-		return
-	}
-	filtered := Mempool[:0]
-	for _, tx := range Mempool {
-		if _, ok := toDelete[HashTransaction(tx)]; !ok {
-			filtered = append(filtered, tx)
-		}
-	}
-	Mempool = filtered
+	Pool.Remove(committed)
+	Pool.PruneStale()
 }
 
 func ClearMempool() {
-	MempoolMu.Lock()
-	Mempool = []Transaction{}
-	MempoolMu.Unlock()
+	Pool.Clear()
 }
 
 // ===================== Batch Processing =====================
 
+// ProcessTxListParallel mengeksekusi txs (urut blok) di atas state global dengan
+// executor Block-STM (executor.go) dan mengembalikan TX yang diterima.
+// Hasilnya identik dengan eksekusi serial, termasuk dana yang diterima di batch yang sama.
 func ProcessTxListParallel(txs []Transaction) []Transaction {
+	return AcceptedTxs(txs, ProcessTxListResults(txs))
+}
+
+// ProcessTxListResults: seperti ProcessTxListParallel, tapi mengembalikan
+// hasil per TX (nil = diterima, selain itu alasan penolakan).
+func ProcessTxListResults(txs []Transaction) []error {
+	if len(txs) == 0 {
+		return []error{}
+	}
+	// cek stateless (signature dsb.) tanpa memegang lock state
+	pre := precheckTxs(txs, ChainHeight())
+
+	BalanceMu.Lock()
+	NonceTableMu.Lock()
+	defer NonceTableMu.Unlock()
+	defer BalanceMu.Unlock()
+	return executeSTM(txs, pre, Balances, NonceTable, runtime.NumCPU())
+}
+
+// ProcessTxListPartitioned: executor lama (partisi per sender, hanya snapshot
+// saldo sender). Dana yang diterima di batch yang sama tidak terlihat dan urutan
+// hasil bergantung scheduling goroutine. Dipertahankan sebagai pembanding benchmark.
+func ProcessTxListPartitioned(txs []Transaction) []Transaction {
 	if len(txs) == 0 {
 		return []Transaction{}
 	}
 
+	// snapshot nonces & balances (sender saja)
+	nonceSnap := map[string]int{}
+	balSnap := map[string]Amount{}
+
+	NonceTableMu.RLock()
+	for _, tx := range txs {
+		nonceSnap[tx.From] = NonceTable[tx.From]
+	}
+	NonceTableMu.RUnlock()
+
+	BalanceMu.RLock()
+	for _, tx := range txs {
+		balSnap[tx.From] = Balances[tx.From]
+	}
+	BalanceMu.RUnlock()
+
+	final := acceptTxsPartitioned(txs, ChainHeight(), nonceSnap, balSnap)
+
+	// single commit
+	if len(final) > 0 {
+		BalanceMu.Lock()
+		NonceTableMu.Lock()
+		applyTxs(Balances, NonceTable, final)
+		NonceTableMu.Unlock()
+		BalanceMu.Unlock()
+	}
+
+	return final
+}
+
+// acceptTxsPartitioned: TX dipartisi per sender, diurutkan per nonce; per sender
+// berhenti di TX pertama yang nonce/signature/saldonya tidak valid.
+func acceptTxsPartitioned(txs []Transaction, height int, nonceSnap map[string]int, balSnap map[string]Amount) []Transaction {
 	// Partition by sender → minimize nonce conflicts
 	partitions := map[string][]Transaction{}
 	for _, tx := range txs {
@@ -136,22 +260,6 @@ func ProcessTxListParallel(txs []Transaction) []Transaction {
 		})
 	}
 
-	// snapshot nonces & balances
-	nonceSnap := make(map[string]int, len(partitions))
-	balSnap := make(map[string]int, len(partitions))
-
-	NonceTableMu.RLock()
-	for sender := range partitions {
-		nonceSnap[sender] = NonceTable[sender]
-	}
-	NonceTableMu.RUnlock()
-
-	BalanceMu.RLock()
-	for sender := range partitions {
-		balSnap[sender] = Balances[sender]
-	}
-	BalanceMu.RUnlock()
-
 	numWorkers := runtime.NumCPU()
 	if numWorkers > len(partitions) {
 		numWorkers = len(partitions)
@@ -182,14 +290,22 @@ func ProcessTxListParallel(txs []Transaction) []Transaction {
 				if tx.Nonce != localNonce+1 {
 					break
 				}
-				if !VerifyTransaction(tx) {
+				if checkTxEnvelope(tx, height) != nil {
+					break
+				}
+				if !SenderMatchesPubKey(tx) || !VerifyTransaction(tx) {
 					break
 				}
-				cost := tx.Amount + tx.Fee
-				if localBal < cost {
+				if wallet.ValidateAddress(tx.To) != nil {
+					break
+				}
+				cost, err := txCost(tx)
+				if err != nil {
+					break
+				}
+				if localBal, err = localBal.Sub(cost); err != nil {
 					break
 				}
-				localBal -= cost
 				localNonce = tx.Nonce
 				accepted = append(accepted, tx)
 			}
@@ -209,21 +325,18 @@ func ProcessTxListParallel(txs []Transaction) []Transaction {
 		}
 	}
 	close(out)
+	return final
+}
 
-	// single commit
-	if len(final) > 0 {
-		BalanceMu.Lock()
-		NonceTableMu.Lock()
-		for _, tx := range final {
-			Balances[tx.From] -= tx.Amount + tx.Fee
-			Balances[tx.To] += tx.Amount
-			NonceTable[tx.From] = tx.Nonce
-		}
-		NonceTableMu.Unlock()
-		BalanceMu.Unlock()
+// applyTxs memutasi map balances/nonces yang diberikan (caller pegang lock bila global).
+// txs harus sudah diterima ExecuteTxsResults, jadi debit tidak mungkin underflow.
+func applyTxs(balances map[string]Amount, nonces map[string]int, txs []Transaction) {
+	for _, tx := range txs {
+		cost, _ := txCost(tx)
+		balances[tx.From] -= cost
+		mustCredit(balances, tx.To, tx.Amount)
+		nonces[tx.From] = tx.Nonce
 	}
-
-	return final
 }
 
 func ProcessMempoolParallel() []Transaction {
@@ -233,37 +346,30 @@ func ProcessMempoolParallel() []Transaction {
 
 // ===================== Utils =====================
 
-func CalculateFee(tx Transaction) int {
-	b, _ := json.Marshal(tx)
-	size := len(b)
-	feePerByte := 1 // super murah (bisa dituning)
-	return size * feePerByte
+// CalculateFee: ukuran encoding kanonik × fee per byte. Integer & signature
+// berukuran tetap, jadi hasilnya sama sebelum dan sesudah TX ditandatangani.
+func CalculateFee(tx Transaction) Amount {
+	size := Amount(len(EncodeTransaction(tx)))
+	fee, err := size.Mul(Params.FeePerByte) // default 1, super murah (lihat genesis params)
+	if err != nil {
+		return MaxSupply + 1 // fee absurd → TX ditolak txCost
+	}
+	return fee
 }
 
-func VerifyTransaction(tx Transaction) bool {
-	data := fmt.Sprintf("%s|%s|%d|%d", tx.From, tx.To, tx.Amount, tx.Nonce)
-	pubBytes, err1 := hex.DecodeString(tx.PubKey)
-	sigBytes, err2 := hex.DecodeString(tx.Signature)
-	if err1 != nil || err2 != nil {
+// SenderMatchesPubKey: tx.From wajib hasil derivasi tx.PubKey (anti spoofing
+// sender). Address legacy hanya boleh mengirim ke address baru pubkey yang
+// tercatat untuknya di genesis (TX migrasi, lihat address_migration.go).
+func SenderMatchesPubKey(tx Transaction) bool {
+	pubBytes, err := hex.DecodeString(tx.PubKey)
+	if err != nil || len(pubBytes) != ed25519.PublicKeySize {
 		return false
 	}
-	return ed25519.Verify(ed25519.PublicKey(pubBytes), []byte(data), sigBytes)
-}
-
-func EncodeTransaction(tx Transaction) []byte {
-	b, _ := json.Marshal(tx)
-	return b
-}
-
-func DecodeTransaction(data []byte) (Transaction, error) {
-	var tx Transaction
-	err := json.Unmarshal(data, &tx)
-	return tx, err
+	return wallet.AddressFromPubKey(pubBytes) == tx.From || isMigrationTx(tx, pubBytes)
 }
 
+// HashTransaction: sha256 encoding kanonik penuh (fee, pubkey & signature ikut).
 func HashTransaction(tx Transaction) string {
-	data := fmt.Sprintf("%s|%s|%d|%d|%s",
-		tx.From, tx.To, tx.Amount, tx.Nonce, tx.Signature)
-	h := sha256.Sum256([]byte(data))
+	h := sha256.Sum256(EncodeTransaction(tx))
 	return hex.EncodeToString(h[:])
 }
@@ -0,0 +1,475 @@
+package ledger
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ================== TxPool (mempool) ==================
+//
+// - antrean per sender, urut nonce; TX dengan nonce di depan (gap) diparkir
+//   sampai nonce sebelumnya masuk
+// - Pending() mengurutkan TX executable secara global berdasarkan fee per byte
+//   (nonce per sender tetap berurutan)
+// - kapasitas dibatasi; saat penuh TX ber-fee-rate terendah (ekor antrean
+//   sender) di-evict
+// - replace-by-fee untuk nonce yang sama dengan kenaikan fee minimal
+// - TTL: TX yang terlalu lama di pool dibuang
+
+var (
+	ErrTxKnown            = errors.New("tx sudah ada di mempool")
+	ErrNonceTooLow        = errors.New("nonce sudah terpakai")
+	ErrNonceTooHigh       = errors.New("nonce terlalu jauh di depan")
+	ErrReplaceUnderpriced = errors.New("replace-by-fee: kenaikan fee kurang")
+	ErrPoolFull           = errors.New("mempool penuh dan fee terlalu rendah")
+	ErrInsufficientFunds  = errors.New("saldo tidak cukup untuk semua tx pending")
+)
+
+type TxPoolConfig struct {
+	MaxSize     int           // total TX di pool
+	MaxNonceGap int           // nonce maksimal di depan nonce state
+	MinBumpPct  int           // RBF: fee baru ≥ fee lama × (100+MinBumpPct)/100
+	TTL         time.Duration // umur maksimal TX di pool
+}
+
+func DefaultTxPoolConfig() TxPoolConfig {
+	return TxPoolConfig{
+		MaxSize:     50000,
+		MaxNonceGap: 64,
+		MinBumpPct:  10,
+		TTL:         3 * time.Hour,
+	}
+}
+
+// TxPoolConfigFromEnv: default, bisa di-override HYPERLUX_MEMPOOL_SIZE /
+// HYPERLUX_MEMPOOL_TTL (durasi Go, mis. "30m") / HYPERLUX_MEMPOOL_BUMP (persen).
+func TxPoolConfigFromEnv() TxPoolConfig {
+	cfg := DefaultTxPoolConfig()
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_MEMPOOL_SIZE")); err == nil && v > 0 {
+		cfg.MaxSize = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("HYPERLUX_MEMPOOL_TTL")); err == nil && v > 0 {
+		cfg.TTL = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("HYPERLUX_MEMPOOL_BUMP")); err == nil && v >= 0 {
+		cfg.MinBumpPct = v
+	}
+	return cfg
+}
+
+type poolTx struct {
+	tx    Transaction
+	hash  string
+	size  uint64
+	added time.Time
+	seq   uint64 // FIFO tie-break
+}
+
+// cmpFeeRate: bandingkan fee per byte (perkalian silang 128-bit, tanpa float).
+func cmpFeeRate(a, b *poolTx) int {
+	ah, al := bits.Mul64(uint64(a.tx.Fee), b.size)
+	bh, bl := bits.Mul64(uint64(b.tx.Fee), a.size)
+	switch {
+	case ah != bh:
+		return cmpUint64(ah, bh)
+	default:
+		return cmpUint64(al, bl)
+	}
+}
+
+func cmpUint64(a, b uint64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+// betterThan: fee rate lebih tinggi, seri → yang lebih dulu masuk.
+func (a *poolTx) betterThan(b *poolTx) bool {
+	if c := cmpFeeRate(a, b); c != 0 {
+		return c > 0
+	}
+	return a.seq < b.seq
+}
+
+type TxPool struct {
+	mu      sync.RWMutex
+	cfg     TxPoolConfig
+	byHash  map[string]*poolTx
+	senders map[string]map[int]*poolTx // sender → nonce → tx
+	seq     uint64
+	now     func() time.Time
+}
+
+func NewTxPool(cfg TxPoolConfig) *TxPool {
+	return &TxPool{
+		cfg:     cfg,
+		byHash:  map[string]*poolTx{},
+		senders: map[string]map[int]*poolTx{},
+		now:     time.Now,
+	}
+}
+
+// Pool: mempool global node.
+var Pool = NewTxPool(TxPoolConfigFromEnv())
+
+func (p *TxPool) Config() TxPoolConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cfg
+}
+
+func (p *TxPool) SetConfig(cfg TxPoolConfig) {
+	p.mu.Lock()
+	p.cfg = cfg
+	p.mu.Unlock()
+}
+
+// Add memasukkan TX yang sudah lolos cek stateless (signature, envelope, address).
+// stateNonce = nonce terakhir yang sudah dieksekusi, balance = saldo state sender.
+func (p *TxPool) Add(tx Transaction, stateNonce int, balance Amount) error {
+	if tx.Fee < CalculateFee(tx) {
+		return ErrTxFee
+	}
+	ptx := &poolTx{tx: tx, hash: HashTransaction(tx), size: uint64(len(EncodeTransaction(tx)))}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.byHash[ptx.hash]; ok {
+		return ErrTxKnown
+	}
+	if tx.Nonce <= stateNonce {
+		return fmt.Errorf("%w (state=%d, got %d)", ErrNonceTooLow, stateNonce, tx.Nonce)
+	}
+	if tx.Nonce > stateNonce+p.cfg.MaxNonceGap {
+		return fmt.Errorf("%w (state=%d, got %d, max gap %d)", ErrNonceTooHigh, stateNonce, tx.Nonce, p.cfg.MaxNonceGap)
+	}
+
+	queue := p.senders[tx.From]
+	old := queue[tx.Nonce]
+	if old != nil {
+		minFee := old.tx.Fee.MulDiv(Amount(100+p.cfg.MinBumpPct), 100)
+		if tx.Fee <= old.tx.Fee || tx.Fee < minFee {
+			return fmt.Errorf("%w (fee lama %d, minimal %d)", ErrReplaceUnderpriced, old.tx.Fee, max(minFee, old.tx.Fee+1))
+		}
+	}
+
+	// saldo harus menutup semua TX sender di pool (dengan TX lama diganti yang baru)
+	need, _ := txCost(tx)
+	for n, q := range queue {
+		if n == tx.Nonce {
+			continue
+		}
+		c, _ := txCost(q.tx)
+		var err error
+		if need, err = need.Add(c); err != nil {
+			return ErrInsufficientFunds
+		}
+	}
+	if need > balance {
+		return ErrInsufficientFunds
+	}
+
+	if old == nil && len(p.byHash) >= p.cfg.MaxSize {
+		p.expireLocked()
+		if len(p.byHash) >= p.cfg.MaxSize {
+			victim := p.evictionCandidateLocked()
+			if victim == nil || cmpFeeRate(ptx, victim) <= 0 {
+				return ErrPoolFull
+			}
+			p.removeLocked(victim)
+		}
+	}
+
+	if old != nil {
+		p.removeLocked(old)
+	}
+	p.seq++
+	ptx.seq = p.seq
+	ptx.added = p.now()
+	p.insertLocked(ptx)
+	return nil
+}
+
+func (p *TxPool) insertLocked(ptx *poolTx) {
+	q := p.senders[ptx.tx.From]
+	if q == nil {
+		q = map[int]*poolTx{}
+		p.senders[ptx.tx.From] = q
+	}
+	q[ptx.tx.Nonce] = ptx
+	p.byHash[ptx.hash] = ptx
+}
+
+func (p *TxPool) removeLocked(ptx *poolTx) {
+	delete(p.byHash, ptx.hash)
+	if q := p.senders[ptx.tx.From]; q != nil {
+		delete(q, ptx.tx.Nonce)
+		if len(q) == 0 {
+			delete(p.senders, ptx.tx.From)
+		}
+	}
+}
+
+// evictionCandidateLocked: ekor antrean (nonce tertinggi) tiap sender dengan
+// fee rate terendah, supaya eviction tidak membuat gap di tengah antrean.
+func (p *TxPool) evictionCandidateLocked() *poolTx {
+	var worst *poolTx
+	for _, q := range p.senders {
+		var tail *poolTx
+		for _, ptx := range q {
+			if tail == nil || ptx.tx.Nonce > tail.tx.Nonce {
+				tail = ptx
+			}
+		}
+		if tail != nil && (worst == nil || worst.betterThan(tail)) {
+			worst = tail
+		}
+	}
+	return worst
+}
+
+func (p *TxPool) expireLocked() int {
+	if p.cfg.TTL <= 0 {
+		return 0
+	}
+	cutoff := p.now().Add(-p.cfg.TTL)
+	n := 0
+	for _, ptx := range p.byHash {
+		if ptx.added.Before(cutoff) {
+			p.removeLocked(ptx)
+			n++
+		}
+	}
+	return n
+}
+
+// Expire membuang TX yang melewati TTL; return jumlah yang dibuang.
+func (p *TxPool) Expire() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.expireLocked()
+}
+
+// Pending: TX executable (nonce menyambung dari state), urut fee per byte
+// global dengan nonce per sender tetap berurutan. limit ≤ 0 → semua.
+func (p *TxPool) Pending(limit int) []Transaction {
+	p.mu.Lock()
+	p.expireLocked()
+	runs := make([][]*poolTx, 0, len(p.senders))
+	for sender, q := range p.senders {
+		next := stateNonceOf(sender) + 1
+		var run []*poolTx
+		for ptx, ok := q[next]; ok; ptx, ok = q[next] {
+			run = append(run, ptx)
+			next++
+		}
+		if len(run) > 0 {
+			runs = append(runs, run)
+		}
+	}
+	p.mu.Unlock()
+
+	h := &runHeap{}
+	for _, r := range runs {
+		heap.Push(h, r)
+	}
+	out := []Transaction{}
+	for h.Len() > 0 && (limit <= 0 || len(out) < limit) {
+		r := heap.Pop(h).([]*poolTx)
+		out = append(out, r[0].tx)
+		if len(r) > 1 {
+			heap.Push(h, r[1:])
+		}
+	}
+	return out
+}
+
+// PendingNonce: nonce berikutnya untuk sender dengan memperhitungkan TX di pool.
+func (p *TxPool) PendingNonce(sender string, stateNonce int) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	next := stateNonce + 1
+	q := p.senders[sender]
+	for {
+		if _, ok := q[next]; !ok {
+			return next
+		}
+		next++
+	}
+}
+
+// Get: TX di pool berdasarkan hash.
+func (p *TxPool) Get(hash string) (Transaction, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if ptx, ok := p.byHash[hash]; ok {
+		return ptx.tx, true
+	}
+	return Transaction{}, false
+}
+
+// Remove membuang TX (mis. yang sudah masuk blok) berdasarkan hash.
+func (p *TxPool) Remove(txs []Transaction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, tx := range txs {
+		if ptx, ok := p.byHash[HashTransaction(tx)]; ok {
+			p.removeLocked(ptx)
+		}
+	}
+}
+
+// PruneStale membuang TX yang nonce-nya sudah terpakai di state
+// (mis. digantikan TX lain dengan nonce sama dari blok peer).
+func (p *TxPool) PruneStale() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for sender, q := range p.senders {
+		stateNonce := stateNonceOf(sender)
+		for nonce, ptx := range q {
+			if nonce <= stateNonce {
+				p.removeLocked(ptx)
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (p *TxPool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.byHash)
+}
+
+// Stats: jumlah TX executable (pending) dan yang diparkir karena gap nonce (queued).
+func (p *TxPool) Stats() (pending, queued int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for sender, q := range p.senders {
+		next := stateNonceOf(sender) + 1
+		for _, ok := q[next]; ok; _, ok = q[next] {
+			pending++
+			next++
+		}
+		queued += len(q)
+	}
+	return pending, queued - pending
+}
+
+// All: semua TX (urut sender+nonce tidak dijamin), untuk persistence.
+func (p *TxPool) All() []Transaction {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]Transaction, 0, len(p.byHash))
+	for _, ptx := range p.byHash {
+		out = append(out, ptx.tx)
+	}
+	return out
+}
+
+// encode: TX + waktu masuk (urut masuk), supaya TTL & urutan FIFO tetap
+// berlaku setelah restart.
+func (p *TxPool) encode() []byte {
+	p.mu.RLock()
+	list := make([]*poolTx, 0, len(p.byHash))
+	for _, ptx := range p.byHash {
+		list = append(list, ptx)
+	}
+	p.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].seq < list[j].seq })
+
+	e := NewEncoder(tagMempool)
+	e.Len(len(list))
+	for _, ptx := range list {
+		e.Raw(EncodeTransaction(ptx.tx))
+		e.Int64(ptx.added.UnixNano())
+	}
+	return e.Bytes()
+}
+
+func decodeMempool(data []byte) ([]Transaction, []time.Time, error) {
+	d := NewDecoder(data, tagMempool)
+	n := d.Len()
+	var txs []Transaction
+	var added []time.Time
+	for i := 0; i < n && d.Err() == nil; i++ {
+		tx, err := DecodeTransaction(d.Raw())
+		at := d.Int64()
+		if d.Err() != nil {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, tx)
+		added = append(added, time.Unix(0, at))
+	}
+	return txs, added, d.Finish()
+}
+
+// restore: isi ulang dari DB tanpa cek saldo (divalidasi lagi saat eksekusi).
+// added = waktu masuk tersimpan per TX (nil: format lama, dianggap baru masuk).
+func (p *TxPool) restore(txs []Transaction, added []time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, tx := range txs {
+		ptx := &poolTx{tx: tx, hash: HashTransaction(tx), size: uint64(len(EncodeTransaction(tx)))}
+		if _, dup := p.byHash[ptx.hash]; dup {
+			continue
+		}
+		if old := p.senders[tx.From][tx.Nonce]; old != nil {
+			if cmpFeeRate(ptx, old) <= 0 {
+				continue
+			}
+			p.removeLocked(old)
+		}
+		p.seq++
+		ptx.seq = p.seq
+		ptx.added = p.now()
+		if i < len(added) {
+			ptx.added = added[i]
+		}
+		p.insertLocked(ptx)
+	}
+}
+
+func (p *TxPool) Clear() {
+	p.mu.Lock()
+	p.byHash = map[string]*poolTx{}
+	p.senders = map[string]map[int]*poolTx{}
+	p.mu.Unlock()
+}
+
+func stateNonceOf(addr string) int {
+	NonceTableMu.RLock()
+	defer NonceTableMu.RUnlock()
+	return NonceTable[addr]
+}
+
+// runHeap: max-heap antrean sender berdasarkan fee rate TX terdepan.
+type runHeap [][]*poolTx
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i][0].betterThan(h[j][0]) }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)        { *h = append(*h, x.([]*poolTx)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
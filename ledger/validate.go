@@ -0,0 +1,243 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/soden46/hyperlux-chain/crypto"
+)
+
+// ================== Block validation errors ==================
+
+var (
+	ErrBlockKnown      = errors.New("block sudah ada")
+	ErrNoChainHead     = errors.New("chain kosong, jalankan init dulu")
+	ErrBlockIndex      = errors.New("index tidak menyambung ke head")
+	ErrBlockAhead      = errors.New("blok di depan head (belum sinkron)")
+	ErrBlockPrevHash   = errors.New("prev_hash tidak cocok dengan head")
+	ErrBlockMerkleRoot = errors.New("merkle root tidak cocok")
+	ErrBlockHash       = errors.New("hash header tidak cocok")
+	ErrBlockProposer   = errors.New("proposer bukan validator eligible")
+	ErrBlockSignature  = errors.New("signature proposer tidak valid")
+	ErrBlockTx         = errors.New("transaksi tidak valid menurut aturan eksekusi")
+	ErrBlockTooLarge   = errors.New("blok melebihi batas ukuran / jumlah tx")
+	ErrBlockStateRoot  = errors.New("state root hasil re-eksekusi tidak cocok")
+	ErrBlockCommit     = errors.New("sertifikat commit tidak valid")
+	ErrBlockPoH        = errors.New("proof of history tidak valid")
+	ErrBlockPruned     = errors.New("blok tanpa body (sudah dipangkas)")
+	ErrBlockEvidence   = errors.New("bukti equivocation tidak valid")
+)
+
+// BlockValidationError membungkus salah satu Err* di atas (pakai errors.Is).
+type BlockValidationError struct {
+	Index  int
+	Hash   string
+	Err    error
+	Detail string
+}
+
+func (e *BlockValidationError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("block %d (%.12s): %v", e.Index, e.Hash, e.Err)
+	}
+	return fmt.Sprintf("block %d (%.12s): %v: %s", e.Index, e.Hash, e.Err, e.Detail)
+}
+
+func (e *BlockValidationError) Unwrap() error { return e.Err }
+
+func invalidBlock(b Block, err error, format string, args ...interface{}) error {
+	return &BlockValidationError{Index: b.Index, Hash: b.Hash, Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+// ================== ValidateBlock ==================
+
+// ValidateBlock memeriksa blok kandidat terhadap head saat ini tanpa mengubah state.
+// Proposal BFT belum punya sertifikat commit; bila ada, ikut diverifikasi.
+func ValidateBlock(b Block) error {
+	_, err := validateBlock(b, false)
+	return err
+}
+
+// importMu menserialkan ImportBlock: jalur commit BFT & gossip bisa membawa
+// blok height yang sama bersamaan, jadi head dicek ulang di bawah lock.
+var importMu sync.Mutex
+
+// ImportBlock: validasi (termasuk sertifikat commit), append ke store bersama
+// state hasil re-eksekusi, baru state global diganti. Dipakai jalur consensus
+// & gossip/sync.
+func ImportBlock(b Block) error {
+	importMu.Lock()
+	defer importMu.Unlock()
+
+	st, err := validateBlock(b, true)
+	if err != nil {
+		return err
+	}
+	if err := appendBlock(b, &st); err != nil {
+		if errors.Is(err, ErrBlockKnown) {
+			return invalidBlock(b, ErrBlockKnown, "")
+		}
+		return err
+	}
+	RemoveCommittedFromMempool(b.Transactions)
+	SaveMempool() // state sudah ikut batch AppendBlock
+	removeIncludedEvidence(b.Evidence)
+	return nil
+}
+
+// blockState: hasil re-eksekusi blok di atas head. balances/nonces hanya berisi
+// akun yang disentuh blok; tree = pohon state setelah blok (root = StateRoot).
+type blockState struct {
+	balances   map[string]Amount
+	nonces     map[string]int
+	validators []ValidatorDef
+	tree       *crypto.SparseMerkleTree
+}
+
+// validateBlock mengembalikan state blok yang sudah dieksekusi (belum dipasang).
+func validateBlock(b Block, requireCommit bool) (blockState, error) {
+	// 0) blok hasil prune tinggal header: TX & merkle root tidak bisa dicek
+	if b.Pruned {
+		return blockState{}, invalidBlock(b, ErrBlockPruned, "")
+	}
+
+	// 1) kontinuitas terhadap head
+	head, ok := HeadBlock()
+	if !ok {
+		return blockState{}, invalidBlock(b, ErrNoChainHead, "")
+	}
+	if b.Index <= head.Index {
+		if known, ok := GetBlockByHeight(b.Index); ok && known.Hash == b.Hash {
+			return blockState{}, invalidBlock(b, ErrBlockKnown, "")
+		}
+	}
+	if b.Index > head.Index+1 {
+		return blockState{}, invalidBlock(b, ErrBlockAhead, "head=%d", head.Index)
+	}
+	if b.Index != head.Index+1 {
+		return blockState{}, invalidBlock(b, ErrBlockIndex, "head=%d", head.Index)
+	}
+	if b.PrevHash != head.Hash {
+		return blockState{}, invalidBlock(b, ErrBlockPrevHash, "head=%.12s", head.Hash)
+	}
+
+	// 2) batas blok, merkle root & hash header
+	if len(b.Transactions) > Params.MaxBlockTxs {
+		return blockState{}, invalidBlock(b, ErrBlockTooLarge, "%d tx > %d", len(b.Transactions), Params.MaxBlockTxs)
+	}
+	if len(b.Evidence) > MaxBlockEvidence {
+		return blockState{}, invalidBlock(b, ErrBlockTooLarge, "%d bukti > %d", len(b.Evidence), MaxBlockEvidence)
+	}
+	// batas ukuran berlaku untuk header & TX; sertifikat commit dan entry PoH
+	// (tick + mixin, dibatasi verifyBlockPoH) tidak dihitung
+	body := b
+	body.Commit = nil
+	if b.PoH != nil {
+		summary := *b.PoH
+		summary.Entries = nil
+		body.PoH = &summary
+	}
+	if size := len(EncodeBlock(body)); size > Params.MaxBlockBytes {
+		return blockState{}, invalidBlock(b, ErrBlockTooLarge, "%d byte > %d", size, Params.MaxBlockBytes)
+	}
+	if mr := ComputeMerkleRoot(b.Transactions); mr != b.MerkleRoot {
+		return blockState{}, invalidBlock(b, ErrBlockMerkleRoot, "expected %.12s", mr)
+	}
+	if h := hashBlockHeader(b); h != b.Hash {
+		return blockState{}, invalidBlock(b, ErrBlockHash, "expected %.12s", h)
+	}
+
+	// 3) proposer harus di active set epoch blok, lolos undian VRF &
+	//    menandatangani blok (suspend lokal tidak ikut: hasilnya harus sama di
+	//    semua node)
+	active := ActiveValidators(b.Index)
+	if !inValidatorSet(active, b.Proposer) {
+		return blockState{}, invalidBlock(b, ErrBlockProposer, "%q tidak di active set epoch %d", b.Proposer, EpochOf(b.Index))
+	}
+	if err := VerifyBlockSignature(b); err != nil {
+		return blockState{}, invalidBlock(b, ErrBlockSignature, "%v", err)
+	}
+	if err := verifyBlockVRF(b, head, active); err != nil {
+		return blockState{}, invalidBlock(b, ErrBlockProposer, "%v", err)
+	}
+
+	// 4) sertifikat commit: > 2/3 stake active set epoch blok ini
+	if requireCommit || b.Commit != nil {
+		if err := VerifyCommit(b, active); err != nil {
+			return blockState{}, invalidBlock(b, ErrBlockCommit, "%v", err)
+		}
+	}
+
+	// 5) PoH: segmen menyambung ke parent, tick sesuai parameter & memuat semua TX
+	//    (hash diverifikasi paralel per entry)
+	if err := verifyBlockPoH(b, head); err != nil {
+		return blockState{}, invalidBlock(b, ErrBlockPoH, "%v", err)
+	}
+
+	// 6) re-eksekusi (Block-STM, setara serial urut blok) di atas salinan akun
+	//    yang disentuh blok
+	balances, nonces := touchedAccounts(b.Transactions, b.Proposer)
+	for i, err := range ExecuteTxsResults(b.Transactions, b.Index, balances, nonces) {
+		if err != nil {
+			return blockState{}, invalidBlock(b, ErrBlockTx, "tx %d: %v", i, err)
+		}
+	}
+	if err := creditProposer(balances, b.Proposer, b.Transactions); err != nil {
+		return blockState{}, invalidBlock(b, ErrBlockTx, "reward: %v", err)
+	}
+
+	// 7) migrasi address validator legacy & bukti equivocation → jail (berlaku
+	//    mulai set epoch berikutnya)
+	prev := GetValidators()
+	validators, err := applyEvidence(migrateValidators(prev, b.Transactions), b.Evidence, b.Index)
+	if err != nil {
+		return blockState{}, invalidBlock(b, ErrBlockEvidence, "%v", err)
+	}
+
+	tree := stateTreeAfter(head, b.Index, balances, nonces, prev, validators)
+	if got := treeRootHex(tree); got != b.StateRoot {
+		return blockState{}, invalidBlock(b, ErrBlockStateRoot, "expected %.12s", got)
+	}
+	return blockState{balances: balances, nonces: nonces, validators: validators, tree: tree}, nil
+}
+
+// touchedAccounts: salinan balance & nonce pengirim/penerima txs dan proposer,
+// satu-satunya akun yang bisa diubah eksekusi blok.
+func touchedAccounts(txs []Transaction, proposer string) (map[string]Amount, map[string]int) {
+	balances := make(map[string]Amount, 2*len(txs)+1)
+	nonces := make(map[string]int, 2*len(txs)+1)
+	BalanceMu.RLock()
+	NonceTableMu.RLock()
+	defer NonceTableMu.RUnlock()
+	defer BalanceMu.RUnlock()
+	touch := func(addr string) {
+		if addr != "" {
+			balances[addr], nonces[addr] = Balances[addr], NonceTable[addr]
+		}
+	}
+	for _, tx := range txs {
+		touch(tx.From)
+		touch(tx.To)
+	}
+	touch(proposer)
+	return balances, nonces
+}
+
+// copyAccountState: salinan seluruh akun (snapshot).
+func copyAccountState() (map[string]Amount, map[string]int) {
+	BalanceMu.RLock()
+	balances := make(map[string]Amount, len(Balances))
+	for k, v := range Balances {
+		balances[k] = v
+	}
+	BalanceMu.RUnlock()
+
+	NonceTableMu.RLock()
+	nonces := make(map[string]int, len(NonceTable))
+	for k, v := range NonceTable {
+		nonces[k] = v
+	}
+	NonceTableMu.RUnlock()
+	return balances, nonces
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/soden46/hyperlux-chain/wallet"
@@ -13,22 +14,41 @@ import (
 // ================== Data & Registry ==================
 
 type ValidatorDef struct {
-	Address string `json:"address"`
-	Stake   int    `json:"stake"`
+	Address     string `json:"address"`
+	Stake       Amount `json:"stake"`
+	JailedUntil int    `json:"jailed_until,omitempty"` // height pertama bebas jail (jail.go)
 }
 
+// Validators diganti utuh (import blok, restore, slash), tidak pernah diubah di
+// tempat; goroutine lain membaca lewat GetValidators.
 var (
 	Validators       []ValidatorDef
+	ValidatorsMu     sync.RWMutex
 	ValidatorWallets = map[string]*wallet.Wallet{}
 )
 
-const validatorsDBFile = "validators.json"
+// GetValidators: registry validator saat ini (read-only, jangan diubah).
+func GetValidators() []ValidatorDef {
+	ValidatorsMu.RLock()
+	defer ValidatorsMu.RUnlock()
+	return Validators
+}
+
+func setValidators(vals []ValidatorDef) {
+	ValidatorsMu.Lock()
+	Validators = vals
+	ValidatorsMu.Unlock()
+}
+
+const legacyValidatorsFile = "validators.json" // sebelum validator disimpan di DB
 
 // ================== Wallet loading helpers ==================
 
 func AutoLoadValidatorWallets() {
+	queueLegacyValidatorMigrations()
+
 	loaded := 0
-	for _, v := range Validators {
+	for _, v := range GetValidators() {
 		path := filepath.Join("validators", v.Address+".json")
 		if _, err := os.Stat(path); err == nil {
 			if w, err := wallet.LoadWallet(path); err == nil {
@@ -65,29 +85,29 @@ func AutoLoadValidatorWallets() {
 
 // ================== Load/Save Validators ==================
 
+// LoadValidators: dari DB (state/validators); validators.json hanya dibaca
+// sekali dari node lama, simpan berikutnya masuk DB.
 func LoadValidators() {
-	data, err := os.ReadFile(validatorsDBFile)
+	InitDB()
+	data, err := db.Get([]byte(keyValidators), nil)
 	if err != nil {
-		return
+		if data, err = os.ReadFile(legacyValidatorsFile); err != nil {
+			return
+		}
 	}
 	var list []ValidatorDef
 	if err := json.Unmarshal(data, &list); err != nil {
 		fmt.Println("⚠️ LoadValidators warn:", err)
 		return
 	}
-	Validators = list
+	setValidators(list)
 }
 
-func SaveValidators() {
-	b, err := json.MarshalIndent(Validators, "", "  ")
-	if err != nil {
-		fmt.Println("⚠️ SaveValidators warn:", err)
-		return
-	}
-	_ = os.WriteFile(validatorsDBFile, b, 0644)
-}
+func SaveValidators() { saveStateWarn() }
 
 // ================== Status / Suspension ==================
+// Suspend = status lokal node (vote & proposal node ini, lihat consensus);
+// active set epoch hanya mengikuti jail di state chain (jail.go).
 
 func getOrCreateRuntime(addr string) *ValidatorRuntime {
 	ValidatorStatusMu.Lock()
@@ -123,6 +143,12 @@ func IsSuspended(addr string, need SuspensionScope) bool {
 }
 
 func SuspendValidator(addr string, scope SuspensionScope, dur time.Duration) {
+	suspend(addr, scope, dur)
+	saveValidatorStatus()
+}
+
+// suspend: ubah status runtime saja (pemanggil yang menyimpan).
+func suspend(addr string, scope SuspensionScope, dur time.Duration) {
 	rt := getOrCreateRuntime(addr)
 	ValidatorStatusMu.Lock()
 	rt.SuspendedUntil = time.Now().Add(dur).Unix()
@@ -142,7 +168,7 @@ const (
 
 type SlashParams struct {
 	// Absolute amount (token) to slash. Jika 0 dan Percent>0 → gunakan persentase stake.
-	Amount  int
+	Amount  Amount
 	Percent float64 // e.g., 0.0001 = 0.01%
 
 	// Distribusi (default 70/15/10/5 untuk safety fault)
@@ -192,173 +218,209 @@ func defaultDowntimePolicy() SlashParams {
 	}
 }
 
-func findValidator(addr string) (idx int, ok bool) {
-	for i := range Validators {
-		if Validators[i].Address == addr {
+func findValidator(vals []ValidatorDef, addr string) (idx int, ok bool) {
+	for i := range vals {
+		if vals[i].Address == addr {
 			return i, true
 		}
 	}
 	return -1, false
 }
 
-func slashSingle(addr string, amt int) int {
-	if amt <= 0 {
+func slashSingle(vals []ValidatorDef, addr string, amt Amount) Amount {
+	if amt == 0 {
 		return 0
 	}
-	i, ok := findValidator(addr)
+	i, ok := findValidator(vals, addr)
 	if !ok {
 		return 0
 	}
-	if Validators[i].Stake < amt {
-		amt = Validators[i].Stake
+	if vals[i].Stake < amt {
+		amt = vals[i].Stake
 	}
-	Validators[i].Stake -= amt
+	vals[i].Stake -= amt
 	return amt
 }
 
-func distributeSlashed(total int, reporter string, offender string) {
-	if total <= 0 {
-		return
+// slashCredit: saldo yang diterima dari hasil slash.
+type slashCredit struct {
+	addr string
+	amt  Amount
+}
+
+// distributeSlashed membagi hasil slash: tambahan burned & treasury, plus
+// kredit whistleblower & validator jujur (pro-rata stake di vals).
+func distributeSlashed(vals []ValidatorDef, total Amount, reporter string, offender string) (burned, treasury Amount, credits []slashCredit) {
+	if total == 0 {
+		return 0, 0, nil
 	}
 	// 70% burn, 15% treasury, 10% whistle, 5% honest
-	burn := int(float64(total) * 0.70)
-	trea := int(float64(total) * 0.15)
-	whis := int(float64(total) * 0.10)
-	hon := total - burn - trea - whis // residu → honest
+	burn := total.MulDiv(70, 100)
+	trea := total.MulDiv(15, 100)
+	whis := total.MulDiv(10, 100)
+	hon := total - burn - trea - whis // residu → honest (tidak underflow: 95% ≤ total)
 
 	// update sinks
-	BurnedSupply += burn
-	TreasuryBalance += trea
+	burned, treasury = burn, trea
 
 	// whistle
 	if reporter != "" && whis > 0 {
-		BalanceMu.Lock()
-		Balances[reporter] += whis
-		BalanceMu.Unlock()
+		credits = append(credits, slashCredit{reporter, whis})
 	} else {
 		// jika tidak ada reporter, masuk treasury
-		TreasuryBalance += whis
+		treasury += whis
 		whis = 0
 	}
 
 	// honest redistribution pro-rata stake (kecuali offender)
 	if hon > 0 {
-		totalStake := 0
-		for _, v := range Validators {
+		var totalStake Amount
+		for _, v := range vals {
 			if v.Address == offender {
 				continue
 			}
 			totalStake += v.Stake
 		}
 		if totalStake > 0 {
-			BalanceMu.Lock()
-			for _, v := range Validators {
+			for _, v := range vals {
 				if v.Address == offender {
 					continue
 				}
-				share := (hon * v.Stake) / totalStake
-				if share > 0 {
-					Balances[v.Address] += share
+				if share := hon.MulDiv(v.Stake, totalStake); share > 0 {
+					credits = append(credits, slashCredit{v.Address, share})
 				}
 			}
-			BalanceMu.Unlock()
 		} else {
 			// fallback → treasury
-			TreasuryBalance += hon
+			treasury += hon
 		}
 	}
 
 	fmt.Printf("💥 Slashed=%d | burn=%d treasury=%d whistle=%d honest=%d\n", total, burn, trea, whis, hon)
+	return burned, treasury, credits
 }
 
 // === Public helpers (HANYA SATU DEFINISI) ===
 
-func SlashDowntime(addr string) {
+func SlashDowntime(addr string) error {
 	p := defaultDowntimePolicy()
-	ApplySlash(addr, p, "")
+	return ApplySlash(addr, p, "")
 }
 
-func SlashSafetyFault(addr string, amount int, reporter string, correlationMul float64) {
+func SlashSafetyFault(addr string, amount Amount, reporter string, correlationMul float64) error {
 	p := defaultSafetyPolicy()
 	p.Amount = amount
 	if correlationMul > 0 {
 		p.CorrelationMul = correlationMul
 	}
-	ApplySlash(addr, p, reporter)
+	return ApplySlash(addr, p, reporter)
 }
 
-func SlashValidator(addr string, amount int) {
-	SlashSafetyFault(addr, amount, "", 1.0)
+func SlashValidator(addr string, amount Amount) error {
+	return SlashSafetyFault(addr, amount, "", 1.0)
 }
 
-func SlashCluster(mainAddr, subAddr string, totalAmount int, reporter string) {
-	if totalAmount <= 0 {
-		return
+func SlashCluster(mainAddr, subAddr string, totalAmount Amount, reporter string) error {
+	if totalAmount == 0 {
+		return nil
 	}
-	mainAmt := (totalAmount * 40) / 100
+	mainAmt := totalAmount.MulDiv(40, 100)
 	subAmt := totalAmount - mainAmt
 
 	// sub
 	if subAmt > 0 && subAddr != "" {
-		SlashSafetyFault(subAddr, subAmt, reporter, 1.0)
+		if err := SlashSafetyFault(subAddr, subAmt, reporter, 1.0); err != nil {
+			return err
+		}
 	}
 	// main
 	if mainAmt > 0 && mainAddr != "" {
-		SlashSafetyFault(mainAddr, mainAmt, reporter, 1.0)
+		return SlashSafetyFault(mainAddr, mainAmt, reporter, 1.0)
 	}
+	return nil
 }
 
-// core slashing executor
-func ApplySlash(offender string, params SlashParams, reporter string) {
+// core slashing executor. Stake, burned & treasury ikut state root, jadi slash
+// manual hanya sebelum genesis; setelah chain berjalan, equivocation dihukum
+// lewat bukti di blok (jail.go).
+func ApplySlash(offender string, params SlashParams, reporter string) error {
+	if err := requirePreGenesis("slash"); err != nil {
+		return err
+	}
+	// stake dipotong di salinan registry, lalu diganti utuh bersama supply
+	vals := append([]ValidatorDef(nil), GetValidators()...)
+
 	// resolve amount
 	amt := params.Amount
-	if amt <= 0 && params.Percent > 0 {
-		if i, ok := findValidator(offender); ok {
-			amt = int(float64(Validators[i].Stake) * params.Percent)
-			if amt <= 0 && Validators[i].Stake > 0 {
+	if amt == 0 && params.Percent > 0 {
+		if i, ok := findValidator(vals, offender); ok {
+			amt = vals[i].Stake.Frac(params.Percent)
+			if amt == 0 && vals[i].Stake > 0 {
 				amt = 1 // minimal 1 token
 			}
 		}
 	}
 	if params.CorrelationMul > 0 && params.CorrelationMul != 1.0 {
-		amt = int(float64(amt) * params.CorrelationMul)
+		// dibatasi MaxSupply; slashSingle memotong lagi ke stake validator
+		scaled := float64(amt) * params.CorrelationMul
+		if scaled >= float64(MaxSupply) {
+			amt = MaxSupply
+		} else {
+			amt = Amount(scaled)
+		}
 	}
-	if amt <= 0 {
-		return
+	if amt == 0 {
+		return nil
 	}
 
 	// apply slash (mutate stake)
-	actual := slashSingle(offender, amt)
-	if actual <= 0 {
-		return
+	actual := slashSingle(vals, offender, amt)
+	if actual == 0 {
+		return nil
 	}
 	fmt.Printf("⛔ Validator %s slashed %d (kind=%d)\n", offender, actual, params.Kind)
 
 	// distribution by policy
+	var burned, treasury Amount
+	var credits []slashCredit
 	switch params.Kind {
 	case SlashKindDowntime:
 		// burn all
-		BurnedSupply += actual
+		burned = actual
 	default:
 		// 70/15/10/5
-		distributeSlashed(actual, reporter, offender)
+		burned, treasury, credits = distributeSlashed(vals, actual, reporter, offender)
+	}
+	setValidators(vals)
+	SupplyMu.Lock()
+	BurnedSupply += burned
+	TreasuryBalance += treasury
+	SupplyMu.Unlock()
+	if len(credits) > 0 {
+		BalanceMu.Lock()
+		for _, c := range credits {
+			mustCredit(Balances, c.addr, c.amt)
+		}
+		BalanceMu.Unlock()
 	}
 
-	// suspension
+	// suspension: disimpan bersama stake & distribusi dalam satu batch
 	if params.SuspendFor > 0 && params.SuspendScope != ScopeNone {
-		SuspendValidator(offender, params.SuspendScope, params.SuspendFor)
+		suspend(offender, params.SuspendScope, params.SuspendFor)
 	}
 
-	SaveValidators()
+	saveStateWarn()
+	return nil
 }
 
 // ================== Fix/Init Validators ==================
 
 func FixValidators() {
 	_ = os.MkdirAll("validators", 0o755)
+	vals := append([]ValidatorDef(nil), GetValidators()...)
 
 	// coba load dari folder jika DB kosong (LoadValidators dipanggil di luar)
-	if len(Validators) == 0 {
+	if len(vals) == 0 {
 		files, _ := os.ReadDir("validators")
 		for _, f := range files {
 			if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
@@ -366,7 +428,7 @@ func FixValidators() {
 			}
 			w, err := wallet.LoadWallet(filepath.Join("validators", f.Name()))
 			if err == nil {
-				Validators = append(Validators, ValidatorDef{
+				vals = append(vals, ValidatorDef{
 					Address: w.AddressEd,
 					Stake:   100000,
 				})
@@ -376,13 +438,13 @@ func FixValidators() {
 	}
 
 	// Jika tetap kosong → generate default N
-	if len(Validators) == 0 {
+	if len(vals) == 0 {
 		const N = 6
 		for i := 0; i < N; i++ {
 			w := wallet.GenerateWallet()
 			filename := filepath.Join("validators", w.AddressEd+".json")
 			if err := w.SaveToFile(filename); err == nil {
-				Validators = append(Validators, ValidatorDef{
+				vals = append(vals, ValidatorDef{
 					Address: w.AddressEd,
 					Stake:   100000,
 				})
@@ -390,13 +452,15 @@ func FixValidators() {
 			}
 		}
 	}
+	setValidators(vals)
 
 	SaveValidators()
 	AutoLoadValidatorWallets()
+	// registry diubah manual: active set baru berlaku di boundary epoch berikutnya
 }
 
 // Utility export
 func ExportValidatorsJSON() []byte {
-	b, _ := json.MarshalIndent(Validators, "", "  ")
+	b, _ := json.MarshalIndent(GetValidators(), "", "  ")
 	return b
 }
@@ -0,0 +1,313 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ================== BFT messages (proposal & vote) ==================
+//
+// Pesan consensus gaya Tendermint: proposer round menandatangani Proposal,
+// tiap validator menandatangani Prevote/Precommit dengan kuncinya sendiri.
+// Preimage signature mengikat ChainID, height & round supaya vote tidak bisa
+// diputar ulang ke chain / round lain. Vote dengan BlockHash "" = vote nil.
+
+type VoteType byte
+
+const (
+	VotePrevote   VoteType = 1
+	VotePrecommit VoteType = 2
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case VotePrevote:
+		return "prevote"
+	case VotePrecommit:
+		return "precommit"
+	default:
+		return fmt.Sprintf("vote(%d)", byte(t))
+	}
+}
+
+var (
+	ErrVoteSignature = errors.New("signature vote tidak valid")
+	ErrVoteValidator = errors.New("voter bukan validator")
+	ErrVoteMismatch  = errors.New("vote untuk height/round/tipe lain")
+	ErrVoteConflict  = errors.New("validator memberi dua vote berbeda (equivocation)")
+)
+
+type Vote struct {
+	Type      VoteType `json:"type"`
+	Height    int      `json:"height"`
+	Round     int      `json:"round"`
+	BlockHash string   `json:"block_hash"` // "" = nil
+	Validator string   `json:"validator"`
+	PubKey    string   `json:"pubkey"`
+	Signature string   `json:"signature"`
+}
+
+type Proposal struct {
+	Height    int    `json:"height"`
+	Round     int    `json:"round"`
+	Block     Block  `json:"block"`
+	Proposer  string `json:"proposer"`  // proposer round ini (blok bisa hasil round sebelumnya)
+	VRFProof  string `json:"vrf_proof"` // undian proposer untuk (height, round) ini
+	PubKey    string `json:"pubkey"`
+	Signature string `json:"signature"`
+}
+
+// ================== Signing ==================
+
+func voteSigningBytes(v Vote) []byte {
+	e := NewEncoder(tagVoteSigning)
+	e.String(ChainID)
+	e.Uint64(uint64(v.Type))
+	e.Int(v.Height)
+	e.Int(v.Round)
+	e.Hex(v.BlockHash)
+	e.String(v.Validator)
+	return e.Bytes()
+}
+
+func proposalSigningBytes(p Proposal) []byte {
+	e := NewEncoder(tagProposalSigning)
+	e.String(ChainID)
+	e.Int(p.Height)
+	e.Int(p.Round)
+	e.Hex(p.Block.Hash)
+	e.String(p.Proposer)
+	e.Hex(p.VRFProof)
+	return e.Bytes()
+}
+
+func SignVote(w *wallet.Wallet, typ VoteType, height, round int, blockHash string) Vote {
+	v := Vote{Type: typ, Height: height, Round: round, BlockHash: blockHash, Validator: w.AddressEd, PubKey: hex.EncodeToString(w.PubEd)}
+	v.Signature = hex.EncodeToString(w.SignEd(voteSigningBytes(v)))
+	return v
+}
+
+func SignProposal(w *wallet.Wallet, height, round int, b Block, vrfProof string) Proposal {
+	p := Proposal{Height: height, Round: round, Block: b, Proposer: w.AddressEd, VRFProof: vrfProof, PubKey: hex.EncodeToString(w.PubEd)}
+	p.Signature = hex.EncodeToString(w.SignEd(proposalSigningBytes(p)))
+	return p
+}
+
+// verifySigner: pubkey harus menurunkan addr & signature valid atas msg.
+func verifySigner(addr, pubHex, sigHex string, msg []byte) error {
+	pub, err := hex.DecodeString(pubHex)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("pubkey invalid")
+	}
+	if wallet.AddressFromPubKey(pub) != addr {
+		return fmt.Errorf("pubkey bukan milik %s", addr)
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil || !ed25519.Verify(pub, msg, sig) {
+		return fmt.Errorf("signature salah")
+	}
+	return nil
+}
+
+// VerifyVote: signature & kepemilikan pubkey (keanggotaan validator dicek VoteSet).
+func VerifyVote(v Vote) error {
+	if v.Type != VotePrevote && v.Type != VotePrecommit {
+		return fmt.Errorf("%w: tipe %d", ErrVoteSignature, v.Type)
+	}
+	if err := verifySigner(v.Validator, v.PubKey, v.Signature, voteSigningBytes(v)); err != nil {
+		return fmt.Errorf("%w: %v", ErrVoteSignature, err)
+	}
+	return nil
+}
+
+// VerifyProposal: signature proposer round (undian VRF dicek consensus dengan
+// seed height tsb, isi blok divalidasi ValidateBlock).
+func VerifyProposal(p Proposal) error {
+	if err := verifySigner(p.Proposer, p.PubKey, p.Signature, proposalSigningBytes(p)); err != nil {
+		return fmt.Errorf("proposal %d/%d: %v", p.Height, p.Round, err)
+	}
+	return nil
+}
+
+// ================== Codec ==================
+
+func EncodeVote(v Vote) []byte {
+	e := NewEncoder(tagVote)
+	e.Uint64(uint64(v.Type))
+	e.Int(v.Height)
+	e.Int(v.Round)
+	e.Hex(v.BlockHash)
+	e.String(v.Validator)
+	e.Hex(v.PubKey)
+	e.Hex(v.Signature)
+	return e.Bytes()
+}
+
+func DecodeVote(data []byte) (Vote, error) {
+	d := NewDecoder(data, tagVote)
+	var v Vote
+	v.Type = VoteType(d.Uint64())
+	v.Height = d.Int()
+	v.Round = d.Int()
+	v.BlockHash = d.Hex()
+	v.Validator = d.String()
+	v.PubKey = d.Hex()
+	v.Signature = d.Hex()
+	return v, d.Finish()
+}
+
+func EncodeProposal(p Proposal) []byte {
+	e := NewEncoder(tagProposal)
+	e.Int(p.Height)
+	e.Int(p.Round)
+	e.String(p.Proposer)
+	e.Hex(p.VRFProof)
+	e.Hex(p.PubKey)
+	e.Hex(p.Signature)
+	e.Raw(EncodeBlock(p.Block))
+	return e.Bytes()
+}
+
+func DecodeProposal(data []byte) (Proposal, error) {
+	d := NewDecoder(data, tagProposal)
+	var p Proposal
+	p.Height = d.Int()
+	p.Round = d.Int()
+	p.Proposer = d.String()
+	p.VRFProof = d.Hex()
+	p.PubKey = d.Hex()
+	p.Signature = d.Hex()
+	raw := d.Raw()
+	if err := d.Finish(); err != nil {
+		return Proposal{}, err
+	}
+	b, err := DecodeBlock(raw)
+	if err != nil {
+		return Proposal{}, fmt.Errorf("block: %w", err)
+	}
+	p.Block = b
+	return p, nil
+}
+
+// ================== Stake-weighted tally ==================
+
+// HasTwoThirds: power > 2/3 total (perbandingan 128-bit, bebas overflow).
+func HasTwoThirds(power, total Amount) bool {
+	hi1, lo1 := bits.Mul64(uint64(power), 3)
+	hi2, lo2 := bits.Mul64(uint64(total), 2)
+	return hi1 > hi2 || (hi1 == hi2 && lo1 > lo2)
+}
+
+// HasOneThird: power > 1/3 total.
+func HasOneThird(power, total Amount) bool {
+	hi, lo := bits.Mul64(uint64(power), 3)
+	return hi > 0 || lo > uint64(total)
+}
+
+// TotalStake: voting power seluruh validator.
+func TotalStake(validators []ValidatorDef) Amount {
+	var total Amount
+	for _, v := range validators {
+		total, _ = total.Add(v.Stake) // dibatasi MaxSupply
+	}
+	return total
+}
+
+// VoteSet: vote satu (height, round, tipe) dengan bobot stake validator.
+// Tidak thread-safe; pemanggil memegang lock.
+type VoteSet struct {
+	Height int
+	Round  int
+	Type   VoteType
+
+	total  Amount
+	sum    Amount
+	stakes map[string]Amount
+	votes  map[string]Vote
+	byHash map[string]Amount
+}
+
+func NewVoteSet(height, round int, typ VoteType, validators []ValidatorDef) *VoteSet {
+	s := &VoteSet{
+		Height: height, Round: round, Type: typ,
+		total:  TotalStake(validators),
+		stakes: make(map[string]Amount, len(validators)),
+		votes:  map[string]Vote{},
+		byHash: map[string]Amount{},
+	}
+	for _, v := range validators {
+		s.stakes[v.Address] = v.Stake
+	}
+	return s
+}
+
+// Add memverifikasi & mencatat vote. added=false untuk duplikat identik.
+// ErrVoteConflict mengembalikan vote baru yang bertentangan (bukti equivocation).
+func (s *VoteSet) Add(v Vote) (added bool, err error) {
+	if v.Height != s.Height || v.Round != s.Round || v.Type != s.Type {
+		return false, ErrVoteMismatch
+	}
+	stake, ok := s.stakes[v.Validator]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrVoteValidator, v.Validator)
+	}
+	if prev, ok := s.votes[v.Validator]; ok {
+		if prev.BlockHash == v.BlockHash {
+			return false, nil
+		}
+		if VerifyVote(v) != nil {
+			return false, ErrVoteSignature
+		}
+		return false, fmt.Errorf("%w: %s %.12s vs %.12s", ErrVoteConflict, v.Validator, prev.BlockHash, v.BlockHash)
+	}
+	if err := VerifyVote(v); err != nil {
+		return false, err
+	}
+	s.votes[v.Validator] = v
+	s.byHash[v.BlockHash], _ = s.byHash[v.BlockHash].Add(stake)
+	s.sum, _ = s.sum.Add(stake)
+	return true, nil
+}
+
+func (s *VoteSet) Total() Amount { return s.total }
+
+// VoteOf: vote validator yang sudah tercatat.
+func (s *VoteSet) VoteOf(addr string) (Vote, bool) {
+	v, ok := s.votes[addr]
+	return v, ok
+}
+
+// Power: stake yang memilih hash ("" = nil).
+func (s *VoteSet) Power(hash string) Amount { return s.byHash[hash] }
+
+// Voted: stake semua vote yang masuk.
+func (s *VoteSet) Voted() Amount { return s.sum }
+
+// TwoThirdsMajority: hash (boleh "" = nil) yang didukung > 2/3 stake.
+func (s *VoteSet) TwoThirdsMajority() (string, bool) {
+	for hash, power := range s.byHash {
+		if HasTwoThirds(power, s.total) {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// HasTwoThirdsAny: > 2/3 stake sudah vote (apa pun isinya).
+func (s *VoteSet) HasTwoThirdsAny() bool { return HasTwoThirds(s.sum, s.total) }
+
+// Votes: urut address validator (deterministik).
+func (s *VoteSet) Votes() []Vote {
+	out := make([]Vote, 0, len(s.votes))
+	for _, v := range s.votes {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Validator < out[j].Validator })
+	return out
+}
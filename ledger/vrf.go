@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/soden46/hyperlux-chain/crypto"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// ================== VRF proposer selection ==================
+//
+// Tiap validator mengevaluasi ECVRF (RFC 9381) atas (seed epoch, height, round)
+// dengan kunci Ed25519-nya. Validator terpilih bila
+//
+//	beta mod totalStake < stake × (round+1)
+//
+// jadi peluang menang sebanding stake (round 0) dan naik tiap view change
+// sampai semua validator eligible. Bila lebih dari satu menang, beta terkecil
+// diutamakan. Pemenang menaruh proof di header blok; node lain memverifikasi
+// proof + undian tanpa perlu private key siapa pun.
+//
+// Seed epoch berantai dari output VRF blok sebelumnya (bukan hash blok), jadi
+// proposer tidak bisa "grinding" isi blok untuk mengatur undian berikutnya.
+
+// MaxProposerRounds: batas round undian. Peluang naik tiap round, jadi tanpa
+// batas proof round besar selalu lolos; round blok juga tidak boleh melebihi
+// round sertifikat commit-nya.
+const MaxProposerRounds = 64
+
+type VRFHeader struct {
+	Seed  string `json:"seed"`  // seed epoch blok ini
+	Round int    `json:"round"` // round BFT saat proposer lolos undian
+	Proof string `json:"proof"` // ECVRF pi (80 byte)
+}
+
+// EpochSeed: seed undian untuk blok height di atas parent. Dalam satu epoch
+// seed diwarisi dari parent; di awal epoch (atau parent tanpa VRF: genesis /
+// blok lama) diturunkan ulang dari output VRF parent.
+func EpochSeed(parent Block, height int) string {
+	return epochSeedFor(ChainID, Params.EpochLength, parent, height)
+}
+
+// epochSeedFor: EpochSeed dengan chain ID & panjang epoch tertentu (snapshot
+// diverifikasi sebelum parameternya dipasang).
+func epochSeedFor(chainID string, epochLength int, parent Block, height int) string {
+	if parent.VRF != nil && parent.Index/epochLength == height/epochLength {
+		return parent.VRF.Seed
+	}
+	e := NewEncoder(tagVRFSeed)
+	e.String(chainID)
+	e.Int(height / epochLength)
+	if parent.VRF != nil {
+		e.Hex(parent.VRF.Seed)
+		e.Raw(BlockVRFOutput(parent))
+	} else {
+		e.Hex(parent.Hash) // transisi sekali dari blok tanpa VRF
+	}
+	sum := sha256.Sum256(e.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+func vrfAlpha(seed string, height, round int) []byte {
+	e := NewEncoder(tagVRFAlpha)
+	e.String(ChainID)
+	e.Hex(seed)
+	e.Int(height)
+	e.Int(round)
+	return e.Bytes()
+}
+
+// ProposerEligible: undian stake-weighted dari output VRF.
+func ProposerEligible(beta []byte, stake, total Amount, round int) bool {
+	if stake == 0 || total == 0 || round < 0 || round >= MaxProposerRounds {
+		return false
+	}
+	y := new(big.Int).Mod(new(big.Int).SetBytes(beta), new(big.Int).SetUint64(uint64(total)))
+	limit := new(big.Int).Mul(new(big.Int).SetUint64(uint64(stake)), big.NewInt(int64(round)+1))
+	return y.Cmp(limit) < 0
+}
+
+// VRFPriority: true bila beta a mengalahkan b (lebih kecil) saat ada beberapa pemenang.
+func VRFPriority(a, b []byte) bool { return bytes.Compare(a, b) < 0 }
+
+// ProveProposer: VRF validator w untuk (seed, height, round) & apakah lolos undian.
+func ProveProposer(w *wallet.Wallet, seed string, height, round int, validators []ValidatorDef) (VRFHeader, []byte, bool, error) {
+	pi, err := crypto.VRFProve(w.PrivEd, vrfAlpha(seed, height, round))
+	if err != nil {
+		return VRFHeader{}, nil, false, err
+	}
+	beta, _ := crypto.VRFProofToHash(pi)
+	var stake Amount
+	for _, v := range validators {
+		if v.Address == w.AddressEd {
+			stake = v.Stake
+		}
+	}
+	hdr := VRFHeader{Seed: seed, Round: round, Proof: hex.EncodeToString(pi)}
+	return hdr, beta, ProposerEligible(beta, stake, TotalStake(validators), round), nil
+}
+
+// VerifyProposerVRF: pubkey milik addr, proof valid untuk (seed, height, round)
+// dan addr lolos undian stake-weighted di set validator. Mengembalikan beta.
+func VerifyProposerVRF(addr, pubHex, seed string, height, round int, proofHex string, validators []ValidatorDef) ([]byte, error) {
+	pub, err := hex.DecodeString(pubHex)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("pubkey invalid")
+	}
+	if wallet.AddressFromPubKey(pub) != addr {
+		return nil, fmt.Errorf("pubkey bukan milik %s", addr)
+	}
+	pi, err := hex.DecodeString(proofHex)
+	if err != nil {
+		return nil, fmt.Errorf("proof VRF bukan hex")
+	}
+	beta, err := crypto.VRFVerify(pub, vrfAlpha(seed, height, round), pi)
+	if err != nil {
+		return nil, err
+	}
+	var stake Amount
+	for _, v := range validators {
+		if v.Address == addr {
+			stake = v.Stake
+		}
+	}
+	if !ProposerEligible(beta, stake, TotalStake(validators), round) {
+		return nil, fmt.Errorf("%s (stake %d) tidak lolos undian VRF round %d", addr, stake, round)
+	}
+	return beta, nil
+}
+
+// verifyBlockVRF: header VRF blok di atas parent (dipanggil validateBlock).
+func verifyBlockVRF(b, parent Block, validators []ValidatorDef) error {
+	if b.VRF == nil {
+		return fmt.Errorf("blok tanpa proof VRF")
+	}
+	if want := EpochSeed(parent, b.Index); b.VRF.Seed != want {
+		return fmt.Errorf("seed VRF %.12s ≠ seed epoch %.12s", b.VRF.Seed, want)
+	}
+	if b.Commit != nil && b.VRF.Round > b.Commit.Round {
+		return fmt.Errorf("round VRF %d > round commit %d", b.VRF.Round, b.Commit.Round)
+	}
+	_, err := VerifyProposerVRF(b.Proposer, b.ProposerKey, b.VRF.Seed, b.Index, b.VRF.Round, b.VRF.Proof, validators)
+	return err
+}
+
+// BlockVRFOutput: beta proposer blok (nil untuk genesis / blok tanpa VRF).
+func BlockVRFOutput(b Block) []byte {
+	if b.VRF == nil {
+		return nil
+	}
+	beta, err := crypto.VRFProofToHash(hexBytes(b.VRF.Proof))
+	if err != nil {
+		return nil
+	}
+	return beta
+}
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+// ================== Codec ==================
+
+func encodeVRF(e *Encoder, v *VRFHeader) {
+	e.Hex(v.Seed)
+	e.Int(v.Round)
+	e.Hex(v.Proof)
+}
+
+func decodeVRF(d *Decoder) *VRFHeader {
+	v := &VRFHeader{}
+	v.Seed = d.Hex()
+	v.Round = d.Int()
+	v.Proof = d.Hex()
+	return v
+}
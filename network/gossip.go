@@ -1,7 +1,7 @@
 package network
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/soden46/hyperlux-chain/ledger"
@@ -20,14 +20,12 @@ func StartGossip() {
 		for {
 			msg, err := sb.Next(nil)
 			if err != nil { return }
-			var blk ledger.Block
-			if err := json.Unmarshal(msg.Data, &blk); err != nil {
+			if fromSelf(msg) {
 				continue
 			}
-			// TODO: verifikasi blok (validator sig, root, dll)
-			ledger.Blockchain = append(ledger.Blockchain, blk)
-			ledger.SaveBlockchain()
-			fmt.Printf("📥 Received block %d from peer (hash=%.12s...)\n", blk.Index, blk.Hash)
+			if blk, err := ReceiveBlock(msgSender(msg), msg.Data); err == nil {
+				fmt.Printf("📥 Received block %d from peer (hash=%.12s...)\n", blk.Index, blk.Hash)
+			}
 		}
 	}()
 
@@ -46,4 +44,69 @@ func StartGossip() {
 			}
 		}
 	}()
+
+	sv, sp := getConsensusSubs()
+	go func() {
+		if sv == nil { return }
+		for {
+			msg, err := sv.Next(nil)
+			if err != nil { return }
+			if fromSelf(msg) {
+				continue
+			}
+			v, err := ledger.DecodeVote(msg.Data)
+			if err == nil {
+				err = ledger.VerifyVote(v)
+			}
+			if err != nil {
+				ReportMisbehaviour(msgSender(msg), err)
+				continue
+			}
+			select {
+			case voteBus <- v:
+			default:
+			}
+		}
+	}()
+
+	go func() {
+		if sp == nil { return }
+		for {
+			msg, err := sp.Next(nil)
+			if err != nil { return }
+			if fromSelf(msg) {
+				continue
+			}
+			p, err := ledger.DecodeProposal(msg.Data)
+			if err == nil {
+				err = ledger.VerifyProposal(p)
+			}
+			if err != nil {
+				ReportMisbehaviour(msgSender(msg), err)
+				continue
+			}
+			select {
+			case proposalBus <- p:
+			default:
+			}
+		}
+	}()
+}
+
+// ReceiveBlock: decode & impor blok gossip dari peer. Blok rusak / invalid
+// dicatat sebagai misbehaviour; duplikat atau blok di depan head (kita yang
+// tertinggal) dikembalikan sebagai error tanpa menghukum peer.
+func ReceiveBlock(from string, data []byte) (ledger.Block, error) {
+	blk, err := ledger.DecodeBlock(data)
+	if err != nil {
+		ReportMisbehaviour(from, err)
+		return blk, err
+	}
+	if err := ledger.ImportBlock(blk); err != nil {
+		if !errors.Is(err, ledger.ErrBlockKnown) && !errors.Is(err, ledger.ErrBlockAhead) {
+			ReportMisbehaviour(from, err)
+		}
+		return blk, err
+	}
+	return blk, nil
 }
@@ -4,7 +4,6 @@ import (
 	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -24,6 +23,21 @@ type MiniBlock struct {
 
 var miniBlockBus = make(chan MiniBlock, 8192)
 
+func encodeMiniHeader(e *ledger.Encoder, mb MiniBlock) {
+	e.String(mb.Slot)
+	e.Int64(mb.Timestamp)
+	e.Hex(mb.MerkleRoot)
+	e.String(mb.ProducerID)
+	e.Hex(mb.PubKey)
+}
+
+// miniBlockSigningHash: hash header yang ditandatangani producer.
+func miniBlockSigningHash(mb MiniBlock) [32]byte {
+	e := ledger.NewEncoder(ledger.TagMiniBlockHeader)
+	encodeMiniHeader(e, mb)
+	return sha256.Sum256(e.Bytes())
+}
+
 func SignMiniBlock(w *wallet.Wallet, slot string, txs []ledger.Transaction) (MiniBlock, error) {
 	mb := MiniBlock{
 		Slot:       slot,
@@ -33,19 +47,20 @@ func SignMiniBlock(w *wallet.Wallet, slot string, txs []ledger.Transaction) (Min
 		ProducerID: GetNodeID(),
 		PubKey:     hex.EncodeToString(w.PubEd),
 	}
-	header := fmt.Sprintf("%s|%d|%s|%s|%s", mb.Slot, mb.Timestamp, mb.MerkleRoot, mb.ProducerID, mb.PubKey)
-	h := sha256.Sum256([]byte(header))
+	h := miniBlockSigningHash(mb)
 	sig := w.SignEd(h[:])
 	mb.Signature = hex.EncodeToString(sig)
 	return mb, nil
 }
 
 func VerifyMiniBlock(mb MiniBlock) bool {
-	header := fmt.Sprintf("%s|%d|%s|%s|%s", mb.Slot, mb.Timestamp, mb.MerkleRoot, mb.ProducerID, mb.PubKey)
-	h := sha256.Sum256([]byte(header))
+	h := miniBlockSigningHash(mb)
 	pub, err1 := hex.DecodeString(mb.PubKey)
 	sig, err2 := hex.DecodeString(mb.Signature)
-	if err1 != nil || err2 != nil {
+	if err1 != nil || err2 != nil || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	if ledger.ComputeMerkleRoot(mb.TxList) != mb.MerkleRoot {
 		return false
 	}
 	return ed25519.Verify(ed25519.PublicKey(pub), h[:], sig)
@@ -77,11 +92,59 @@ loop:
 }
 
 func encodeMiniBlock(mb MiniBlock) []byte {
-	b, _ := json.Marshal(mb)
-	return b
+	e := ledger.NewEncoder(ledger.TagMiniBlock)
+	encodeMiniHeader(e, mb)
+	e.Hex(mb.Signature)
+	e.Len(len(mb.TxList))
+	for _, tx := range mb.TxList {
+		e.Raw(ledger.EncodeTransaction(tx))
+	}
+	return e.Bytes()
 }
 
 func decodeMiniBlock(b []byte) (MiniBlock, error) {
+	d := ledger.NewDecoder(b, ledger.TagMiniBlock)
 	var mb MiniBlock
-	return mb, json.Unmarshal(b, &mb)
+	mb.Slot = d.String()
+	mb.Timestamp = d.Int64()
+	mb.MerkleRoot = d.Hex()
+	mb.ProducerID = d.String()
+	mb.PubKey = d.Hex()
+	mb.Signature = d.Hex()
+	n := d.Len()
+	for i := 0; i < n && d.Err() == nil; i++ {
+		raw := d.Raw()
+		if d.Err() != nil {
+			break
+		}
+		tx, err := ledger.DecodeTransaction(raw)
+		if err != nil {
+			return MiniBlock{}, fmt.Errorf("tx %d: %w", i, err)
+		}
+		mb.TxList = append(mb.TxList, tx)
+	}
+	return mb, d.Finish()
+}
+
+// ================= Consensus messages (proposal & vote BFT) =================
+// Hanya pesan dari peer yang masuk bus; pesan validator lokal langsung
+// diproses engine consensus lalu dipublish ke peer.
+
+var (
+	voteBus     = make(chan ledger.Vote, 8192)
+	proposalBus = make(chan ledger.Proposal, 256)
+)
+
+// Votes: vote dari peer yang signature-nya sudah dicek.
+func Votes() <-chan ledger.Vote { return voteBus }
+
+// Proposals: proposal dari peer yang signature-nya sudah dicek.
+func Proposals() <-chan ledger.Proposal { return proposalBus }
+
+func PublishVote(v ledger.Vote) {
+	_ = PublishVoteP2P(v) // no-op jika P2P dimatikan
+}
+
+func PublishProposal(p ledger.Proposal) {
+	_ = PublishProposalP2P(p)
 }
@@ -258,8 +258,8 @@ func shardFor(key string) int {
 
 // ================= QoS / Gateway =================
 
-func isValidator(addr string) (bool, int) {
-	for _, v := range ledger.Validators {
+func isValidator(addr string) (bool, ledger.Amount) {
+	for _, v := range ledger.GetValidators() {
 		if v.Address == addr {
 			return true, v.Stake
 		}
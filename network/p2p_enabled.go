@@ -4,7 +4,6 @@ package network
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -23,14 +22,20 @@ var (
 	PubSub      *pubsub.PubSub
 	TopicBlocks *pubsub.Topic
 	TopicMini   *pubsub.Topic
+	TopicVotes  *pubsub.Topic
+	TopicProps  *pubsub.Topic
 
 	subBlocks *pubsub.Subscription
 	subMini   *pubsub.Subscription
+	subVotes  *pubsub.Subscription
+	subProps  *pubsub.Subscription
 )
 
 const (
 	topicBlocks = "hyperlux/blocks/v1"
 	topicMini   = "hyperlux/miniblocks/v1"
+	topicVotes  = "hyperlux/votes/v1"
+	topicProps  = "hyperlux/proposals/v1"
 )
 
 func StartP2P(bootstrap []string) error {
@@ -64,6 +69,11 @@ func StartP2P(bootstrap []string) error {
 	if subBlocks, err = TopicBlocks.Subscribe(); err != nil { return err }
 	if subMini, err = TopicMini.Subscribe(); err != nil { return err }
 
+	if TopicVotes, err = ps.Join(topicVotes); err != nil { return err }
+	if TopicProps, err = ps.Join(topicProps); err != nil { return err }
+	if subVotes, err = TopicVotes.Subscribe(); err != nil { return err }
+	if subProps, err = TopicProps.Subscribe(); err != nil { return err }
+
 	return nil
 }
 
@@ -87,8 +97,7 @@ func BroadcastBlock(block ledger.Block) error {
 	if Host == nil || PubSub == nil || TopicBlocks == nil {
 		return errors.New("p2p not ready")
 	}
-	data, _ := json.Marshal(block)
-	if err := TopicBlocks.Publish(context.Background(), data); err != nil {
+	if err := TopicBlocks.Publish(context.Background(), ledger.EncodeBlock(block)); err != nil {
 		return err
 	}
 	fmt.Printf("🌐 Broadcasted block %d (hash=%.12s...)\n", block.Index, block.Hash)
@@ -102,6 +111,34 @@ func PublishMiniBlockP2P(mb MiniBlock) error {
 	return TopicMini.Publish(context.Background(), encodeMiniBlock(mb))
 }
 
+func PublishVoteP2P(v ledger.Vote) error {
+	if Host == nil || PubSub == nil || TopicVotes == nil {
+		return errors.New("p2p not ready")
+	}
+	return TopicVotes.Publish(context.Background(), ledger.EncodeVote(v))
+}
+
+func PublishProposalP2P(p ledger.Proposal) error {
+	if Host == nil || PubSub == nil || TopicProps == nil {
+		return errors.New("p2p not ready")
+	}
+	return TopicProps.Publish(context.Background(), ledger.EncodeProposal(p))
+}
+
+// msgSender: peer yang meneruskan pesan ke kita (untuk skor misbehaviour).
+func msgSender(m *pubsub.Message) string {
+	return m.ReceivedFrom.String()
+}
+
+// fromSelf: gossipsub juga mengantar pesan kita sendiri ke subscription lokal.
+func fromSelf(m *pubsub.Message) bool {
+	return Host != nil && m.ReceivedFrom == Host.ID()
+}
+
 func getSubs() (*pubsub.Subscription, *pubsub.Subscription) {
 	return subBlocks, subMini
 }
+
+func getConsensusSubs() (*pubsub.Subscription, *pubsub.Subscription) {
+	return subVotes, subProps
+}
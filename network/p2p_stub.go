@@ -16,14 +16,26 @@ func BroadcastBlock(_ ledger.Block) error { return nil }
 
 func PublishMiniBlockP2P(_ MiniBlock) error { return nil }
 
+func PublishVoteP2P(_ ledger.Vote) error { return nil }
+
+func PublishProposalP2P(_ ledger.Proposal) error { return nil }
+
 func getSubs() (interface{ Next(interface{}) (*msg, error) }, interface{ Next(interface{}) (*msg, error) }) {
 	// dummy to satisfy StartGossip(); we won't use it.
 	return nil, nil
 }
 
+func getConsensusSubs() (interface{ Next(interface{}) (*msg, error) }, interface{ Next(interface{}) (*msg, error) }) {
+	return nil, nil
+}
+
 // minimal type to satisfy interface in stub; not used.
 type msg struct {
 	Data []byte
 }
 
+func msgSender(_ *msg) string { return "" }
+
+func fromSelf(_ *msg) bool { return false }
+
 var ErrP2PDisabled = errors.New("p2p disabled")
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -51,3 +52,28 @@ func TouchSelfAddr(addr string) {
 	regMu.Unlock()
 	savePeers()
 }
+
+// ================= Peer misbehaviour =================
+
+var (
+	misbehaviourMu sync.Mutex
+	misbehaviour   = map[string]int{} // peerID → jumlah pelanggaran
+)
+
+// ReportMisbehaviour mencatat pesan invalid dari peer (blok ditolak, payload rusak, dsb.).
+func ReportMisbehaviour(peerID string, reason error) {
+	if peerID == "" {
+		peerID = "unknown"
+	}
+	misbehaviourMu.Lock()
+	misbehaviour[peerID]++
+	count := misbehaviour[peerID]
+	misbehaviourMu.Unlock()
+	fmt.Printf("🚨 Peer misbehaviour: %s (count=%d): %v\n", peerID, count, reason)
+}
+
+func MisbehaviourCount(peerID string) int {
+	misbehaviourMu.Lock()
+	defer misbehaviourMu.Unlock()
+	return misbehaviour[peerID]
+}
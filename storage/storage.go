@@ -4,5 +4,5 @@ package storage
 
 // func InitDB() {
 // 	fmt.Println("Storage engine initialized")
-// 	// TODO: wrap LevelDB/BadgerDB, implement pruning & sharding
+// 	// TODO: wrap LevelDB/BadgerDB, implement sharding (pruning: ledger/prune.go)
 // }
@@ -0,0 +1,195 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func TestBech32mVectors(t *testing.T) {
+	// BIP-350 test vectors
+	valid := []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	}
+	for _, s := range valid {
+		if _, _, err := wallet.Bech32mDecode(s); err != nil {
+			t.Errorf("valid %q: %v", s, err)
+		}
+	}
+	invalid := []string{
+		"\x201xj0phk",
+		"\x7f1g6xzxy",
+		"\x801vctc34",
+		"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4",
+		"qyrz8wqd2c9m",
+		"1qyrz8wqd2c9m",
+		"y1b0jsk6g",
+		"lt1igcx5c0",
+		"in1muywd",
+		"mm1crxm3i",
+		"au1s5cgom",
+		"M1VUXWEZ",
+		"16plkw9",
+		"1p2gdwpf",
+		"A12UEL5L", // bech32 (BIP-173), bukan bech32m
+		"A1lqfn3a", // huruf campuran
+	}
+	for _, s := range invalid {
+		if _, _, err := wallet.Bech32mDecode(s); err == nil {
+			t.Errorf("invalid %q accepted", s)
+		}
+	}
+}
+
+func TestAddressRejectsTyposAndWrongPrefix(t *testing.T) {
+	w := wallet.GenerateWallet()
+	addr := w.AddressEd
+	if err := wallet.ValidateAddress(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := wallet.ValidateAddress(strings.ToUpper(addr)); err != nil {
+		t.Fatalf("uppercase address: %v", err)
+	}
+	if wallet.AddressFromPubKey(w.PubEd) != addr {
+		t.Fatal("address derivation mismatch")
+	}
+
+	// setiap salah ketik satu karakter di bagian data ditangkap checksum
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	sep := strings.LastIndexByte(addr, '1')
+	for i := sep + 1; i < len(addr); i++ {
+		for _, c := range charset {
+			if byte(c) == addr[i] {
+				continue
+			}
+			typo := addr[:i] + string(c) + addr[i+1:]
+			if err := wallet.ValidateAddress(typo); !errors.Is(err, wallet.ErrAddressChecksum) {
+				t.Fatalf("typo at %d (%q): err = %v", i, typo, err)
+			}
+		}
+	}
+
+	// HRP ikut checksum: prefix diganti = checksum salah; bech32m valid dengan
+	// HRP lain ditolak sebagai prefix salah
+	if err := wallet.ValidateAddress("hlx" + addr[3:]); !errors.Is(err, wallet.ErrAddressChecksum) {
+		t.Fatalf("swapped prefix err = %v", err)
+	}
+	if err := wallet.ValidateAddress("abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"); !errors.Is(err, wallet.ErrAddressHRP) {
+		t.Fatalf("foreign hrp err = %v", err)
+	}
+	if err := wallet.ValidateAddress(w.LegacyAddressEd()); !errors.Is(err, wallet.ErrAddressLegacy) {
+		t.Fatalf("legacy address err = %v", err)
+	}
+}
+
+func TestLegacyMigrationIsOnChainTx(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	legacy := w[1].LegacyAddressEd()
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 100},
+		{Address: legacy, Stake: 40},
+	})
+	appendStateBlock(t)
+	setBalance(legacy, 5000)
+
+	// belum tercatat di tabel migrasi genesis: migrasi ditolak
+	if _, err := ledger.NewMigrationTx(w[1]); err == nil {
+		t.Fatal("migration accepted for a legacy address without a recorded pubkey")
+	}
+	withLegacyKeys(t, map[string]string{legacy: hex.EncodeToString(w[1].PubEd)})
+
+	// legacy pubkey hanya boleh mengirim ke address barunya
+	spoof := ledger.NewTransaction(w[1], w[2].AddressEd, 10)
+	spoof.From, spoof.Nonce = legacy, 1
+	spoof.Signature = hex.EncodeToString(w[1].SignEd(ledger.TxSigningBytes(spoof)))
+	if err := ledger.ValidateAndAddToMempool(spoof); err == nil {
+		t.Fatal("legacy sender to a third address accepted")
+	}
+
+	tx, err := ledger.NewMigrationTx(w[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.From != legacy || tx.To != w[1].AddressEd || tx.Amount+tx.Fee != 5000 {
+		t.Fatalf("migration tx = %+v", tx)
+	}
+	if err := ledger.ValidateAndAddToMempool(tx); err != nil {
+		t.Fatal(err)
+	}
+	// belum ada perubahan state sebelum TX masuk blok
+	if ledger.GetBalance(legacy) != 5000 || ledger.GetBalance(w[1].AddressEd) != 0 {
+		t.Fatal("state changed before the migration tx was committed")
+	}
+
+	b := committedBlock(t, w[0], w[0])
+	if len(b.Transactions) != 1 {
+		t.Fatalf("block has %d txs", len(b.Transactions))
+	}
+	if err := ledger.ImportBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if ledger.GetBalance(legacy) != 0 || ledger.GetBalance(w[1].AddressEd) != tx.Amount {
+		t.Fatalf("balances after migration: legacy %d, new %d", ledger.GetBalance(legacy), ledger.GetBalance(w[1].AddressEd))
+	}
+	var addrs []string
+	for _, v := range ledger.Validators {
+		addrs = append(addrs, v.Address)
+	}
+	if len(addrs) != 2 || addrs[1] != w[1].AddressEd {
+		t.Fatalf("validators after migration = %v", addrs)
+	}
+	if ledger.ComputeStateRoot() != b.StateRoot {
+		t.Fatal("state after import does not match the block state root")
+	}
+}
+
+func withLegacyKeys(t *testing.T, keys map[string]string) {
+	old := ledger.LegacyKeys
+	ledger.LegacyKeys = keys
+	t.Cleanup(func() { ledger.LegacyKeys = old })
+}
+
+func TestLegacyMigrationRejectsKeySharingPrefix(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	attacker := testWallets(1)[0]
+	legacy := attacker.LegacyAddressEd()
+	setBalance(legacy, 5000)
+
+	// pubkey yang tercatat untuk address legacy: 4 byte awal sama dengan kunci
+	// attacker (address legacy identik), sisanya berbeda
+	recorded := append([]byte(nil), attacker.PubEd...)
+	recorded[len(recorded)-1] ^= 0xff
+	if wallet.LegacyAddressFromPubKey(recorded) != legacy {
+		t.Fatal("recorded key must share the legacy address")
+	}
+	withLegacyKeys(t, map[string]string{legacy: hex.EncodeToString(recorded)})
+
+	if _, err := ledger.NewMigrationTx(attacker); err == nil {
+		t.Fatal("migration tx built for a key that only shares the legacy prefix")
+	}
+	tx := ledger.NewTransaction(attacker, attacker.AddressEd, 4000)
+	tx.From, tx.Nonce = legacy, 1
+	tx.Signature = hex.EncodeToString(attacker.SignEd(ledger.TxSigningBytes(tx)))
+	if !ledger.VerifyTransaction(tx) {
+		t.Fatal("attacker tx must carry a valid signature")
+	}
+	if ledger.SenderMatchesPubKey(tx) {
+		t.Fatal("sender matched a key that only shares the legacy prefix")
+	}
+	if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+		t.Fatal("migration by a key sharing the legacy prefix accepted")
+	}
+}
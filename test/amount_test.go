@@ -0,0 +1,189 @@
+package test
+
+import (
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+var (
+	fuzzWalletsMu sync.Mutex
+	fuzzWallets   []*wallet.Wallet
+)
+
+// testWallets: n wallet yang di-cache antar test (dibuat seperlunya).
+func testWallets(n int) []*wallet.Wallet {
+	fuzzWalletsMu.Lock()
+	defer fuzzWalletsMu.Unlock()
+	for len(fuzzWallets) < n {
+		fuzzWallets = append(fuzzWallets, wallet.GenerateWallet())
+	}
+	return fuzzWallets[:n]
+}
+
+// txWithNonce: TX valid dari w dengan nonce eksplisit (beberapa TX per sender dalam satu batch).
+func txWithNonce(w *wallet.Wallet, to string, amount ledger.Amount, nonce int) ledger.Transaction {
+	tx := ledger.NewTransaction(w, to, amount)
+	tx.Nonce = nonce
+	tx.Signature = hex.EncodeToString(w.SignEd(ledger.TxSigningBytes(tx)))
+	return tx
+}
+
+func sumBalances() *big.Int {
+	total := new(big.Int)
+	ledger.BalanceMu.RLock()
+	defer ledger.BalanceMu.RUnlock()
+	for _, b := range ledger.Balances {
+		total.Add(total, new(big.Int).SetUint64(uint64(b)))
+	}
+	return total
+}
+
+func TestRejectsZeroAmount(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 10000
+
+	tx := ledger.NewTransaction(w[0], w[1].AddressEd, 0)
+	if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+		t.Fatal("zero-amount tx accepted")
+	}
+}
+
+func TestRejectsWrappingAmount(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 10000
+
+	// amount+fee yang wrap ke angka kecil di uint64 (setara amount negatif di int lama)
+	tx := ledger.NewTransaction(w[0], w[1].AddressEd, 1)
+	tx.Amount = ledger.Amount(math.MaxUint64 - uint64(tx.Fee) + 1)
+	tx.Signature = hex.EncodeToString(w[0].SignEd(ledger.TxSigningBytes(tx)))
+	if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+		t.Fatal("wrapping amount accepted into mempool")
+	}
+	if accepted := ledger.ProcessTxListParallel([]ledger.Transaction{tx}); len(accepted) != 0 {
+		t.Fatal("executor accepted wrapping amount")
+	}
+	if got := ledger.GetBalance(w[0].AddressEd); got != 10000 {
+		t.Fatalf("sender balance = %d, want 10000", got)
+	}
+}
+
+func TestParseAmountRejectsSigns(t *testing.T) {
+	for _, s := range []string{"-1", "+1", "", "1.5", "9223372036854775808", "abc"} {
+		if _, err := ledger.ParseAmount(s); err == nil {
+			t.Errorf("ParseAmount(%q) accepted", s)
+		}
+	}
+	if _, err := ledger.ParsePositiveAmount("0"); err == nil {
+		t.Error("ParsePositiveAmount(0) accepted")
+	}
+}
+
+func FuzzAmountArithmetic(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(math.MaxUint64), uint64(1))
+	f.Add(uint64(1)<<63, uint64(1)<<63)
+	f.Add(uint64(12345), uint64(678))
+	f.Fuzz(func(t *testing.T, a, b uint64) {
+		want := new(big.Int).Add(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+		sum, err := ledger.Amount(a).Add(ledger.Amount(b))
+		if want.IsUint64() != (err == nil) {
+			t.Fatalf("Add(%d,%d) err=%v, overflow=%v", a, b, err, !want.IsUint64())
+		}
+		if err == nil {
+			if uint64(sum) != want.Uint64() {
+				t.Fatalf("Add(%d,%d)=%d", a, b, sum)
+			}
+			if back, err := sum.Sub(ledger.Amount(b)); err != nil || uint64(back) != a {
+				t.Fatalf("Sub roundtrip: %d, %v", back, err)
+			}
+		}
+
+		if _, err := ledger.Amount(a).Sub(ledger.Amount(b)); (err == nil) != (a >= b) {
+			t.Fatalf("Sub(%d,%d) err=%v", a, b, err)
+		}
+
+		prod := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+		if _, err := ledger.Amount(a).Mul(ledger.Amount(b)); prod.IsUint64() != (err == nil) {
+			t.Fatalf("Mul(%d,%d) err=%v", a, b, err)
+		}
+
+		if b > 0 && a <= b {
+			q := ledger.Amount(a).MulDiv(ledger.Amount(a), ledger.Amount(b))
+			wantQ := new(big.Int).Div(new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(a)), new(big.Int).SetUint64(b))
+			if uint64(q) != wantQ.Uint64() {
+				t.Fatalf("MulDiv(%d,%d,%d)=%d want %s", a, a, b, q, wantQ)
+			}
+		}
+	})
+}
+
+func FuzzParseAmount(f *testing.F) {
+	for _, s := range []string{"0", "1", "-1", "+5", "18446744073709551615", " 42 ", "0x10"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		a, err := ledger.ParseAmount(s)
+		if err != nil {
+			return
+		}
+		if a > ledger.MaxSupply {
+			t.Fatalf("ParseAmount(%q)=%d > MaxSupply", s, a)
+		}
+		if back, err := ledger.ParseAmount(strconv.FormatUint(uint64(a), 10)); err != nil || back != a {
+			t.Fatalf("roundtrip %q → %d → %d (%v)", s, a, back, err)
+		}
+	})
+}
+
+// FuzzTransferConservesSupply: total saldo sebelum = sesudah + fee TX yang diterima
+// (fee baru dikredit ke proposer saat blok dibuat), berapa pun amount-nya.
+func FuzzTransferConservesSupply(f *testing.F) {
+	f.Add(uint64(1000), uint64(500), uint64(10), uint64(200), uint64(math.MaxUint64), uint64(0))
+	f.Add(uint64(ledger.MaxSupply), uint64(0), uint64(ledger.MaxSupply), uint64(1), uint64(1), uint64(1))
+	f.Add(uint64(0), uint64(0), uint64(1), uint64(1), uint64(1), uint64(1))
+	f.Fuzz(func(t *testing.T, bal0, bal1, a0, a1, a2, a3 uint64) {
+		resetLedgerState()
+		w := testWallets(3)
+		// alokasi awal tetap dalam MaxSupply (invariant genesis)
+		bal0 %= uint64(ledger.MaxSupply) / 2
+		bal1 %= uint64(ledger.MaxSupply) / 2
+		ledger.Balances[w[0].AddressEd] = ledger.Amount(bal0)
+		ledger.Balances[w[1].AddressEd] = ledger.Amount(bal1)
+
+		txs := []ledger.Transaction{
+			txWithNonce(w[0], w[1].AddressEd, ledger.Amount(a0), 1),
+			txWithNonce(w[0], w[2].AddressEd, ledger.Amount(a1), 2),
+			txWithNonce(w[1], w[0].AddressEd, ledger.Amount(a2), 1),
+			txWithNonce(w[1], w[1].AddressEd, ledger.Amount(a3), 2),
+		}
+
+		before := sumBalances()
+		accepted := ledger.ProcessTxListParallel(txs)
+
+		fees := new(big.Int)
+		for _, tx := range accepted {
+			if tx.Amount == 0 {
+				t.Fatal("zero-amount tx accepted")
+			}
+			fees.Add(fees, new(big.Int).SetUint64(uint64(tx.Fee)))
+		}
+		after := sumBalances()
+		if got := new(big.Int).Add(after, fees); got.Cmp(before) != 0 {
+			t.Fatalf("supply not conserved: before=%s after=%s fees=%s", before, after, fees)
+		}
+		for _, addr := range []string{w[0].AddressEd, w[1].AddressEd, w[2].AddressEd} {
+			if new(big.Int).SetUint64(uint64(ledger.GetBalance(addr))).Cmp(before) > 0 {
+				t.Fatalf("balance %s exceeds total supply", addr)
+			}
+		}
+	})
+}
@@ -0,0 +1,330 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/consensus"
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func TestVoteSetStakeWeightedThreshold(t *testing.T) {
+	w := testWallets(4)
+	vals := []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 50},
+		{Address: w[1].AddressEd, Stake: 30},
+		{Address: w[2].AddressEd, Stake: 20},
+	}
+	set := ledger.NewVoteSet(5, 1, ledger.VotePrecommit, vals)
+	hash := "ab12"
+
+	// 50 dari 100 belum cukup walau sudah 1/3 jumlah validator
+	if _, err := set.Add(ledger.SignVote(w[0], ledger.VotePrecommit, 5, 1, hash)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := set.TwoThirdsMajority(); ok {
+		t.Fatal("50/100 stake must not reach 2/3")
+	}
+	if added, err := set.Add(ledger.SignVote(w[0], ledger.VotePrecommit, 5, 1, hash)); added || err != nil {
+		t.Fatalf("duplicate vote added=%v err=%v", added, err)
+	}
+	if _, err := set.Add(ledger.SignVote(w[0], ledger.VotePrecommit, 5, 1, "")); !errors.Is(err, ledger.ErrVoteConflict) {
+		t.Fatalf("conflicting vote err = %v", err)
+	}
+	if _, err := set.Add(ledger.SignVote(w[3], ledger.VotePrecommit, 5, 1, hash)); !errors.Is(err, ledger.ErrVoteValidator) {
+		t.Fatalf("non-validator vote err = %v", err)
+	}
+	if _, err := set.Add(ledger.SignVote(w[1], ledger.VotePrevote, 5, 1, hash)); !errors.Is(err, ledger.ErrVoteMismatch) {
+		t.Fatalf("prevote in precommit set err = %v", err)
+	}
+	forged := ledger.SignVote(w[1], ledger.VotePrecommit, 5, 1, "cd34")
+	forged.BlockHash = hash
+	if _, err := set.Add(forged); !errors.Is(err, ledger.ErrVoteSignature) {
+		t.Fatalf("forged vote err = %v", err)
+	}
+
+	// 50 + 20 = 70 > 2/3 dari 100
+	if _, err := set.Add(ledger.SignVote(w[2], ledger.VotePrecommit, 5, 1, hash)); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := set.TwoThirdsMajority(); !ok || got != hash || set.Power(hash) != 70 {
+		t.Fatalf("majority = %q %v (power %d)", got, ok, set.Power(hash))
+	}
+	if ledger.HasTwoThirds(2, 3) || !ledger.HasTwoThirds(3, 4) {
+		t.Fatal("2/3 threshold must be strict")
+	}
+
+	v := ledger.SignVote(w[1], ledger.VotePrevote, 7, 0, "")
+	got, err := ledger.DecodeVote(ledger.EncodeVote(v))
+	if err != nil || got != v || ledger.VerifyVote(got) != nil {
+		t.Fatalf("vote roundtrip = %+v (%v)", got, err)
+	}
+}
+
+// advanceEpoch: blok kosong sampai head = blok terakhir epoch, jadi set dari
+// ledger.Validators berlaku mulai blok berikutnya.
+func advanceEpoch(t *testing.T) {
+	t.Helper()
+	for {
+		head := appendStateBlock(t)
+		if ledger.EpochOf(head.Index+1) != ledger.EpochOf(head.Index) {
+			return
+		}
+	}
+}
+
+func withBFTValidators(t *testing.T, vals []ledger.ValidatorDef, local ...*wallet.Wallet) {
+	oldVals, oldWallets, oldCfg := ledger.Validators, ledger.ValidatorWallets, consensus.BFT
+	ledger.Validators = vals
+	ledger.ValidatorWallets = map[string]*wallet.Wallet{}
+	for _, w := range local {
+		ledger.ValidatorWallets[w.AddressEd] = w
+	}
+	// set baru hanya berlaku di boundary epoch
+	advanceEpoch(t)
+	consensus.BFT = consensus.BFTConfig{
+		ProposeTimeout:   30 * time.Millisecond,
+		PrevoteTimeout:   30 * time.Millisecond,
+		PrecommitTimeout: 30 * time.Millisecond,
+		TimeoutDelta:     5 * time.Millisecond,
+		MaxRounds:        20,
+	}
+	t.Cleanup(func() {
+		ledger.Validators, ledger.ValidatorWallets, consensus.BFT = oldVals, oldWallets, oldCfg
+		advanceEpoch(t)
+	})
+}
+
+func TestBFTCommitsWithLocalStakeQuorum(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	// w[3] (30%) tidak punya node: round yang dia pimpin timeout → view change
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 70},
+		{Address: w[3].AddressEd, Stake: 30},
+	}, w[2])
+
+	setBalance(w[0].AddressEd, 10000)
+	if err := ledger.ValidateAndAddToMempool(ledger.NewTransaction(w[0], w[1].AddressEd, 10)); err != nil {
+		t.Fatal(err)
+	}
+	before := ledger.ChainHeight()
+	consensus.CommitBlock()
+
+	head, _ := ledger.HeadBlock()
+	if ledger.ChainHeight() != before+1 || head.Proposer != w[2].AddressEd || len(head.Transactions) != 1 {
+		t.Fatalf("head %d by %s with %d txs, want new block by local validator", head.Index, head.Proposer, len(head.Transactions))
+	}
+	if ledger.GetBalance(w[1].AddressEd) != 10 || ledger.GetMempoolSize() != 0 {
+		t.Fatal("block committed without applying its tx")
+	}
+	if c := head.Commit; c == nil || len(c.Sigs) != 1 || c.Sigs[0].Validator != w[2].AddressEd || c.Power != 70 || c.Total != 100 {
+		t.Fatalf("commit certificate = %+v", head.Commit)
+	}
+}
+
+func TestBFTRejectsWithoutTwoThirdsStake(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	// 50% stake lokal: semua round gagal mencapai > 2/3
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 50},
+		{Address: w[3].AddressEd, Stake: 50},
+	}, w[2])
+	consensus.BFT.MaxRounds = 3
+
+	setBalance(w[0].AddressEd, 10000)
+	if err := ledger.ValidateAndAddToMempool(ledger.NewTransaction(w[0], w[1].AddressEd, 10)); err != nil {
+		t.Fatal(err)
+	}
+	before := ledger.ChainHeight()
+	consensus.CommitBlock()
+
+	if ledger.ChainHeight() != before || ledger.GetMempoolSize() != 1 || ledger.GetBalance(w[0].AddressEd) != 10000 {
+		t.Fatal("block committed or state changed without a stake quorum")
+	}
+}
+
+func TestCommitCertificateRequiredOnImport(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 70},
+		{Address: w[3].AddressEd, Stake: 30},
+	})
+	appendStateBlock(t) // set validator tercatat di history sebelum blok berikutnya
+
+	vrf := proposerTicket(t, w[2])
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], vrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ImportBlock(b); !errors.Is(err, ledger.ErrBlockCommit) {
+		t.Fatalf("import without certificate err = %v", err)
+	}
+
+	precommits := ledger.NewVoteSet(b.Index, vrf.Round, ledger.VotePrecommit, ledger.Validators)
+	if _, err := precommits.Add(ledger.SignVote(w[3], ledger.VotePrecommit, b.Index, vrf.Round, b.Hash)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := precommits.CommitCert(b.Hash); err == nil {
+		t.Fatal("certificate from 30/100 stake must fail")
+	}
+	if _, err := precommits.Add(ledger.SignVote(w[2], ledger.VotePrecommit, b.Index, vrf.Round, b.Hash)); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := precommits.CommitCert(b.Hash)
+	if err != nil || len(cert.Sigs) != 2 || cert.Power != 100 {
+		t.Fatalf("cert = %+v (%v)", cert, err)
+	}
+
+	// sertifikat dimanipulasi: buang signature mayoritas, naikkan stake, salah blok
+	tampered := []func(c *ledger.CommitCert){
+		func(c *ledger.CommitCert) { // hanya w[3] (30)
+			for _, sig := range c.Sigs {
+				if sig.Validator == w[3].AddressEd {
+					c.Sigs, c.Power = []ledger.CommitSig{sig}, sig.Stake
+				}
+			}
+		},
+		func(c *ledger.CommitCert) { c.Sigs[0].Stake += 50; c.Power += 50 },
+		func(c *ledger.CommitCert) { c.Round++ },
+	}
+	for i, mutate := range tampered {
+		c := *cert
+		c.Sigs = append([]ledger.CommitSig(nil), cert.Sigs...)
+		mutate(&c)
+		bad := b
+		bad.Commit = &c
+		if err := ledger.ImportBlock(bad); !errors.Is(err, ledger.ErrBlockCommit) {
+			t.Fatalf("tampered cert %d err = %v", i, err)
+		}
+	}
+
+	b.Commit = cert
+	decoded, err := ledger.DecodeBlock(ledger.EncodeBlock(b))
+	if err != nil || decoded.Commit == nil || len(decoded.Commit.Sigs) != 2 {
+		t.Fatalf("decoded commit = %+v (%v)", decoded.Commit, err)
+	}
+	if err := ledger.ImportBlock(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.VerifyStoredCommit(b.Index); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := ledger.GetBlockCommit(b.Index); !ok || c == nil || c.BlockHash != b.Hash {
+		t.Fatalf("stored commit = %+v", c)
+	}
+}
+
+// committedBlock: blok proposal w di atas head dengan sertifikat commit dari
+// precommit semua wallet di signers.
+func committedBlock(t *testing.T, w *wallet.Wallet, signers ...*wallet.Wallet) ledger.Block {
+	t.Helper()
+	vrf := proposerTicket(t, w)
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w, vrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := ledger.NewVoteSet(b.Index, vrf.Round, ledger.VotePrecommit, ledger.ActiveValidators(b.Index))
+	for _, s := range signers {
+		if _, err := set.Add(ledger.SignVote(s, ledger.VotePrecommit, b.Index, vrf.Round, b.Hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.Commit, err = set.CommitCert(b.Hash); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestConcurrentImportAppliesBlockOnce(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	appendStateBlock(t)
+
+	setBalance(w[0].AddressEd, 10000)
+	if err := ledger.ValidateAndAddToMempool(ledger.NewTransaction(w[0], w[1].AddressEd, 10)); err != nil {
+		t.Fatal(err)
+	}
+	b := committedBlock(t, w[2], w[2])
+	before := ledger.ChainHeight()
+
+	// jalur commit BFT & gossip mengimpor blok yang sama bersamaan
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ledger.ImportBlock(b)
+		}(i)
+	}
+	wg.Wait()
+	imported := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			imported++
+		case !errors.Is(err, ledger.ErrBlockKnown):
+			t.Fatalf("concurrent import err = %v", err)
+		}
+	}
+	if imported != 1 || ledger.ChainHeight() != before+1 || ledger.GetBalance(w[1].AddressEd) != 10 {
+		t.Fatalf("imported %d times, height %d, balance %d", imported, ledger.ChainHeight(), ledger.GetBalance(w[1].AddressEd))
+	}
+
+	// blok di depan head: node tertinggal, bukan blok invalid
+	ahead := b
+	ahead.Index += 2
+	if err := ledger.ImportBlock(ahead); !errors.Is(err, ledger.ErrBlockAhead) {
+		t.Fatalf("block ahead of head err = %v", err)
+	}
+}
+
+// Proposer, consensus & RPC membaca registry validator dan supply di goroutine
+// lain selagi ImportBlock menggantinya (dicek oleh go test -race).
+func TestImportDoesNotRaceStateReaders(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	appendStateBlock(t)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = len(ledger.GetValidators())
+			_, _ = ledger.Supply()
+			_ = ledger.CurrentEpochSet()
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		if err := ledger.ImportBlock(committedBlock(t, w[2], w[2])); err != nil {
+			close(done)
+			wg.Wait()
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	if vals := ledger.GetValidators(); len(vals) != 1 || vals[0].Address != w[2].AddressEd {
+		t.Fatalf("validators after import = %+v", vals)
+	}
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func TestBlockSignatureBindsProposerAndHash(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(2)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 70},
+		{Address: w[1].AddressEd, Stake: 30},
+	})
+	appendStateBlock(t)
+
+	vrf := proposerTicket(t, w[0])
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[0], vrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precommits := ledger.NewVoteSet(b.Index, vrf.Round, ledger.VotePrecommit, ledger.Validators)
+	for _, v := range w {
+		if _, err := precommits.Add(ledger.SignVote(v, ledger.VotePrecommit, b.Index, vrf.Round, b.Hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.Commit, err = precommits.CommitCert(b.Hash); err != nil {
+		t.Fatal(err)
+	}
+	if b.Proposer != w[0].AddressEd || b.ProposerKey != hex.EncodeToString(w[0].PubEd) {
+		t.Fatalf("block proposer = %s key %s", b.Proposer, b.ProposerKey)
+	}
+	if err := ledger.VerifyBlockSignature(b); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := hex.DecodeString(b.Hash)
+
+	// validator lain menandatangani blok atas nama proposer
+	foreign := b
+	foreign.ProposerKey = hex.EncodeToString(w[1].PubEd)
+	foreign.Signature = hex.EncodeToString(w[1].SignEd(hash))
+	// satu byte signature dibalik
+	sig, _ := hex.DecodeString(b.Signature)
+	sig[10] ^= 0x01
+	flipped := b
+	flipped.Signature = hex.EncodeToString(sig)
+	noKey := b
+	noKey.ProposerKey = ""
+	for name, bad := range map[string]ledger.Block{
+		"pubkey of another validator": foreign,
+		"flipped signature byte":      flipped,
+		"missing pubkey":              noKey,
+	} {
+		if err := ledger.VerifyBlockSignature(bad); err == nil {
+			t.Fatalf("%s: signature accepted", name)
+		}
+		if err := ledger.ImportBlock(bad); !errors.Is(err, ledger.ErrBlockSignature) {
+			t.Fatalf("%s: import err = %v, want ErrBlockSignature", name, err)
+		}
+	}
+
+	// proposer menandatangani ulang hash lain: signature valid atas hash
+	// tsb, tapi hash bukan hash header → ditolak saat validasi blok
+	resigned := b
+	resigned.Hash = hexOf(0x5a, 32)
+	other, _ := hex.DecodeString(resigned.Hash)
+	resigned.Signature = hex.EncodeToString(w[0].SignEd(other))
+	// header diubah, hash & signature lama dipertahankan
+	edited := b
+	edited.Timestamp++
+	for name, bad := range map[string]ledger.Block{
+		"re-signed different hash": resigned,
+		"edited header":            edited,
+	} {
+		if err := ledger.ImportBlock(bad); !errors.Is(err, ledger.ErrBlockHash) {
+			t.Fatalf("%s: import err = %v, want ErrBlockHash", name, err)
+		}
+	}
+	if err := ledger.VerifyBlockSignature(resigned); err != nil {
+		t.Fatalf("re-signed hash: %v", err)
+	}
+
+	if err := ledger.ImportBlock(b); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+const freshLedgerEnv = "HYPERLUX_TEST_FRESH_LEDGER"
+
+// inFreshLedger: LevelDB global hanya bisa dibuka sekali per proses, jadi test
+// yang butuh DB kosong dijalankan ulang di proses anak (cwd sementara baru).
+// Di proses anak return true (body test berjalan); di proses induk menunggu
+// anak selesai lalu return false dengan stdout anak di out.
+func inFreshLedger(t *testing.T) (child bool, out string) {
+	t.Helper()
+	if os.Getenv(freshLedgerEnv) == "1" {
+		dir, err := os.MkdirTemp("", "hyperlux-fresh-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		return true, ""
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.count=1", "-test.v")
+	cmd.Env = append(os.Environ(), freshLedgerEnv+"=1")
+	data, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(data), "--- PASS: "+t.Name()) {
+		t.Fatalf("fresh ledger run: %v\n%s", err, data)
+	}
+	return false, string(data)
+}
+
+func TestLegacyBlockchainMigrationAndCache(t *testing.T) {
+	if child, _ := inFreshLedger(t); !child {
+		return
+	}
+	const n = 1100 // > kapasitas LRU (1024)
+	legacy := make([]ledger.Block, n)
+	prev := "0"
+	var err error
+	for i := range legacy {
+		var txs []ledger.Transaction
+		if i%100 == 1 {
+			txs = []ledger.Transaction{{From: "alice", To: "bob", Amount: 5, Nonce: i}}
+		}
+		b := ledger.Block{Index: i, Timestamp: int64(1700000000 + i), PrevHash: prev, Transactions: txs}
+		b.MerkleRoot = ledger.ComputeMerkleRoot(txs)
+		b.Hash = fmt.Sprintf("%064x", 0x1e9ac7000+i)
+		// bentuk kanonik (slice kosong, bukan nil) = yang dibaca balik dari store
+		if b, err = ledger.DecodeBlock(ledger.EncodeBlock(b)); err != nil {
+			t.Fatal(err)
+		}
+		legacy[i], prev = b, b.Hash
+	}
+	blob, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := ledger.DB()
+	if err := db.Put([]byte("blockchain"), blob, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.LoadBlockchain()
+	if ledger.ChainHeight() != n {
+		t.Fatalf("height after migration = %d, want %d", ledger.ChainHeight(), n)
+	}
+	if ok, _ := db.Has([]byte("blockchain"), nil); ok {
+		t.Fatal("legacy blob not removed")
+	}
+	for _, want := range legacy {
+		byHeight, ok := ledger.GetBlockByHeight(want.Index)
+		if !ok || !reflect.DeepEqual(byHeight, want) {
+			t.Fatalf("block %d by height = %+v", want.Index, byHeight)
+		}
+		byHash, ok := ledger.GetBlockByHash(want.Hash)
+		if !ok || !reflect.DeepEqual(byHash, want) {
+			t.Fatalf("block %d by hash = %+v", want.Index, byHash)
+		}
+	}
+	if head, ok := ledger.HeadBlock(); !ok || head.Hash != legacy[n-1].Hash {
+		t.Fatalf("head = %+v", head)
+	}
+
+	// rusak blok 0 langsung di DB: selama masih di cache tetap terbaca,
+	// setelah tergusur blok lain (LRU) dibaca ulang dari DB dan gagal
+	key := make([]byte, 4+8)
+	copy(key, "b/h/")
+	binary.BigEndian.PutUint64(key[4:], 0)
+	if _, ok := ledger.GetBlockByHeight(0); !ok {
+		t.Fatal("block 0 missing")
+	}
+	if err := db.Put(key, []byte{0xff}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := ledger.GetBlockByHeight(0); !ok || b.Hash != legacy[0].Hash {
+		t.Fatal("block 0 not served from cache")
+	}
+	for i := 1; i <= 1024; i++ {
+		ledger.GetBlockByHeight(i)
+	}
+	if _, ok := ledger.GetBlockByHeight(0); ok {
+		t.Fatal("block 0 still cached after 1024 newer reads")
+	}
+	// blok yang baru dipakai tetap di cache walau DB-nya dihapus
+	binary.BigEndian.PutUint64(key[4:], 1024)
+	if err := db.Delete(key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := ledger.GetBlockByHeight(1024); !ok || b.Hash != legacy[1024].Hash {
+		t.Fatal("recently used block evicted")
+	}
+}
@@ -0,0 +1,181 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+var tempLedgerOnce sync.Once
+
+// initTempLedger: LevelDB global dibuka sekali per proses, di direktori sementara.
+func initTempLedger(t *testing.T) {
+	t.Helper()
+	tempLedgerOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "hyperlux-test-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		ledger.InitLedger()
+	})
+}
+
+func withParams(t *testing.T, p ledger.ChainParams) {
+	old := ledger.Params
+	ledger.Params = p
+	t.Cleanup(func() { ledger.Params = old })
+}
+
+func TestSelectBlockTxsRespectsTxCount(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	p := ledger.DefaultChainParams()
+	p.MaxBlockTxs = 3
+	withParams(t, p)
+
+	var txs []ledger.Transaction
+	for n := 1; n <= 5; n++ {
+		txs = append(txs, txWithNonce(w[0], w[1].AddressEd, 1, n))
+	}
+	if got := ledger.SelectBlockTxs(txs, w[0].AddressEd); len(got) != 3 {
+		t.Fatalf("selected %d txs, want 3", len(got))
+	}
+}
+
+func TestSelectBlockTxsRespectsBytes(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(3)
+	tx := txWithNonce(w[0], w[2].AddressEd, 1, 1)
+	txSize := 4 + len(ledger.EncodeTransaction(tx))
+
+	p := ledger.DefaultChainParams()
+	withParams(t, p)
+	// cukup untuk overhead + 2 tx
+	overhead := len(ledger.EncodeBlock(ledger.Block{})) + 400
+	ledger.Params.MaxBlockBytes = overhead + 2*txSize
+
+	candidates := []ledger.Transaction{
+		txWithNonce(w[0], w[2].AddressEd, 1, 1),
+		txWithNonce(w[0], w[2].AddressEd, 1, 2),
+		txWithNonce(w[0], w[2].AddressEd, 1, 3),
+		txWithNonce(w[1], w[2].AddressEd, 1, 1),
+	}
+	got := ledger.SelectBlockTxs(candidates, w[0].AddressEd)
+	if len(got) == 0 || len(got) > 2 {
+		t.Fatalf("selected %d txs, want 1..2", len(got))
+	}
+	blk := ledger.Block{Proposer: w[0].AddressEd, Transactions: got}
+	if size := len(ledger.EncodeBlock(blk)); size > ledger.Params.MaxBlockBytes {
+		t.Fatalf("block size %d > limit %d", size, ledger.Params.MaxBlockBytes)
+	}
+	// nonce per sender tetap menyambung
+	last := map[string]int{}
+	for _, tx := range got {
+		if tx.Nonce != last[tx.From]+1 {
+			t.Fatalf("nonce gap for %s: %d after %d", tx.From, tx.Nonce, last[tx.From])
+		}
+		last[tx.From] = tx.Nonce
+	}
+}
+
+func TestValidateBlockRejectsOversizedBlock(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(2)
+	p := ledger.DefaultChainParams()
+	p.MaxBlockTxs = 1
+	withParams(t, p)
+
+	head, ok := ledger.HeadBlock()
+	if !ok {
+		t.Fatal("no genesis")
+	}
+	b := ledger.Block{
+		Index:    head.Index + 1,
+		PrevHash: head.Hash,
+		Transactions: []ledger.Transaction{
+			txWithNonce(w[0], w[1].AddressEd, 1, 1),
+			txWithNonce(w[0], w[1].AddressEd, 1, 2),
+		},
+	}
+	if err := ledger.ValidateBlock(b); !errors.Is(err, ledger.ErrBlockTooLarge) {
+		t.Fatalf("err=%v, want ErrBlockTooLarge", err)
+	}
+}
+
+func TestFullBlockPassesValidation(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	p := ledger.Params
+	p.MaxBlockBytes = 8 * 1024
+	withParams(t, p)
+
+	setBalance(w[0].AddressEd, 1_000_000)
+	for n := 1; n <= 60; n++ {
+		if err := ledger.ValidateAndAddToMempool(txWithNonce(w[0], w[1].AddressEd, 1, n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], proposerTicket(t, w[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Transactions) == 0 || len(b.Transactions) == 60 {
+		t.Fatalf("block has %d txs, want it filled by bytes", len(b.Transactions))
+	}
+	// header + TX penuh; entry PoH (mixin per TX) di luar batas ukuran
+	if size := len(ledger.EncodeBlock(b)); size <= p.MaxBlockBytes {
+		t.Fatalf("encoded block %d byte, want PoH entries beyond %d", size, p.MaxBlockBytes)
+	}
+	if err := ledger.ValidateBlock(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFullBlockWithEvidencePassesValidation(t *testing.T) {
+	// bukti yang tidak masuk blok tetap di pool: jalankan di proses sendiri
+	if child, _ := inFreshLedger(t); !child {
+		return
+	}
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 70},
+		{Address: w[3].AddressEd, Stake: 30},
+	})
+	head := appendStateBlock(t)
+	a := ledger.SignVote(w[3], ledger.VotePrevote, head.Index, 0, hexOf(0xaa, 32))
+	b := ledger.SignVote(w[3], ledger.VotePrevote, head.Index, 0, hexOf(0xbb, 32))
+	if err := ledger.ReportEquivocation(a, b); err != nil {
+		t.Fatal(err)
+	}
+	p := ledger.Params
+	p.MaxBlockBytes = 8 * 1024
+	withParams(t, p)
+
+	setBalance(w[0].AddressEd, 1_000_000)
+	for n := 1; n <= 60; n++ {
+		if err := ledger.ValidateAndAddToMempool(txWithNonce(w[0], w[1].AddressEd, 1, n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blk, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], proposerTicket(t, w[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blk.Evidence) != 1 || len(blk.Transactions) == 0 || len(blk.Transactions) == 60 {
+		t.Fatalf("block has %d evidence and %d txs, want 1 and filled by bytes", len(blk.Evidence), len(blk.Transactions))
+	}
+	if err := ledger.ValidateBlock(blk); err != nil {
+		t.Fatal(err)
+	}
+}
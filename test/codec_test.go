@@ -0,0 +1,154 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func hexOf(b byte, n int) string { return strings.Repeat(fmt.Sprintf("%02x", b), n) }
+
+func TestTransactionCodecRoundTrip(t *testing.T) {
+	w := []*wallet.Wallet{wallet.GenerateWallet(), wallet.GenerateWallet()}
+	for _, tx := range []ledger.Transaction{
+		ledger.NewTransaction(w[0], w[1].AddressEd, 7),
+		ledger.NewTransactionWithOpts(w[0], w[1].AddressEd, 9, ledger.TxOptions{Memo: "invoice #42", ValidFor: 50}),
+	} {
+		enc := ledger.EncodeTransaction(tx)
+		got, err := ledger.DecodeTransaction(enc)
+		if err != nil || !reflect.DeepEqual(got, tx) || !bytes.Equal(ledger.EncodeTransaction(got), enc) {
+			t.Fatalf("roundtrip = %+v (%v), want %+v", got, err, tx)
+		}
+	}
+}
+
+func TestBlockCodecRoundTrip(t *testing.T) {
+	w := []*wallet.Wallet{wallet.GenerateWallet(), wallet.GenerateWallet()}
+	second := ledger.NewTransaction(w[0], w[1].AddressEd, 8)
+	second.Nonce = 2
+	txs := []ledger.Transaction{ledger.NewTransaction(w[0], w[1].AddressEd, 7), second}
+	signed := ledger.NewBlock(5, txs, hexOf(0xaa, 32), hexOf(0xbb, 32), w[0])
+	unsigned := ledger.NewBlock(0, []ledger.Transaction{}, "0", hexOf(0xcc, 32), nil)
+
+	for name, b := range map[string]ledger.Block{"signed": signed, "unsigned genesis": unsigned} {
+		enc := ledger.EncodeBlock(b)
+		got, err := ledger.DecodeBlock(enc)
+		if err != nil || !reflect.DeepEqual(got, b) || !bytes.Equal(ledger.EncodeBlock(got), enc) {
+			t.Fatalf("%s: roundtrip = %+v (%v)", name, got, err)
+		}
+	}
+}
+
+// Jumlah TX dari wire hanya dibatasi sisa byte: decoder tidak boleh
+// mempraalokasi n × sizeof(Transaction) dari payload gossip.
+func TestDecodeDoesNotPreallocateFromWireCount(t *testing.T) {
+	const k = 1 << 20
+	withCount := func(enc []byte) []byte {
+		// hitungan TX (4 byte terakhir encoding tanpa TX) diganti k, diikuti k byte nol
+		out := append([]byte(nil), enc...)
+		binary.BigEndian.PutUint32(out[len(out)-4:], k)
+		return append(out, make([]byte, k)...)
+	}
+	block := withCount(ledger.EncodeBlock(ledger.NewBlock(1, nil, hexOf(0xaa, 32), hexOf(0xbb, 32), nil)))
+	list := withCount(ledger.EncodeTxList(nil))
+
+	for name, decode := range map[string]func() error{
+		"block":   func() error { _, err := ledger.DecodeBlock(block); return err },
+		"tx list": func() error { _, err := ledger.DecodeTxList(list); return err },
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := decode()
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Fatalf("%s: decoded %d empty transactions", name, k)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 4*k {
+			t.Fatalf("%s: decoding a %d-byte payload allocated %d bytes", name, k, alloc)
+		}
+	}
+}
+
+func TestCodecRejectsNonCanonicalEncoding(t *testing.T) {
+	w := []*wallet.Wallet{wallet.GenerateWallet(), wallet.GenerateWallet()}
+	tx := ledger.NewTransaction(w[0], w[1].AddressEd, 7)
+	enc := ledger.EncodeTransaction(tx)
+	// signature di akhir: flag (1) + panjang (4) + 64 byte
+	sigFlag := len(enc) - 69
+	if enc[sigFlag] != 0x01 {
+		t.Fatalf("unexpected signature layout (flag 0x%02x)", enc[sigFlag])
+	}
+
+	upper := tx
+	upper.Signature = strings.ToUpper(tx.Signature)
+	upperEnc := ledger.EncodeTransaction(upper) // hex bukan lowercase → flag 0x00 + string
+	lowerFlag0 := bytes.Replace(upperEnc, []byte(upper.Signature), []byte(tx.Signature), 1)
+
+	badFlag := bytes.Clone(enc)
+	badFlag[sigFlag] = 0x02
+
+	for name, data := range map[string][]byte{
+		"trailing byte":         append(bytes.Clone(enc), 0x00),
+		"uppercase hex flag 0":  upperEnc,
+		"lowercase hex flag 0":  lowerFlag0,
+		"bad hex flag":          badFlag,
+		"truncated length":      enc[:sigFlag+3],
+		"truncated body":        enc[:len(enc)-1],
+		"length beyond payload": append(bytes.Clone(enc[:sigFlag+1]), 0xff, 0xff, 0xff, 0xff),
+		"wrong tag":             append([]byte{0x10}, enc[1:]...),
+	} {
+		if _, err := ledger.DecodeTransaction(data); err == nil {
+			t.Errorf("%s: decoded without error", name)
+		}
+	}
+	if _, err := ledger.DecodeTransaction(upperEnc); !errors.Is(err, ledger.ErrNonCanonical) {
+		t.Fatalf("uppercase hex err = %v, want ErrNonCanonical", err)
+	}
+	// signature uppercase tetap valid secara kriptografi, tapi ditolak sebelum masuk mempool
+	if err := ledger.ValidateAndAddToMempool(upper); err == nil {
+		t.Fatal("tx with uppercase signature accepted")
+	}
+
+	b := ledger.NewBlock(1, []ledger.Transaction{tx}, hexOf(0xaa, 32), hexOf(0xbb, 32), w[0])
+	benc := ledger.EncodeBlock(b)
+	for name, data := range map[string][]byte{
+		"trailing byte":   append(bytes.Clone(benc), 0x07),
+		"truncated block": benc[:len(benc)-3],
+	} {
+		if _, err := ledger.DecodeBlock(data); err == nil {
+			t.Errorf("block %s: decoded without error", name)
+		}
+	}
+}
+
+func TestHeaderHashSeparatesVRFAndPoH(t *testing.T) {
+	w := testWallets(1)
+	b := ledger.NewBlock(5, nil, hexOf(0xaa, 32), hexOf(0xbb, 32), w[0])
+	// proof VRF non-hex disusun supaya byte-nya = ticks + root PoH di bawah:
+	// tanpa marker section, kedua header menghasilkan preimage yang sama
+	root := hexOf(0x04, 32)
+	proof := "abc\x01\x00\x00\x00\x20" + strings.Repeat("\x04", 32)
+	vrfOnly, pohOnly := b, b
+	vrfOnly.VRF = &ledger.VRFHeader{Seed: hexOf(0x01, 32), Round: 24, Proof: proof}
+	pohOnly.PoH = &ledger.PoHRecord{Hash: hexOf(0x01, 32), Hashes: 24, Ticks: 0x28616263, Root: root}
+
+	seen := map[string]string{}
+	for name, blk := range map[string]ledger.Block{"plain": b, "vrf": vrfOnly, "poh": pohOnly} {
+		h := blk.HeaderHash()
+		if prev, dup := seen[h]; dup {
+			t.Fatalf("%s and %s share header hash %s", name, prev, h)
+		}
+		seen[h] = name
+	}
+	if b.HeaderHash() != b.Hash {
+		t.Fatal("header hash of a block without optional sections changed")
+	}
+}
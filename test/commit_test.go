@@ -0,0 +1,280 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+// State yang ikut batch blok harus kembali utuh setelah "restart" (LoadAllData).
+func TestBlockCommitPersistsFullState(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	oldVals, oldTreasury, oldBurned := ledger.Validators, ledger.TreasuryBalance, ledger.BurnedSupply
+	t.Cleanup(func() {
+		ledger.Validators, ledger.TreasuryBalance, ledger.BurnedSupply = oldVals, oldTreasury, oldBurned
+	})
+	w := testWallets(3)
+
+	setBalance(w[0].AddressEd, 1234)
+	ledger.NonceTable[w[0].AddressEd] = 5
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 900}}
+	ledger.TreasuryBalance, ledger.BurnedSupply = 11, 22
+	b := appendStateBlock(t)
+
+	// simulasi proses baru: memori kosong, lalu load dari DB
+	resetLedgerState()
+	ledger.Validators = nil
+	ledger.TreasuryBalance, ledger.BurnedSupply = 0, 0
+	ledger.LoadAllData()
+
+	if ledger.GetBalance(w[0].AddressEd) != 1234 || ledger.GetNextNonce(w[0].AddressEd) != 6 {
+		t.Fatal("balances/nonces not persisted with block")
+	}
+	if len(ledger.Validators) != 1 || ledger.Validators[0].Stake != 900 {
+		t.Fatalf("validators = %+v", ledger.Validators)
+	}
+	if ledger.TreasuryBalance != 11 || ledger.BurnedSupply != 22 {
+		t.Fatalf("treasury/burned = %d/%d", ledger.TreasuryBalance, ledger.BurnedSupply)
+	}
+	if root := ledger.ComputeStateRoot(); root != b.StateRoot {
+		t.Fatalf("reloaded state root %.12s ≠ block %.12s", root, b.StateRoot)
+	}
+}
+
+func TestSuspensionAndEconomySurviveRestart(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	oldTreasury, oldBurned := ledger.TreasuryBalance, ledger.BurnedSupply
+	t.Cleanup(func() {
+		ledger.TreasuryBalance, ledger.BurnedSupply = oldTreasury, oldBurned
+		ledger.ValidatorStatusMu.Lock()
+		ledger.ValidatorStatus = map[string]*ledger.ValidatorRuntime{}
+		ledger.ValidatorStatusMu.Unlock()
+	})
+	w := testWallets(2)
+
+	ledger.SuspendValidator(w[0].AddressEd, ledger.ScopePropose, time.Hour)
+	ledger.SuspendValidator(w[1].AddressEd, ledger.ScopeAll, -time.Second) // sudah lewat
+	ledger.TreasuryBalance, ledger.BurnedSupply = 40, 60
+	if err := ledger.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.ValidatorStatusMu.Lock()
+	ledger.ValidatorStatus = map[string]*ledger.ValidatorRuntime{}
+	ledger.ValidatorStatusMu.Unlock()
+	ledger.TreasuryBalance, ledger.BurnedSupply = 0, 0
+	ledger.LoadAllData()
+
+	if !ledger.IsSuspended(w[0].AddressEd, ledger.ScopePropose) || ledger.IsSuspended(w[0].AddressEd, ledger.ScopeVote) {
+		t.Fatal("propose suspension not restored")
+	}
+	ledger.ValidatorStatusMu.RLock()
+	_, expired := ledger.ValidatorStatus[w[1].AddressEd]
+	ledger.ValidatorStatusMu.RUnlock()
+	if expired {
+		t.Fatal("expired suspension should not be persisted")
+	}
+	if ledger.TreasuryBalance != 40 || ledger.BurnedSupply != 60 {
+		t.Fatalf("treasury/burned = %d/%d", ledger.TreasuryBalance, ledger.BurnedSupply)
+	}
+}
+
+// State tersimpan tertinggal dari head (commit terputus): dipulihkan dari
+// history; bila root history tidak cocok, head dimundurkan ke blok yang cocok.
+func TestRecoverStateBehindHead(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	oldVals := ledger.Validators
+	t.Cleanup(func() { ledger.Validators = oldVals })
+	w := testWallets(2)
+	height := func(h int) []byte { return binary.BigEndian.AppendUint64(nil, uint64(h)) }
+
+	setBalance(w[0].AddressEd, 500)
+	b1 := appendStateBlock(t)
+	setBalance(w[0].AddressEd, 400)
+	setBalance(w[1].AddressEd, 100)
+	b2 := appendStateBlock(t)
+
+	// state di disk = state b1, state/height = b1, head = b2
+	setBalance(w[0].AddressEd, 500)
+	delete(ledger.Balances, w[1].AddressEd)
+	if err := ledger.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+	db := ledger.DB()
+	if err := db.Put([]byte("state/height"), height(b1.Index), nil); err != nil {
+		t.Fatal(err)
+	}
+	resetLedgerState()
+	ledger.LoadAllData()
+	if head, _ := ledger.HeadBlock(); head.Hash != b2.Hash || ledger.ComputeStateRoot() != b2.StateRoot {
+		t.Fatalf("head %d root %.12s, want state of block %d", head.Index, ledger.ComputeStateRoot(), b2.Index)
+	}
+	if ledger.GetBalance(w[1].AddressEd) != 100 {
+		t.Fatal("state not rebuilt from history")
+	}
+
+	// versi history b2 rusak: root tidak cocok → head mundur ke b1
+	bad := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, 999), 0)
+	if err := db.Put(append([]byte("h/a/"+w[0].AddressEd+"/"), height(b2.Index)...), bad, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("state/height"), height(b1.Index), nil); err != nil {
+		t.Fatal(err)
+	}
+	resetLedgerState()
+	ledger.LoadAllData()
+	if head, _ := ledger.HeadBlock(); head.Hash != b1.Hash || ledger.ComputeStateRoot() != b1.StateRoot {
+		t.Fatalf("head %d root %.12s, want rollback to block %d", head.Index, ledger.ComputeStateRoot(), b1.Index)
+	}
+	if _, ok := ledger.GetBlockByHash(b2.Hash); ok {
+		t.Fatal("rolled back block still indexed by hash")
+	}
+	if _, _, err := ledger.GetAccountAt(w[0].AddressEd, b2.Index); err == nil {
+		t.Fatal("history above the new head still served")
+	}
+	// chain bisa lanjut di atas head baru
+	if b := appendStateBlock(t); b.Index != b2.Index {
+		t.Fatalf("next block index %d, want %d", b.Index, b2.Index)
+	}
+}
+
+// Slash (sebelum genesis): stake terpotong, burned & suspend tersimpan bersama
+// (satu batch).
+func TestSlashPersistsStakeAndSuspensionTogether(t *testing.T) {
+	if child, _ := inFreshLedger(t); !child {
+		return
+	}
+	ledger.LoadAllData()
+	w := testWallets(1)
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[0].AddressEd, Stake: 1000}}
+
+	if err := ledger.ApplySlash(w[0].AddressEd, ledger.SlashParams{
+		Amount: 100, Kind: ledger.SlashKindDowntime, SuspendScope: ledger.ScopeAll, SuspendFor: time.Hour,
+	}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger.Validators, ledger.BurnedSupply = nil, 0
+	ledger.ValidatorStatusMu.Lock()
+	ledger.ValidatorStatus = map[string]*ledger.ValidatorRuntime{}
+	ledger.ValidatorStatusMu.Unlock()
+	ledger.LoadAllData()
+	if len(ledger.Validators) != 1 || ledger.Validators[0].Stake != 900 || ledger.BurnedSupply != 100 {
+		t.Fatalf("validators %+v burned %d after restart", ledger.Validators, ledger.BurnedSupply)
+	}
+	if !ledger.IsSuspended(w[0].AddressEd, ledger.ScopeAll) {
+		t.Fatal("suspension not persisted with the slash")
+	}
+}
+
+// Slash & airdrop manual mengubah state di luar blok: ditolak setelah genesis.
+func TestManualStateChangesRejectedOnStartedChain(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	oldVals, oldBurned := ledger.Validators, ledger.BurnedSupply
+	t.Cleanup(func() { ledger.Validators, ledger.BurnedSupply = oldVals, oldBurned })
+	w := testWallets(2)
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[0].AddressEd, Stake: 1000}}
+	root := ledger.ComputeStateRoot()
+
+	if err := ledger.SlashSafetyFault(w[0].AddressEd, 100, w[1].AddressEd, 1.0); !errors.Is(err, ledger.ErrChainStarted) {
+		t.Fatalf("slash err = %v, want ErrChainStarted", err)
+	}
+	if err := ledger.Airdrop(w[1].AddressEd, 500); !errors.Is(err, ledger.ErrChainStarted) {
+		t.Fatalf("airdrop err = %v, want ErrChainStarted", err)
+	}
+	if ledger.Validators[0].Stake != 1000 || ledger.GetBalance(w[1].AddressEd) != 0 || ledger.ComputeStateRoot() != root {
+		t.Fatal("rejected slash/airdrop changed state")
+	}
+}
+
+// Blok hasil import hanya menulis akun yang disentuhnya; root pohon inkremental
+// sama dengan root yang dibangun ulang dari state lengkap, juga setelah restart.
+func TestImportPersistsOnlyTouchedAccounts(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	const bystander = "hlc-import-bystander"
+	setBalance(bystander, 777)
+	setBalance(w[0].AddressEd, 10000)
+	appendStateBlock(t)
+
+	// nilai tersimpan akun yang tidak disentuh blok diganti langsung di DB:
+	// import yang menulis ulang seluruh akun akan menimpanya
+	db := ledger.DB()
+	key := []byte("state/a/" + bystander)
+	stored, err := db.Get(key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, 1), 2)
+	if err := db.Put(key, marker, nil); err != nil {
+		t.Fatal(err)
+	}
+	for nonce := 1; nonce <= 2; nonce++ {
+		if err := ledger.ValidateAndAddToMempool(txWithNonce(w[0], w[1].AddressEd, 10, nonce)); err != nil {
+			t.Fatal(err)
+		}
+		if err := ledger.ImportBlock(committedBlock(t, w[2], w[2])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head, _ := ledger.HeadBlock()
+	if root := ledger.ComputeStateRoot(); root != head.StateRoot {
+		t.Fatalf("rebuilt state root %.12s ≠ imported %.12s", root, head.StateRoot)
+	}
+	if got, _ := db.Get(key, nil); !bytes.Equal(got, marker) {
+		t.Fatal("import rewrote an account the block did not touch")
+	}
+	if err := db.Put(key, stored, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	resetLedgerState()
+	ledger.LoadAllData()
+	if ledger.GetBalance(w[1].AddressEd) != 20 || ledger.GetNextNonce(w[0].AddressEd) != 3 || ledger.GetBalance(bystander) != 777 {
+		t.Fatal("imported accounts not persisted")
+	}
+	if root := ledger.ComputeStateRoot(); root != head.StateRoot {
+		t.Fatalf("reloaded state root %.12s ≠ head %.12s", root, head.StateRoot)
+	}
+}
+
+// Node lama menyimpan akun di blob JSON balances & nonce_table: dipindah ke key
+// per akun saat load.
+func TestLegacyAccountBlobsMigrated(t *testing.T) {
+	if child, _ := inFreshLedger(t); !child {
+		return
+	}
+	w := testWallets(2)
+	db := ledger.DB()
+	if err := db.Put([]byte("balances"), []byte(`{"`+w[0].AddressEd+`":500,"`+w[1].AddressEd+`":0}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("nonce_table"), []byte(`{"`+w[0].AddressEd+`":4,"`+w[1].AddressEd+`":2}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	ledger.LoadAllData()
+	if ledger.GetBalance(w[0].AddressEd) != 500 || ledger.GetNextNonce(w[0].AddressEd) != 5 || ledger.GetNextNonce(w[1].AddressEd) != 3 {
+		t.Fatal("legacy account blobs not loaded")
+	}
+	for _, key := range []string{"balances", "nonce_table"} {
+		if ok, _ := db.Has([]byte(key), nil); ok {
+			t.Fatalf("legacy key %q not removed", key)
+		}
+	}
+
+	resetLedgerState()
+	ledger.LoadAllData()
+	if ledger.GetBalance(w[0].AddressEd) != 500 || ledger.GetNextNonce(w[1].AddressEd) != 3 {
+		t.Fatal("migrated accounts not persisted")
+	}
+}
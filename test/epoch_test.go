@@ -0,0 +1,88 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func TestEpochBoundaryRotatesActiveSet(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	p := ledger.DefaultChainParams()
+	p.MaxActiveValidators = 2
+	withParams(t, p)
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 50},
+		{Address: w[1].AddressEd, Stake: 30},
+		{Address: w[2].AddressEd, Stake: 20},
+		{Address: w[3].AddressEd, Stake: 10},
+	})
+
+	head, _ := ledger.HeadBlock()
+	cur := ledger.CurrentEpochSet()
+	want := []ledger.ValidatorDef{ledger.Validators[0], ledger.Validators[1]}
+	if !reflect.DeepEqual(cur.Validators, want) || cur.TotalStake != 80 || cur.Start != head.Index+1 {
+		t.Fatalf("current set = %+v", cur)
+	}
+
+	// stake & jail berubah di tengah epoch: set baru menunggu boundary; suspend
+	// lokal tidak memengaruhi active set
+	ledger.Validators[2].Stake = 100
+	ledger.Validators[0].JailedUntil = head.Index + 10*ledger.Params.EpochLength
+	ledger.SuspendValidator(w[1].AddressEd, ledger.ScopeAll, time.Hour)
+	t.Cleanup(func() { ledger.SuspendValidator(w[1].AddressEd, ledger.ScopeAll, 0) })
+	if got := ledger.ActiveValidators(head.Index + 1); !reflect.DeepEqual(got, want) {
+		t.Fatalf("set changed mid-epoch: %+v", got)
+	}
+
+	for ledger.EpochOf(head.Index+1) == cur.Epoch {
+		head = appendStateBlock(t)
+	}
+	next, ok := ledger.GetEpochSet(cur.Epoch + 1)
+	if !ok {
+		t.Fatal("next epoch set not published at the boundary")
+	}
+	want = []ledger.ValidatorDef{ledger.Validators[2], ledger.Validators[1]}
+	if next.Start != ledger.EpochStart(next.Epoch) || next.Start != head.Index+1 || !reflect.DeepEqual(next.Validators, want) ||
+		!reflect.DeepEqual(next.Jailed, []string{w[0].AddressEd}) || next.Seed != ledger.EpochSeed(head, next.Start) {
+		t.Fatalf("next set = %+v", next)
+	}
+	if old, ok := ledger.GetEpochSet(cur.Epoch); !ok || !reflect.DeepEqual(old.Validators, cur.Validators) {
+		t.Fatalf("history of epoch %d = %+v", cur.Epoch, old)
+	}
+
+	// proposer di luar active set ditolak walau terdaftar & tidak di-jail
+	out, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[3], w[3], ledger.VRFHeader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ValidateBlock(out); !errors.Is(err, ledger.ErrBlockProposer) {
+		t.Fatalf("inactive proposer err = %v", err)
+	}
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[2], w[2], proposerTicket(t, w[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ValidateBlock(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// snapshot membawa set epoch berjalan
+	info, err := ledger.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ledger.LoadSnapshot(info.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Epoch == nil || !reflect.DeepEqual(*s.Epoch, next) {
+		t.Fatalf("snapshot epoch = %+v", s.Epoch)
+	}
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func copyState(bal map[string]ledger.Amount, nonces map[string]int) (map[string]ledger.Amount, map[string]int) {
+	b := make(map[string]ledger.Amount, len(bal))
+	for k, v := range bal {
+		b[k] = v
+	}
+	n := make(map[string]int, len(nonces))
+	for k, v := range nonces {
+		n[k] = v
+	}
+	return b, n
+}
+
+// dropZero: executor boleh menulis entry 0 yang tidak ada di hasil serial (dan sebaliknya).
+func dropZero[V comparable](m map[string]V) map[string]V {
+	var zero V
+	out := map[string]V{}
+	for k, v := range m {
+		if v != zero {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// randomBlock: transfer acak antar sedikit akun (banyak konflik), termasuk
+// nonce salah, saldo kurang, dan rantai dana yang diterima di blok yang sama.
+func randomBlock(r *rand.Rand, ws []*wallet.Wallet, n int) []ledger.Transaction {
+	next := map[string]int{}
+	txs := make([]ledger.Transaction, 0, n)
+	for i := 0; i < n; i++ {
+		from := ws[r.Intn(len(ws))]
+		to := ws[r.Intn(len(ws))]
+		nonce := next[from.AddressEd] + 1
+		if r.Intn(10) == 0 {
+			nonce += r.Intn(3) - 1 // nonce duplikat / lompat
+		}
+		if nonce < 1 {
+			nonce = 1
+		}
+		next[from.AddressEd] = max(next[from.AddressEd], nonce)
+		amount := ledger.Amount(1 + r.Intn(3000))
+		txs = append(txs, txWithNonce(from, to.AddressEd, amount, nonce))
+	}
+	return txs
+}
+
+func TestExecutorChainedTransfersInOneBlock(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(3)
+	ledger.Balances[w[0].AddressEd] = 100000
+
+	ab := txWithNonce(w[0], w[1].AddressEd, 5000, 1)
+	bc := txWithNonce(w[1], w[2].AddressEd, 1000, 1) // B hanya punya dana dari ab
+	accepted := ledger.ProcessTxListParallel([]ledger.Transaction{ab, bc})
+	if len(accepted) != 2 {
+		t.Fatalf("accepted %d txs, want 2 (A→B, B→C)", len(accepted))
+	}
+	if got := ledger.GetBalance(w[2].AddressEd); got != 1000 {
+		t.Fatalf("C balance = %d, want 1000", got)
+	}
+	if got, want := ledger.GetBalance(w[1].AddressEd), 5000-1000-bc.Fee; got != want {
+		t.Fatalf("B balance = %d, want %d", got, want)
+	}
+}
+
+func TestExecutorMatchesSerial(t *testing.T) {
+	ws := testWallets(6)
+	r := rand.New(rand.NewSource(42))
+	for round := 0; round < 40; round++ {
+		resetLedgerState()
+		baseBal := map[string]ledger.Amount{}
+		for i, w := range ws {
+			if i%2 == 0 || r.Intn(2) == 0 {
+				baseBal[w.AddressEd] = ledger.Amount(r.Intn(8000))
+			}
+		}
+		txs := randomBlock(r, ws, 60)
+
+		sb, sn := copyState(baseBal, map[string]int{})
+		serial := ledger.ExecuteTxsSerial(txs, 1, sb, sn)
+		pb, pn := copyState(baseBal, map[string]int{})
+		parallel := ledger.ExecuteTxs(txs, 1, pb, pn)
+
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Fatalf("round %d: accepted differ (serial %d, parallel %d)", round, len(serial), len(parallel))
+		}
+		if !reflect.DeepEqual(dropZero(sb), dropZero(pb)) || !reflect.DeepEqual(dropZero(sn), dropZero(pn)) {
+			t.Fatalf("round %d: final state differs", round)
+		}
+	}
+}
+
+// ================== Benchmark: Block-STM vs executor lama ==================
+
+type benchBlock struct {
+	txs  []ledger.Transaction
+	bals map[string]ledger.Amount
+}
+
+func makeBenchBlock(b *testing.B, senders, perSender int) benchBlock {
+	b.Helper()
+	ws := testWallets(senders)
+	bb := benchBlock{bals: map[string]ledger.Amount{}}
+	for _, w := range ws {
+		bb.bals[w.AddressEd] = 1_000_000
+	}
+	resetLedgerState()
+	for n := 1; n <= perSender; n++ {
+		for i, w := range ws {
+			to := ws[(i+1)%len(ws)] // cincin: tiap penerima juga pengirim (konflik antar sender)
+			bb.txs = append(bb.txs, txWithNonce(w, to.AddressEd, 10, n))
+		}
+	}
+	return bb
+}
+
+func (bb benchBlock) reset() {
+	resetLedgerState()
+	ledger.BalanceMu.Lock()
+	for k, v := range bb.bals {
+		ledger.Balances[k] = v
+	}
+	ledger.BalanceMu.Unlock()
+}
+
+func benchExecutor(b *testing.B, exec func([]ledger.Transaction) []ledger.Transaction) {
+	bb := makeBenchBlock(b, 200, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		bb.reset()
+		b.StartTimer()
+		if got := exec(bb.txs); len(got) != len(bb.txs) {
+			b.Fatalf("accepted %d/%d", len(got), len(bb.txs))
+		}
+	}
+	b.ReportMetric(float64(len(bb.txs)*b.N)/b.Elapsed().Seconds(), "tx/s")
+}
+
+func BenchmarkExecutorBlockSTM(b *testing.B) {
+	benchExecutor(b, ledger.ProcessTxListParallel)
+}
+
+func BenchmarkExecutorPartitioned(b *testing.B) {
+	benchExecutor(b, ledger.ProcessTxListPartitioned)
+}
+
+func BenchmarkExecutorSerial(b *testing.B) {
+	benchExecutor(b, func(txs []ledger.Transaction) []ledger.Transaction {
+		return ledger.ExecuteTxsSerial(txs, ledger.ChainHeight(), ledger.Balances, ledger.NonceTable)
+	})
+}
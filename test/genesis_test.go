@@ -0,0 +1,182 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// fixedGenesis: genesis yang sama di setiap proses (address dari seed tetap).
+func fixedGenesis() ledger.Genesis {
+	addr := func(i byte) string {
+		seed := make([]byte, ed25519.SeedSize)
+		seed[0] = i
+		return wallet.AddressFromPubKey(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+	}
+	return ledger.Genesis{
+		ChainID:     "hyperlux-genesis-test",
+		GenesisTime: 1700000000,
+		Balances:    map[string]ledger.Amount{addr(1): 1000, addr(2): 2500, addr(3): 7},
+		Validators:  []ledger.GenesisValidator{{Address: addr(1), Stake: 60}, {Address: addr(2), Stake: 40}},
+		Params:      ledger.DefaultChainParams(),
+	}
+}
+
+var genesisHashLine = regexp.MustCompile(`genesis-hash=([0-9a-f]{64}) root=([0-9a-f]{64})`)
+
+func TestInitFromGenesisIsDeterministic(t *testing.T) {
+	if child, _ := inFreshLedger(t); child {
+		g := fixedGenesis()
+		b, err := ledger.InitFromGenesis(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Index != 0 || b.Hash != g.Hash() || ledger.ChainHeight() != 1 || ledger.ChainID != g.ChainID || ledger.ComputeStateRoot() != b.StateRoot {
+			t.Fatalf("genesis block = %+v", b)
+		}
+		if _, err := ledger.InitFromGenesis(g); err == nil {
+			t.Fatal("second init on a non-empty chain accepted")
+		}
+		fmt.Printf("genesis-hash=%s root=%s\n", b.Hash, b.StateRoot)
+		return
+	}
+	var runs [][]string
+	for i := 0; i < 2; i++ {
+		_, out := inFreshLedger(t)
+		m := genesisHashLine.FindStringSubmatch(out)
+		if m == nil {
+			t.Fatalf("no genesis hash in output:\n%s", out)
+		}
+		runs = append(runs, m[1:])
+	}
+	if runs[0][0] != runs[1][0] || runs[0][1] != runs[1][1] {
+		t.Fatalf("genesis differs between runs: %v vs %v", runs[0], runs[1])
+	}
+}
+
+func TestGenesisHashCoversEveryParam(t *testing.T) {
+	base := fixedGenesis()
+	seen := map[string]string{base.Hash(): "default"}
+	pv := reflect.ValueOf(&base.Params).Elem()
+	for i := 0; i < pv.NumField(); i++ {
+		g := fixedGenesis()
+		f := reflect.ValueOf(&g.Params).Elem().Field(i)
+		switch f.Kind() {
+		case reflect.Int:
+			f.SetInt(f.Int() + 1)
+		case reflect.Uint64:
+			f.SetUint(f.Uint() + 1)
+		default:
+			t.Fatalf("param %s: kind %s belum ditangani test", pv.Type().Field(i).Name, f.Kind())
+		}
+		name := pv.Type().Field(i).Name
+		h := g.Hash()
+		if prev, dup := seen[h]; dup {
+			t.Fatalf("changing %s gives the same genesis hash as %s", name, prev)
+		}
+		seen[h] = name
+	}
+}
+
+// genesis.json devnet di repo: semua param tertulis eksplisit (tidak bergantung
+// default di kode) dan setiap validator punya wallet di validators/.
+func TestShippedDevnetGenesis(t *testing.T) {
+	_, self, _, _ := runtime.Caller(0) // cwd sudah dipindah initTempLedger
+	root := filepath.Dir(filepath.Dir(self))
+	data, err := os.ReadFile(filepath.Join(root, "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Params map[string]json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	pt := reflect.TypeOf(ledger.ChainParams{})
+	for i := 0; i < pt.NumField(); i++ {
+		tag := strings.Split(pt.Field(i).Tag.Get("json"), ",")[0]
+		if _, ok := raw.Params[tag]; !ok {
+			t.Errorf("genesis.json params missing %q", tag)
+		}
+	}
+
+	g, err := ledger.LoadGenesisFile(filepath.Join(root, "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Params != ledger.DefaultChainParams() {
+		t.Errorf("devnet params %+v differ from DefaultChainParams", g.Params)
+	}
+	for _, v := range g.Validators {
+		w, err := wallet.LoadWallet(filepath.Join(root, "validators", v.Address+".json"))
+		if err != nil {
+			t.Fatalf("validator %s has no devnet key: %v", v.Address, err)
+		}
+		if w.AddressEd != v.Address {
+			t.Fatalf("validators/%s.json holds key for %s", v.Address, w.AddressEd)
+		}
+	}
+}
+
+func TestGenesisRejectsInvalidParams(t *testing.T) {
+	if err := fixedGenesis().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for name, mutate := range map[string]func(*ledger.Genesis){
+		"block reward above max": func(g *ledger.Genesis) { g.Params.BlockReward = ledger.MaxSupply + 1 },
+		"fee per byte above max": func(g *ledger.Genesis) { g.Params.FeePerByte = ledger.MaxSupply + 1 },
+		"empty chain id":         func(g *ledger.Genesis) { g.ChainID = "" },
+		"missing genesis time":   func(g *ledger.Genesis) { g.GenesisTime = 0 },
+		"bad balance address":    func(g *ledger.Genesis) { g.Balances["hlx1notanaddress"] = 1 },
+		"bad validator checksum": func(g *ledger.Genesis) {
+			v, last := g.Validators[0].Address, "q"
+			if strings.HasSuffix(v, last) {
+				last = "p"
+			}
+			g.Validators[0].Address = v[:len(v)-1] + last
+		},
+		"legacy validator address": func(g *ledger.Genesis) { g.Validators[0].Address = wallet.GenerateWallet().LegacyAddressEd() },
+		"legacy key of other address": func(g *ledger.Genesis) {
+			g.LegacyKeys = map[string]string{wallet.GenerateWallet().LegacyAddressEd(): hex.EncodeToString(wallet.GenerateWallet().PubEd)}
+		},
+		"zero stake":          func(g *ledger.Genesis) { g.Validators[1].Stake = 0 },
+		"duplicate validator": func(g *ledger.Genesis) { g.Validators[1].Address = g.Validators[0].Address },
+		"supply above max":    func(g *ledger.Genesis) { g.Validators[0].Stake = ledger.MaxSupply },
+	} {
+		g := fixedGenesis()
+		mutate(&g)
+		err := g.Validate()
+		if err == nil {
+			t.Errorf("%s: accepted", name)
+			continue
+		}
+		// InitFromGenesis memvalidasi sebelum menyentuh DB
+		if _, initErr := ledger.InitFromGenesis(g); initErr == nil || initErr.Error() != err.Error() {
+			t.Errorf("%s: InitFromGenesis err = %v, want %v", name, initErr, err)
+		}
+	}
+}
+
+func TestGenesisHashCoversLegacyKeys(t *testing.T) {
+	w := wallet.GenerateWallet()
+	g := fixedGenesis()
+	g.LegacyKeys = map[string]string{w.LegacyAddressEd(): hex.EncodeToString(w.PubEd)}
+	if err := g.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Hash() == fixedGenesis().Hash() {
+		t.Fatal("legacy migration table not bound to the genesis hash")
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func setBalance(addr string, bal ledger.Amount) {
+	ledger.BalanceMu.Lock()
+	ledger.Balances[addr] = bal
+	ledger.BalanceMu.Unlock()
+}
+
+func TestBalanceAndValidatorsAtHeight(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	oldVals := ledger.Validators
+	t.Cleanup(func() { ledger.Validators = oldVals })
+
+	setBalance(w[0].AddressEd, 100)
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 500}}
+	h1 := appendTestBlock(t, nil).Index
+
+	setBalance(w[0].AddressEd, 70)
+	setBalance(w[1].AddressEd, 30)
+	ledger.NonceTable[w[0].AddressEd] = 1
+	h2 := appendTestBlock(t, nil).Index
+
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 800}}
+	h3 := appendTestBlock(t, nil).Index
+
+	for _, c := range []struct {
+		height int
+		addr   string
+		bal    ledger.Amount
+		nonce  int
+	}{
+		{h1, w[0].AddressEd, 100, 0},
+		{h1, w[1].AddressEd, 0, 0},
+		{h2, w[0].AddressEd, 70, 1},
+		{h2, w[1].AddressEd, 30, 0},
+		{h3, w[0].AddressEd, 70, 1}, // tidak berubah di h3
+	} {
+		bal, nonce, err := ledger.GetAccountAt(c.addr, c.height)
+		if err != nil || bal != c.bal || nonce != c.nonce {
+			t.Fatalf("account %.12s @%d = %d/%d (%v), want %d/%d", c.addr, c.height, bal, nonce, err, c.bal, c.nonce)
+		}
+	}
+
+	for height, want := range map[int]ledger.Amount{h1: 500, h2: 500, h3: 800} {
+		vals, err := ledger.GetValidatorsAt(height)
+		if err != nil || len(vals) != 1 || vals[0].Stake != want {
+			t.Fatalf("validators @%d = %+v (%v), want stake %d", height, vals, err, want)
+		}
+	}
+
+	if _, err := ledger.GetBalanceAt(w[0].AddressEd, h3+1); !errors.Is(err, ledger.ErrHeightInFuture) {
+		t.Fatalf("future height err = %v", err)
+	}
+}
+
+func TestHistoryRetentionPrunesOldVersions(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	old := ledger.History
+	ledger.History = ledger.HistoryConfig{Retention: 2}
+	t.Cleanup(func() { ledger.History = old })
+	w := testWallets(2)
+
+	var heights []int
+	for i := 1; i <= 5; i++ {
+		setBalance(w[0].AddressEd, ledger.Amount(i*10))
+		if i == 1 {
+			setBalance(w[1].AddressEd, 7)
+		}
+		heights = append(heights, appendTestBlock(t, nil).Index)
+	}
+	head := heights[len(heights)-1]
+
+	from, to := ledger.HistoryRange()
+	if from != head-2 || to != head {
+		t.Fatalf("history range %d..%d, want %d..%d", from, to, head-2, head)
+	}
+	for i, h := range heights[2:] {
+		if bal, err := ledger.GetBalanceAt(w[0].AddressEd, h); err != nil || bal != ledger.Amount((i+3)*10) {
+			t.Fatalf("balance @%d = %d (%v)", h, bal, err)
+		}
+	}
+	// versi di bawah horizon yang belum tergantikan tetap dipakai
+	if bal, err := ledger.GetBalanceAt(w[1].AddressEd, head-2); err != nil || bal != 7 {
+		t.Fatalf("untouched account @%d = %d (%v)", head-2, bal, err)
+	}
+	if _, err := ledger.GetBalanceAt(w[0].AddressEd, heights[1]); !errors.Is(err, ledger.ErrHistoryUnavailable) {
+		t.Fatalf("pruned height err = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func TestEquivocationEvidenceJailsValidator(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	w := testWallets(2)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 60},
+		{Address: w[1].AddressEd, Stake: 40},
+	})
+	head := appendStateBlock(t) // height bukti di dalam epoch set baru
+
+	a := ledger.SignVote(w[1], ledger.VotePrevote, head.Index, 0, strings.Repeat("aa", 32))
+	b := ledger.SignVote(w[1], ledger.VotePrevote, head.Index, 0, strings.Repeat("bb", 32))
+	forged := b
+	forged.Signature = a.Signature
+	other := ledger.SignVote(w[0], ledger.VotePrevote, head.Index, 0, b.BlockHash)
+	for name, pair := range map[string][2]ledger.Vote{
+		"same vote":       {a, a},
+		"bad signature":   {a, forged},
+		"other validator": {a, other},
+	} {
+		if err := ledger.ReportEquivocation(pair[0], pair[1]); !errors.Is(err, ledger.ErrEvidence) {
+			t.Fatalf("%s: err = %v, want ErrEvidence", name, err)
+		}
+	}
+	if err := ledger.ReportEquivocation(b, a); err != nil {
+		t.Fatal(err)
+	}
+
+	// proposer berikutnya memasukkan bukti; jail ikut state root blok
+	blk := committedBlock(t, w[0], w[0], w[1])
+	want := []ledger.Evidence{ledger.NewEvidence(a, b)}
+	if !reflect.DeepEqual(blk.Evidence, want) {
+		t.Fatalf("block evidence = %+v", blk.Evidence)
+	}
+	decoded, err := ledger.DecodeBlock(ledger.EncodeBlock(blk))
+	if err != nil || !reflect.DeepEqual(decoded.Evidence, want) {
+		t.Fatalf("decoded evidence = %+v (%v)", decoded.Evidence, err)
+	}
+	if err := ledger.ImportBlock(decoded); err != nil {
+		t.Fatal(err)
+	}
+	until := head.Index + 1 + ledger.JailBlocks
+	if ledger.Validators[1].JailedUntil != until {
+		t.Fatalf("jailed until %d, want %d", ledger.Validators[1].JailedUntil, until)
+	}
+	if got, ok := ledger.GetBlockByHeight(blk.Index); !ok || !reflect.DeepEqual(got.Evidence, want) {
+		t.Fatalf("stored evidence = %+v", got.Evidence)
+	}
+
+	// bukti yang sama tidak bisa dipakai dua kali
+	if err := ledger.ReportEquivocation(a, b); err != nil {
+		t.Fatal(err)
+	}
+	next, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[0], proposerTicket(t, w[0]))
+	if err != nil || len(next.Evidence) != 0 {
+		t.Fatalf("replayed evidence included: %+v (%v)", next.Evidence, err)
+	}
+
+	// active set epoch berjalan tetap, epoch berikutnya tanpa w[1]
+	if !reflect.DeepEqual(ledger.ActiveValidators(blk.Index+1), []ledger.ValidatorDef{
+		{Address: w[0].AddressEd, Stake: 60}, {Address: w[1].AddressEd, Stake: 40},
+	}) {
+		t.Fatalf("set changed mid-epoch: %+v", ledger.ActiveValidators(blk.Index+1))
+	}
+	advanceEpoch(t)
+	s := ledger.CurrentEpochSet()
+	if !reflect.DeepEqual(s.Validators, []ledger.ValidatorDef{{Address: w[0].AddressEd, Stake: 60}}) ||
+		!reflect.DeepEqual(s.Jailed, []string{w[1].AddressEd}) {
+		t.Fatalf("epoch set after jail = %+v", s)
+	}
+}
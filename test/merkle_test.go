@@ -0,0 +1,192 @@
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/crypto"
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func merkleKV(i int) ([]byte, []byte) {
+	return []byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))
+}
+
+func TestSparseMerkleProofs(t *testing.T) {
+	const n = 64
+	tree := crypto.NewSparseMerkleTree()
+	if tree.Root() != [32]byte{} {
+		t.Fatal("empty tree root must be zero")
+	}
+	k0, _ := merkleKV(0)
+	if !crypto.VerifyNonInclusion(tree.Root(), k0, tree.Prove(k0)) {
+		t.Fatal("non-inclusion in empty tree")
+	}
+	for i := 0; i < n; i++ {
+		tree.Update(merkleKV(i))
+	}
+	root := tree.Root()
+
+	for i := 0; i < n; i++ {
+		k, v := merkleKV(i)
+		p := tree.Prove(k)
+		if !crypto.VerifyMerkleProof(root, k, v, p) {
+			t.Fatalf("inclusion proof for %s failed", k)
+		}
+		if crypto.VerifyNonInclusion(root, k, p) {
+			t.Fatalf("non-inclusion accepted for present key %s", k)
+		}
+		if crypto.VerifyMerkleProof(root, k, []byte("other"), p) {
+			t.Fatalf("proof accepted with wrong value for %s", k)
+		}
+		tampered := p
+		tampered.Siblings = append([][32]byte(nil), p.Siblings...)
+		tampered.Siblings[len(p.Siblings)/2][0] ^= 1
+		if crypto.VerifyMerkleProof(root, k, v, tampered) {
+			t.Fatalf("tampered sibling accepted for %s", k)
+		}
+		badRoot := root
+		badRoot[31] ^= 1
+		if crypto.VerifyMerkleProof(badRoot, k, v, p) {
+			t.Fatalf("proof accepted against wrong root for %s", k)
+		}
+	}
+
+	for i := n; i < 2*n; i++ {
+		k, v := merkleKV(i)
+		p := tree.Prove(k)
+		if !crypto.VerifyNonInclusion(root, k, p) {
+			t.Fatalf("non-inclusion proof for %s failed", k)
+		}
+		if crypto.VerifyMerkleProof(root, k, v, p) {
+			t.Fatalf("inclusion accepted for absent key %s", k)
+		}
+		if len(p.Siblings) > 0 {
+			tampered := p
+			tampered.Siblings = append([][32]byte(nil), p.Siblings...)
+			tampered.Siblings[0][5] ^= 1
+			if crypto.VerifyNonInclusion(root, k, tampered) {
+				t.Fatalf("tampered non-inclusion accepted for %s", k)
+			}
+		}
+		// proof key lain tidak bisa dipakai untuk key ini
+		present, _ := merkleKV(i - n)
+		if crypto.VerifyNonInclusion(root, present, p) {
+			t.Fatalf("non-inclusion proof of %s reused for present key %s", k, present)
+		}
+	}
+}
+
+func TestSparseMerkleRootIndependentOfInsertionOrder(t *testing.T) {
+	const n = 100
+	a := crypto.NewSparseMerkleTree()
+	for i := 0; i < n; i++ {
+		a.Update(merkleKV(i))
+	}
+	b := crypto.NewSparseMerkleTree()
+	for _, i := range rand.New(rand.NewSource(7)).Perm(n) {
+		b.Update(merkleKV(i))
+	}
+	// nilai sementara yang ditimpa & key yang dihapus tidak meninggalkan jejak
+	c := crypto.NewSparseMerkleTree()
+	for i := n - 1; i >= 0; i-- {
+		k, _ := merkleKV(i)
+		c.Update(k, []byte("stale"))
+		c.Update(merkleKV(i))
+		c.Update([]byte(fmt.Sprintf("tmp-%d", i)), []byte("x"))
+		c.Delete([]byte(fmt.Sprintf("tmp-%d", i)))
+	}
+	if a.Root() != b.Root() || a.Root() != c.Root() || c.Len() != n {
+		t.Fatalf("roots differ: %x / %x / %x (len %d)", a.Root(), b.Root(), c.Root(), c.Len())
+	}
+}
+
+func TestSparseMerkleCloneUpdatesIncrementally(t *testing.T) {
+	const n = 200
+	base := crypto.NewSparseMerkleTree()
+	for i := 0; i < n; i++ {
+		base.Update(merkleKV(i))
+	}
+	baseRoot := base.Root()
+
+	// clone diubah sebagian setelah hash node lama sudah tersimpan
+	next := base.Clone()
+	fresh := crypto.NewSparseMerkleTree()
+	for i := 0; i < n; i++ {
+		k, v := merkleKV(i)
+		switch {
+		case i%7 == 0:
+			next.Delete(k)
+			continue
+		case i%5 == 0:
+			v = []byte("changed")
+			next.Update(k, v)
+		}
+		fresh.Update(k, v)
+	}
+	next.Update([]byte("new-key"), []byte("new-value"))
+	fresh.Update([]byte("new-key"), []byte("new-value"))
+
+	if next.Root() != fresh.Root() || next.Len() != fresh.Len() {
+		t.Fatalf("incremental root %x (len %d) != rebuilt %x (len %d)", next.Root(), next.Len(), fresh.Root(), fresh.Len())
+	}
+	if base.Root() != baseRoot || base.Len() != n {
+		t.Fatal("updating a clone changed the original tree")
+	}
+	k, v := merkleKV(3)
+	deleted, _ := merkleKV(7)
+	if !crypto.VerifyMerkleProof(next.Root(), k, v, next.Prove(k)) || !crypto.VerifyNonInclusion(next.Root(), deleted, next.Prove(deleted)) {
+		t.Fatal("proof from incremental tree does not verify")
+	}
+}
+
+func TestStateRootAndAccountProofs(t *testing.T) {
+	addr := func(i int) string { return fmt.Sprintf("hlc-state-root-%d", i) }
+	set := func(order []int) string {
+		ledger.BalanceMu.Lock()
+		ledger.NonceTableMu.Lock()
+		ledger.Balances = map[string]ledger.Amount{}
+		ledger.NonceTable = map[string]int{}
+		for _, i := range order {
+			ledger.Balances[addr(i)] = ledger.Amount(100 * (i + 1))
+			ledger.NonceTable[addr(i)] = i
+		}
+		ledger.NonceTableMu.Unlock()
+		ledger.BalanceMu.Unlock()
+		return ledger.ComputeStateRoot()
+	}
+	root := set([]int{0, 1, 2, 3, 4})
+	if other := set([]int{4, 2, 0, 3, 1}); other != root {
+		t.Fatalf("state root depends on insertion order: %s vs %s", root, other)
+	}
+	// akun dengan balance & nonce nol = tidak ada
+	ledger.BalanceMu.Lock()
+	ledger.Balances[addr(5)] = 0
+	ledger.BalanceMu.Unlock()
+	if got := ledger.ComputeStateRoot(); got != root {
+		t.Fatal("zero account changed the state root")
+	}
+
+	p := ledger.ProveAccount(addr(2))
+	if p.StateRoot != root || p.Balance != 300 || p.Nonce != 2 || !ledger.VerifyAccountProof(root, p) {
+		t.Fatalf("account proof = %+v", p)
+	}
+	tampered := p
+	tampered.Balance++
+	if ledger.VerifyAccountProof(root, tampered) {
+		t.Fatal("proof accepted with tampered balance")
+	}
+	absent := ledger.ProveAccount(addr(5))
+	if absent.Balance != 0 || !ledger.VerifyAccountProof(root, absent) {
+		t.Fatalf("non-inclusion proof for empty account = %+v", absent)
+	}
+	forged := absent
+	forged.Address = addr(2) // akun yang ada tidak bisa dibuktikan kosong
+	if ledger.VerifyAccountProof(root, forged) {
+		t.Fatal("existing account proven empty")
+	}
+	if ledger.VerifyAccountProof("zz", p) {
+		t.Fatal("malformed root accepted")
+	}
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+// pohSegment: entry PoH dari start — tiap tick `perTick` hash, mixin (bila ada)
+// di hash ke-3 tick tersebut.
+func pohSegment(start string, ticks int, perTick uint64, mixins map[int]string) []ledger.PoHEntry {
+	h, _ := hex.DecodeString(start)
+	cur := [32]byte(h)
+	var out []ledger.PoHEntry
+	for t := 0; t < ticks; t++ {
+		n := perTick
+		if m, ok := mixins[t]; ok {
+			for i := 0; i < 2; i++ {
+				cur = sha256.Sum256(cur[:])
+			}
+			mb, _ := hex.DecodeString(m)
+			cur = sha256.Sum256(append(cur[:], mb...))
+			out = append(out, ledger.PoHEntry{NumHashes: 3, Hash: hex.EncodeToString(cur[:]), Mixin: m})
+			n -= 3
+		}
+		for i := uint64(0); i < n; i++ {
+			cur = sha256.Sum256(cur[:])
+		}
+		out = append(out, ledger.PoHEntry{NumHashes: n, Hash: hex.EncodeToString(cur[:])})
+	}
+	return out
+}
+
+func TestVerifyPoHParallelMatchesSequential(t *testing.T) {
+	start := strings.Repeat("ab", 32)
+	mix := strings.Repeat("cd", 32)
+	entries := pohSegment(start, 40, 200, map[int]string{3: mix, 17: mix})
+	for _, workers := range []int{1, 4, 64} {
+		if err := ledger.VerifyPoH(start, entries, workers); err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+	}
+
+	// dua entry rusak: semua jumlah worker melaporkan index terkecil
+	bad := append([]ledger.PoHEntry(nil), entries...)
+	bad[30].Hash, bad[9].NumHashes = bad[31].Hash, bad[9].NumHashes+1
+	for _, workers := range []int{1, 4, 64} {
+		if err := ledger.VerifyPoH(start, bad, workers); err == nil || !strings.Contains(err.Error(), "entry 9:") {
+			t.Fatalf("workers=%d: err = %v", workers, err)
+		}
+	}
+	swapped := append([]ledger.PoHEntry(nil), entries...)
+	swapped[4].Mixin = strings.Repeat("ef", 32)
+	if err := ledger.VerifyPoH(start, swapped, 4); err == nil || !strings.Contains(err.Error(), "entry 4:") {
+		t.Fatalf("swapped mixin err = %v", err)
+	}
+	if err := ledger.VerifyPoH(strings.Repeat("00", 32), entries, 4); err == nil || !strings.Contains(err.Error(), "entry 0:") {
+		t.Fatalf("wrong start err = %v", err)
+	}
+}
+
+func TestBlockPoHMixesTxsAndChainsToParent(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	oldPrune := ledger.Pruning
+	t.Cleanup(func() { ledger.Pruning = oldPrune })
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+
+	setBalance(w[0].AddressEd, 10000)
+	tx := ledger.NewTransaction(w[0], w[1].AddressEd, 10)
+	if err := ledger.ValidateAndAddToMempool(tx); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := ledger.HeadBlock()
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], proposerTicket(t, w[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := b.PoH
+	if p == nil || p.Ticks < 1 || p.Hashes != uint64(p.Ticks)*ledger.Params.PoHHashesPerTick {
+		t.Fatalf("poh = %+v", p)
+	}
+	mixed := false
+	for _, en := range p.Entries {
+		mixed = mixed || en.Mixin == ledger.HashTransaction(tx)
+	}
+	if !mixed {
+		t.Fatal("block tx not mixed into PoH")
+	}
+	if err := ledger.VerifyPoH(ledger.PoHStart(head), p.Entries, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ValidateBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ledger.DecodeBlock(ledger.EncodeBlock(b))
+	if err != nil || decoded.Hash != b.Hash || decoded.PoH == nil || len(decoded.PoH.Entries) != len(p.Entries) {
+		t.Fatalf("decoded poh = %+v (%v)", decoded.PoH, err)
+	}
+
+	// entry (body) diubah tanpa mengubah ringkasan di header
+	forged := b
+	forged.PoH = &ledger.PoHRecord{Hash: p.Hash, Hashes: p.Hashes, Ticks: p.Ticks, Root: p.Root,
+		Entries: append([]ledger.PoHEntry(nil), p.Entries...)}
+	forged.PoH.Entries[0].Hash = strings.Repeat("00", 32)
+	if err := ledger.ValidateBlock(forged); !errors.Is(err, ledger.ErrBlockPoH) {
+		t.Fatalf("forged entries err = %v", err)
+	}
+
+	// blok berikutnya melanjutkan dari akhir PoH blok ini
+	if err := ledger.AppendBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	ledger.RemoveCommittedFromMempool(b.Transactions)
+	next, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], proposerTicket(t, w[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.VerifyPoH(p.Hash, next.PoH.Entries, 4); err != nil {
+		t.Fatalf("next block does not chain: %v", err)
+	}
+	if rec, err := ledger.VerifyStoredPoH(b.Index); err != nil || rec.Hash != p.Hash {
+		t.Fatalf("stored poh = %+v (%v)", rec, err)
+	}
+
+	// pruning membuang entry, ringkasan & hash header tetap
+	appendTestBlock(t, nil)
+	appendTestBlock(t, nil)
+	ledger.Pruning = ledger.PruneConfig{Depth: 2}
+	if _, err := ledger.PruneNow(); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := ledger.GetBlockByHeight(b.Index)
+	if !ok || got.Hash != b.Hash || got.PoH == nil || len(got.PoH.Entries) != 0 || got.PoH.Root != p.Root {
+		t.Fatalf("pruned block poh = %+v", got.PoH)
+	}
+	if _, err := ledger.VerifyStoredPoH(b.Index); err == nil {
+		t.Fatal("pruned PoH reported as verified")
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+func TestPruneBodiesAndHistory(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	oldHist, oldPrune := ledger.History, ledger.Pruning
+	ledger.History = ledger.HistoryConfig{Retention: 100}
+	t.Cleanup(func() { ledger.History, ledger.Pruning = oldHist, oldPrune })
+	w := testWallets(2)
+
+	var blocks []ledger.Block
+	for i := 1; i <= 6; i++ {
+		setBalance(w[0].AddressEd, ledger.Amount(i*10))
+		blocks = append(blocks, appendTestBlock(t, []ledger.Transaction{txWithNonce(w[0], w[1].AddressEd, 1, i)}))
+	}
+	head := blocks[len(blocks)-1].Index
+
+	ledger.Pruning = ledger.PruneConfig{Depth: 2}
+	res, err := ledger.PruneNow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.To != head-2 || res.Blocks < 4 || res.Versions < 4 || res.Bytes <= 0 {
+		t.Fatalf("prune result = %+v", res)
+	}
+	// belum ada snapshot: diambil dulu sebelum body dipangkas
+	if _, err := os.Stat(ledger.SnapshotPath(head)); err != nil {
+		t.Fatalf("no snapshot at head %d after first prune: %v", head, err)
+	}
+
+	for _, b := range blocks {
+		got, ok := ledger.GetBlockByHeight(b.Index)
+		if !ok || got.Hash != b.Hash || got.MerkleRoot != b.MerkleRoot {
+			t.Fatalf("header %d lost", b.Index)
+		}
+		if pruned := b.Index <= head-2; pruned != (len(got.Transactions) == 0) || pruned != got.Pruned {
+			t.Fatalf("block %d: %d txs, pruned=%v after prune", b.Index, len(got.Transactions), got.Pruned)
+		}
+	}
+	pruned, _ := ledger.GetBlockByHeight(blocks[0].Index)
+	if err := ledger.ValidateBlock(pruned); !errors.Is(err, ledger.ErrBlockPruned) {
+		t.Fatalf("validate pruned block: err=%v, want ErrBlockPruned", err)
+	}
+	r, ok := ledger.GetReceipt(ledger.HashTransaction(blocks[0].Transactions[0]))
+	if !ok || !r.Pruned || r.Status != ledger.ReceiptSuccess {
+		t.Fatalf("receipt of pruned tx = %+v (%v)", r, ok)
+	}
+	if _, err := ledger.GetBalanceAt(w[0].AddressEd, head-3); !errors.Is(err, ledger.ErrHistoryUnavailable) {
+		t.Fatalf("pruned state version err = %v", err)
+	}
+	if bal, err := ledger.GetBalanceAt(w[0].AddressEd, head-2); err != nil || bal != 40 {
+		t.Fatalf("balance @horizon = %d (%v)", bal, err)
+	}
+
+	// snapshot terbaru menahan horizon body
+	appendStateBlock(t)
+	info, err := ledger.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		appendTestBlock(t, nil)
+	}
+	if res, err := ledger.PruneNow(); err != nil || ledger.BodiesPrunedTo() != info.Height {
+		t.Fatalf("pruned to %d (%+v, %v), want snapshot height %d", ledger.BodiesPrunedTo(), res, err, info.Height)
+	}
+
+	// mode background: AppendBlock hanya memberi sinyal ke pruner
+	ledger.Pruning.Enabled = true
+	runs := ledger.PruneStatus().Runs
+	appendTestBlock(t, nil)
+	deadline := time.Now().Add(5 * time.Second)
+	for ledger.PruneStatus().Runs == runs {
+		if time.Now().After(deadline) {
+			t.Fatal("background pruner did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
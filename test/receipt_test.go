@@ -0,0 +1,121 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+// appendTestBlock: blok berisi txs langsung di atas head (tanpa validasi).
+func appendTestBlock(t *testing.T, txs []ledger.Transaction) ledger.Block {
+	t.Helper()
+	head, ok := ledger.HeadBlock()
+	if !ok {
+		t.Fatal("no genesis")
+	}
+	b := ledger.Block{Index: head.Index + 1, PrevHash: head.Hash, Timestamp: head.Timestamp + 1, Transactions: txs}
+	b.MerkleRoot = ledger.ComputeMerkleRoot(txs)
+	b.Hash = fmt.Sprintf("%064x", 0xb10c0000+b.Index)
+	if err := ledger.AppendBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestReceiptsAndAccountHistory(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	a, b, c := w[0].AddressEd, w[1].AddressEd, w[2].AddressEd
+	before := historyLen(a)
+
+	txs := []ledger.Transaction{
+		txWithNonce(w[0], b, 10, 1),
+		txWithNonce(w[1], a, 20, 1),
+		txWithNonce(w[0], c, 30, 2),
+		txWithNonce(w[0], a, 40, 3), // self-transfer
+	}
+	blk := appendTestBlock(t, txs)
+
+	for i, tx := range txs {
+		r, ok := ledger.GetReceipt(ledger.HashTransaction(tx))
+		if !ok {
+			t.Fatalf("receipt tx %d missing", i)
+		}
+		if r.Status != ledger.ReceiptSuccess || r.Height != blk.Index || r.Index != i || r.BlockHash != blk.Hash {
+			t.Fatalf("receipt tx %d = %+v", i, r)
+		}
+		if r.FeePaid != tx.Fee || r.Tx.Signature != tx.Signature {
+			t.Fatalf("receipt tx %d fee/tx mismatch", i)
+		}
+	}
+
+	sent, _ := ledger.AccountHistory(a, ledger.DirSent, 0, 0)
+	recv, _ := ledger.AccountHistory(a, ledger.DirReceived, 0, 0)
+	if all := historyLen(a); all-before != 4 {
+		t.Fatalf("history of a grew by %d, want 4", all-before)
+	}
+	// terbaru dulu: self-transfer (index 3) paling atas, arah "self" ikut di kedua filter
+	if sent[0].TxHash != ledger.HashTransaction(txs[3]) || sent[0].Direction != ledger.DirAll {
+		t.Fatalf("newest sent entry = %+v", sent[0])
+	}
+	if recv[0].TxHash != sent[0].TxHash || recv[1].TxHash != ledger.HashTransaction(txs[1]) {
+		t.Fatal("received filter wrong")
+	}
+
+	// paginasi: 2 per halaman menyambung tanpa duplikat
+	p1, more := ledger.AccountHistory(a, ledger.DirAll, 0, 2)
+	p2, _ := ledger.AccountHistory(a, ledger.DirAll, 2, 2)
+	if len(p1) != 2 || !more || len(p2) == 0 || p1[1].TxHash == p2[0].TxHash {
+		t.Fatalf("pagination broken: p1=%v more=%v p2=%v", p1, more, p2)
+	}
+	if p2[0].TxHash != ledger.HashTransaction(txs[1]) {
+		t.Fatalf("page 2 starts with %s", p2[0].TxHash)
+	}
+}
+
+func historyLen(addr string) int {
+	all, _ := ledger.AccountHistory(addr, ledger.DirAll, 0, 0)
+	return len(all)
+}
+
+func TestFailedTxReceipt(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 10000
+
+	ok := txWithNonce(w[0], w[1].AddressEd, 100, 1)
+	broke := txWithNonce(w[0], w[1].AddressEd, 9000, 2)
+	for _, tx := range []ledger.Transaction{ok, broke} {
+		if err := ledger.ValidateAndAddToMempool(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ledger.Balances[w[0].AddressEd] = 5000 // saldo turun setelah admission
+
+	snap := ledger.MempoolSnapshot()
+	results := ledger.ProcessTxListResults(snap)
+	if !errors.Is(results[1], ledger.ErrAmountUnderflow) {
+		t.Fatalf("results = %v, want underflow on second tx", results)
+	}
+	ledger.RecordFailedTxs(ledger.ChainHeight(), snap, results)
+
+	r, found := ledger.GetReceipt(ledger.HashTransaction(broke))
+	if !found || r.Status != ledger.ReceiptFailed || r.Index != -1 || r.FeePaid != 0 {
+		t.Fatalf("failed receipt = %+v (found=%v)", r, found)
+	}
+	if !strings.Contains(r.Error, "saldo") || r.Tx.Amount != 9000 {
+		t.Fatalf("receipt reason/tx = %q / %d", r.Error, r.Tx.Amount)
+	}
+	if _, pending := ledger.PendingTx(r.TxHash); pending {
+		t.Fatal("failed tx still in mempool")
+	}
+	hist, _ := ledger.AccountHistory(w[0].AddressEd, ledger.DirSent, 0, 1)
+	if len(hist) != 1 || hist[0].TxHash != r.TxHash || hist[0].Status != ledger.ReceiptFailed || hist[0].Index != -1 {
+		t.Fatalf("newest history entry = %+v", hist)
+	}
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// withSigCacheCap: kapasitas SigCache sementara, cache & counter mulai kosong.
+func withSigCacheCap(t testing.TB, capacity int) {
+	old := ledger.SigStats().CacheCap
+	ledger.SigCache.Clear()
+	ledger.SigCache.SetCapacity(capacity)
+	ledger.ResetSigStats()
+	t.Cleanup(func() {
+		ledger.SigCache.Clear()
+		ledger.SigCache.SetCapacity(old)
+	})
+}
+
+// signedBlock: perSender TX berurutan dari tiap wallet (sudah didanai di ledger).
+func signedBlock(ws []*wallet.Wallet, perSender int) []ledger.Transaction {
+	var txs []ledger.Transaction
+	for i, w := range ws {
+		ledger.Balances[w.AddressEd] = 1_000_000
+		for n := 1; n <= perSender; n++ {
+			txs = append(txs, txWithNonce(w, ws[(i+1)%len(ws)].AddressEd, 10, n))
+		}
+	}
+	return txs
+}
+
+func TestBatchVerifyRejectsOnlyBadSignature(t *testing.T) {
+	resetLedgerState()
+	withSigCacheCap(t, 1000)
+	w := testWallets(4)
+	txs := signedBlock(w, 8)
+
+	// TX terakhir wallet 2: signature valid tapi untuk payload lain
+	bad := 2*8 + 7
+	other := txs[bad]
+	other.Amount++
+	txs[bad].Signature = hex.EncodeToString(w[2].SignEd(ledger.TxSigningBytes(other)))
+
+	accepted := ledger.ProcessTxListParallel(txs)
+	if len(accepted) != len(txs)-1 {
+		t.Fatalf("accepted %d txs, want %d", len(accepted), len(txs)-1)
+	}
+	for _, tx := range accepted {
+		if tx.Signature == txs[bad].Signature {
+			t.Fatal("tx with bad signature accepted")
+		}
+	}
+	st := ledger.SigStats()
+	if st.Batches == 0 || st.BatchFallbacks == 0 {
+		t.Fatalf("want batch + fallback, got %+v", st)
+	}
+	if st.CacheSize != len(txs)-1 {
+		t.Fatalf("cache size %d, want %d (bad sig must not be cached)", st.CacheSize, len(txs)-1)
+	}
+}
+
+func TestSigCacheSkipsReverifyAtCommit(t *testing.T) {
+	resetLedgerState()
+	withSigCacheCap(t, 1000)
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 1_000_000
+
+	for n := 1; n <= 10; n++ {
+		if err := ledger.ValidateAndAddToMempool(txWithNonce(w[0], w[1].AddressEd, 1, n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := ledger.SigStats()
+	if before.SingleVerifies != 10 {
+		t.Fatalf("mempool admission verified %d sigs, want 10", before.SingleVerifies)
+	}
+
+	if accepted := ledger.ProcessMempoolParallel(); len(accepted) != 10 {
+		t.Fatalf("accepted %d txs, want 10", len(accepted))
+	}
+	after := ledger.SigStats()
+	if after.SingleVerifies != before.SingleVerifies || after.Batches != before.Batches {
+		t.Fatalf("signatures re-verified at commit: before %+v after %+v", before, after)
+	}
+	if after.CacheHits-before.CacheHits != 10 {
+		t.Fatalf("cache hits +%d, want +10", after.CacheHits-before.CacheHits)
+	}
+}
+
+func TestSigCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	resetLedgerState()
+	withSigCacheCap(t, 2)
+	w := testWallets(2)
+	a := txWithNonce(w[0], w[1].AddressEd, 1, 1)
+	b := txWithNonce(w[0], w[1].AddressEd, 1, 2)
+	c := txWithNonce(w[0], w[1].AddressEd, 1, 3)
+
+	ledger.VerifyTransaction(a)
+	ledger.VerifyTransaction(b)
+	ledger.VerifyTransaction(a) // a jadi paling baru
+	ledger.VerifyTransaction(c) // b dibuang
+
+	ledger.ResetSigStats()
+	ledger.VerifyTransaction(a)
+	ledger.VerifyTransaction(c)
+	if st := ledger.SigStats(); st.CacheHits != 2 || st.SingleVerifies != 0 {
+		t.Fatalf("a & c should be cached: %+v", st)
+	}
+	ledger.VerifyTransaction(b)
+	if st := ledger.SigStats(); st.CacheMisses != 1 || st.SingleVerifies != 1 || st.CacheSize != 2 {
+		t.Fatalf("b should have been evicted: %+v", st)
+	}
+}
+
+// ================== Benchmark: batch vs satu-per-satu (cache nonaktif) ==================
+
+func benchSigVerify(b *testing.B, exec func([]ledger.Transaction) []ledger.Transaction) {
+	resetLedgerState()
+	withSigCacheCap(b, 0)
+	txs := signedBlock(testWallets(4), 64)
+	base := make(map[string]ledger.Amount, len(ledger.Balances))
+	for k, v := range ledger.Balances {
+		base[k] = v
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		resetLedgerState()
+		for k, v := range base {
+			ledger.Balances[k] = v
+		}
+		b.StartTimer()
+		if got := exec(txs); len(got) != len(txs) {
+			b.Fatalf("accepted %d/%d", len(got), len(txs))
+		}
+	}
+	b.ReportMetric(float64(len(txs)*b.N)/b.Elapsed().Seconds(), "sig/s")
+}
+
+func BenchmarkSigVerifyBatch(b *testing.B) {
+	benchSigVerify(b, ledger.ProcessTxListParallel)
+}
+
+func BenchmarkSigVerifySingle(b *testing.B) {
+	benchSigVerify(b, func(txs []ledger.Transaction) []ledger.Transaction {
+		return ledger.ExecuteTxsSerial(txs, ledger.ChainHeight(), ledger.Balances, ledger.NonceTable)
+	})
+}
@@ -0,0 +1,258 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+)
+
+// appendStateBlock: blok kosong dengan hash & state root asli di atas head.
+func appendStateBlock(t *testing.T) ledger.Block {
+	t.Helper()
+	head, ok := ledger.HeadBlock()
+	if !ok {
+		t.Fatal("no genesis")
+	}
+	b := ledger.NewBlock(head.Index+1, nil, head.Hash, ledger.StateRootForBlock(head.Index+1), nil)
+	if err := ledger.AppendBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func withSnapshotConfig(t *testing.T, keep int) {
+	old := ledger.Snapshots
+	ledger.Snapshots = ledger.SnapshotConfig{Keep: keep, Dir: t.TempDir()}
+	oldVals, oldTreasury := ledger.Validators, ledger.TreasuryBalance
+	t.Cleanup(func() {
+		ledger.Snapshots = old
+		ledger.Validators, ledger.TreasuryBalance = oldVals, oldTreasury
+	})
+}
+
+func localGenesisHash(t *testing.T) string {
+	t.Helper()
+	h, ok := ledger.LocalGenesisHash()
+	if !ok {
+		t.Fatal("no local genesis")
+	}
+	return h
+}
+
+func TestSnapshotRestoreAndContinue(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	w := testWallets(3)
+
+	setBalance(w[0].AddressEd, 100)
+	ledger.NonceTable[w[1].AddressEd] = 2
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 500}}
+	ledger.TreasuryBalance = 7
+	base := appendStateBlock(t)
+	info, err := ledger.CreateSnapshot()
+	if err != nil || info.Height != base.Index || info.StateRoot != base.StateRoot {
+		t.Fatalf("snapshot = %+v (%v)", info, err)
+	}
+
+	// state maju setelah snapshot; di luar blok → snapshot ditolak
+	setBalance(w[0].AddressEd, 1)
+	if _, err := ledger.CreateSnapshot(); !errors.Is(err, ledger.ErrSnapshotStateRoot) {
+		t.Fatalf("snapshot of uncommitted state err = %v", err)
+	}
+	appendStateBlock(t)
+
+	snap, err := ledger.LoadSnapshot(info.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trust := ledger.SnapshotTrust{GenesisHash: localGenesisHash(t), BlockHash: base.Hash}
+	if err := ledger.RestoreSnapshot(snap, trust, false); !errors.Is(err, ledger.ErrChainNotEmpty) {
+		t.Fatalf("restore over existing chain err = %v", err)
+	}
+	if err := ledger.RestoreSnapshot(snap, trust, true); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := ledger.HeadBlock(); head.Hash != base.Hash || ledger.ChainBase() != base.Index {
+		t.Fatalf("head %d/%.12s base %d, want %d", head.Index, head.Hash, ledger.ChainBase(), base.Index)
+	}
+	if ledger.GetBalance(w[0].AddressEd) != 100 || ledger.GetNextNonce(w[1].AddressEd) != 3 || ledger.TreasuryBalance != 7 {
+		t.Fatal("state not restored")
+	}
+	if _, ok := ledger.GetBlockByHeight(base.Index - 1); ok {
+		t.Fatal("blocks below snapshot should not exist")
+	}
+
+	// chain lanjut dari snapshot; history dimulai di height snapshot
+	setBalance(w[0].AddressEd, 60)
+	next := appendStateBlock(t)
+	if next.Index != base.Index+1 {
+		t.Fatalf("next block %d", next.Index)
+	}
+	for h, want := range map[int]ledger.Amount{base.Index: 100, next.Index: 60} {
+		if bal, err := ledger.GetBalanceAt(w[0].AddressEd, h); err != nil || bal != want {
+			t.Fatalf("balance @%d = %d (%v), want %d", h, bal, err, want)
+		}
+	}
+}
+
+func TestSnapshotChecksumAndRetention(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 2)
+
+	var last ledger.SnapshotInfo
+	for i := 0; i < 3; i++ {
+		appendStateBlock(t)
+		info, err := ledger.CreateSnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = info
+	}
+	list, err := ledger.ListSnapshots()
+	if err != nil || len(list) != 2 || list[1].Height != last.Height {
+		t.Fatalf("list = %+v (%v), want 2 newest", list, err)
+	}
+
+	data, err := os.ReadFile(last.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(last.Path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ledger.LoadSnapshot(last.Path); !errors.Is(err, ledger.ErrSnapshotCorrupt) {
+		t.Fatalf("tampered snapshot err = %v", err)
+	}
+}
+
+func TestSnapshotVerifyCoversSupplyAndEpoch(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	oldBurned := ledger.BurnedSupply
+	t.Cleanup(func() { ledger.BurnedSupply = oldBurned })
+	w := testWallets(1)
+
+	ledger.Validators = []ledger.ValidatorDef{{Address: w[0].AddressEd, Stake: 500}}
+	ledger.TreasuryBalance, ledger.BurnedSupply = 7, 3
+	// snapshot di blok terakhir epoch: set berikutnya baru & seed-nya bisa dicek
+	head := appendStateBlock(t)
+	for ledger.EpochOf(head.Index+1) == ledger.EpochOf(head.Index) {
+		head = appendStateBlock(t)
+	}
+	info, err := ledger.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ledger.LoadSnapshot(info.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		tamper func(s *ledger.Snapshot)
+		want   error
+	}{
+		"treasury": {func(s *ledger.Snapshot) { s.Treasury++ }, ledger.ErrSnapshotStateRoot},
+		"burned":   {func(s *ledger.Snapshot) { s.Burned++ }, ledger.ErrSnapshotStateRoot},
+		"epoch set": {func(s *ledger.Snapshot) {
+			ep := *s.Epoch
+			ep.TotalStake++
+			s.Epoch = &ep
+		}, ledger.ErrSnapshotStateRoot},
+		"epoch seed": {func(s *ledger.Snapshot) {
+			ep := *s.Epoch
+			ep.Seed = strings.Repeat("ab", 32)
+			s.Epoch = &ep
+		}, ledger.ErrSnapshotCorrupt},
+	}
+	for name, c := range cases {
+		tampered := *s
+		c.tamper(&tampered)
+		if err := tampered.Verify(); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, c.want)
+		}
+	}
+}
+
+func TestSnapshotRestoreRequiresTrustedGenesisAndBlock(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	withSnapshotConfig(t, 0)
+	w := testWallets(3)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	// blok snapshot = blok terakhir epoch: seed set berikutnya diturunkan darinya
+	head := appendStateBlock(t)
+	for ledger.EpochOf(head.Index+2) == ledger.EpochOf(head.Index+1) {
+		head = appendStateBlock(t)
+	}
+	b := committedBlock(t, w[2], w[2])
+	if err := ledger.ImportBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	info, err := ledger.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := ledger.LoadSnapshot(info.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Block.Commit == nil {
+		t.Fatal("snapshot block carries no commit certificate")
+	}
+	genesis, base := localGenesisHash(t), ledger.ChainBase()
+
+	other := fixedGenesis()
+	other.ChainID = snap.ChainID
+	otherJSON, _ := json.Marshal(other)
+	cases := map[string]struct {
+		tamper func(s *ledger.Snapshot, trust *ledger.SnapshotTrust)
+		want   error
+	}{
+		"no local genesis": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) { trust.GenesisHash = "" }, ledger.ErrSnapshotGenesis},
+		"other local genesis": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) {
+			trust.GenesisHash = other.Hash()
+		}, ledger.ErrSnapshotGenesis},
+		"snapshot from other genesis": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) { s.Genesis = otherJSON }, ledger.ErrSnapshotGenesis},
+		"snapshot without genesis":    {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) { s.Genesis = nil }, ledger.ErrSnapshotGenesis},
+		"trust hash of other block": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) {
+			trust.BlockHash = hexOf(0x55, 32)
+		}, ledger.ErrSnapshotUntrusted},
+		"block without commit": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) { s.Block.Commit = nil }, ledger.ErrSnapshotUntrusted},
+		"commit below quorum": {func(s *ledger.Snapshot, trust *ledger.SnapshotTrust) {
+			c := *s.Block.Commit
+			c.Sigs = nil
+			s.Block.Commit = &c
+		}, ledger.ErrSnapshotUntrusted},
+	}
+	for name, c := range cases {
+		tampered := *snap
+		trust := ledger.SnapshotTrust{GenesisHash: genesis}
+		c.tamper(&tampered, &trust)
+		if err := ledger.RestoreSnapshot(&tampered, trust, true); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, c.want)
+		}
+	}
+	if head, _ := ledger.HeadBlock(); head.Hash != b.Hash || ledger.ChainBase() != base {
+		t.Fatalf("rejected restore touched the chain: head %.12s base %d", head.Hash, ledger.ChainBase())
+	}
+
+	// tanpa trust hash: sertifikat commit lolos terhadap set epoch lokal
+	if err := ledger.RestoreSnapshot(snap, ledger.SnapshotTrust{GenesisHash: genesis}, true); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := ledger.HeadBlock(); head.Hash != b.Hash || ledger.ChainBase() != b.Index {
+		t.Fatalf("head %.12s base %d after restore", head.Hash, ledger.ChainBase())
+	}
+	if h, ok := ledger.LocalGenesisHash(); !ok || h != genesis {
+		t.Fatalf("genesis after restore = %.12s", h)
+	}
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func resetLedgerState() {
+	ledger.BalanceMu.Lock()
+	ledger.Balances = map[string]ledger.Amount{}
+	ledger.BalanceMu.Unlock()
+	ledger.NonceTableMu.Lock()
+	ledger.NonceTable = map[string]int{}
+	ledger.NonceTableMu.Unlock()
+	ledger.ClearMempool()
+}
+
+// spoofTx: attacker menandatangani dengan kuncinya sendiri tapi mengaku sebagai victim.
+func spoofTx(attacker *wallet.Wallet, victim string, amount ledger.Amount) ledger.Transaction {
+	tx := ledger.NewTransaction(attacker, attacker.AddressEd, amount)
+	tx.From = victim
+	tx.Nonce = ledger.GetNextNonce(victim)
+	tx.Signature = hex.EncodeToString(attacker.SignEd(ledger.TxSigningBytes(tx)))
+	return tx
+}
+
+func TestAddressFromPubKeyMatchesGeneratedWallet(t *testing.T) {
+	w := wallet.GenerateWallet()
+	if got := wallet.AddressFromPubKey(w.PubEd); got != w.AddressEd {
+		t.Fatalf("AddressFromPubKey = %s, want %s", got, w.AddressEd)
+	}
+}
+
+func TestMempoolAcceptsOwnTransaction(t *testing.T) {
+	resetLedgerState()
+	sender, recv := wallet.GenerateWallet(), wallet.GenerateWallet()
+	ledger.Balances[sender.AddressEd] = 10000
+
+	tx := ledger.NewTransaction(sender, recv.AddressEd, 10)
+	if err := ledger.ValidateAndAddToMempool(tx); err != nil {
+		t.Fatalf("valid tx rejected: %v", err)
+	}
+}
+
+func TestMempoolRejectsSpoofedSender(t *testing.T) {
+	resetLedgerState()
+	victim, attacker := wallet.GenerateWallet(), wallet.GenerateWallet()
+	ledger.Balances[victim.AddressEd] = 10000
+
+	tx := spoofTx(attacker, victim.AddressEd, 500)
+	if !ledger.VerifyTransaction(tx) {
+		t.Fatal("spoofed tx should carry a valid signature over its own payload")
+	}
+	if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+		t.Fatal("spoofed tx accepted into mempool")
+	}
+	if n := ledger.GetMempoolSize(); n != 0 {
+		t.Fatalf("mempool size = %d, want 0", n)
+	}
+}
+
+func TestExecutorRejectsSpoofedSender(t *testing.T) {
+	resetLedgerState()
+	victim, attacker := wallet.GenerateWallet(), wallet.GenerateWallet()
+	ledger.Balances[victim.AddressEd] = 10000
+
+	accepted := ledger.ProcessTxListParallel([]ledger.Transaction{spoofTx(attacker, victim.AddressEd, 500)})
+	if len(accepted) != 0 {
+		t.Fatalf("executor accepted %d spoofed tx", len(accepted))
+	}
+	if got := ledger.GetBalance(victim.AddressEd); got != 10000 {
+		t.Fatalf("victim balance = %d, want 10000", got)
+	}
+	if got := ledger.GetBalance(attacker.AddressEd); got != 0 {
+		t.Fatalf("attacker balance = %d, want 0", got)
+	}
+}
+
+func TestRejectsSwappedPubKeyWithVictimSignature(t *testing.T) {
+	resetLedgerState()
+	victim, attacker := wallet.GenerateWallet(), wallet.GenerateWallet()
+	ledger.Balances[victim.AddressEd] = 10000
+
+	// tx asli victim, pubkey diganti attacker → signature & binding sama-sama gagal
+	tx := ledger.NewTransaction(victim, attacker.AddressEd, 1)
+	tx.PubKey = hex.EncodeToString(attacker.PubEd)
+	if ledger.SenderMatchesPubKey(tx) {
+		t.Fatal("swapped pubkey must not match sender")
+	}
+	if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+		t.Fatal("tx with swapped pubkey accepted")
+	}
+}
+
+func TestRejectsMalformedPubKey(t *testing.T) {
+	resetLedgerState()
+	victim := wallet.GenerateWallet()
+	ledger.Balances[victim.AddressEd] = 10000
+
+	tx := ledger.NewTransaction(victim, victim.AddressEd, 1)
+	tx.PubKey = "abcd"
+	if ledger.VerifyTransaction(tx) || ledger.SenderMatchesPubKey(tx) {
+		t.Fatal("short pubkey must be rejected without panicking")
+	}
+	if accepted := ledger.ProcessTxListParallel([]ledger.Transaction{tx}); len(accepted) != 0 {
+		t.Fatal("executor accepted tx with malformed pubkey")
+	}
+}
+
+func TestTxEnvelopeRules(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 1_000_000
+	resign := func(tx ledger.Transaction) ledger.Transaction {
+		tx.Signature = hex.EncodeToString(w[0].SignEd(ledger.TxSigningBytes(tx)))
+		return tx
+	}
+	height := ledger.ChainHeight()
+
+	otherChain := ledger.NewTransaction(w[0], w[1].AddressEd, 10)
+	otherChain.ChainID += "-fork"
+	tamperedFee := ledger.NewTransaction(w[0], w[1].AddressEd, 10)
+	tamperedFee.Fee++ // tidak ditandatangani ulang
+	expired := ledger.NewTransaction(w[0], w[1].AddressEd, 10)
+	expired.ValidUntil = height - 1
+	longMemo := ledger.NewTransactionWithOpts(w[0], w[1].AddressEd, 10, ledger.TxOptions{Memo: strings.Repeat("m", ledger.MaxMemoLen+1)})
+
+	for name, tx := range map[string]ledger.Transaction{
+		"wrong chain id": resign(otherChain),
+		"tampered fee":   tamperedFee,
+		"expired":        resign(expired),
+		"memo > 256":     longMemo,
+	} {
+		if err := ledger.ValidateAndAddToMempool(tx); err == nil {
+			t.Errorf("%s: accepted into mempool", name)
+		}
+		if errs := ledger.ExecuteTxsResults([]ledger.Transaction{tx}, height, map[string]ledger.Amount{w[0].AddressEd: 1_000_000}, map[string]int{}); errs[0] == nil {
+			t.Errorf("%s: accepted by executor", name)
+		}
+	}
+	if ledger.VerifyTransaction(tamperedFee) {
+		t.Fatal("signature must cover the fee")
+	}
+	if ledger.GetMempoolSize() != 0 {
+		t.Fatalf("mempool size = %d", ledger.GetMempoolSize())
+	}
+
+	// batas memo inklusif
+	ok := ledger.NewTransactionWithOpts(w[0], w[1].AddressEd, 10, ledger.TxOptions{Memo: strings.Repeat("m", ledger.MaxMemoLen)})
+	if err := ledger.ValidateAndAddToMempool(ok); err != nil {
+		t.Fatalf("memo of %d bytes rejected: %v", ledger.MaxMemoLen, err)
+	}
+}
@@ -0,0 +1,253 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+func feeTx(w *wallet.Wallet, to string, nonce int, fee ledger.Amount) ledger.Transaction {
+	return ledger.NewTransactionWithOpts(w, to, 1, ledger.TxOptions{Nonce: nonce, Fee: fee})
+}
+
+func TestTxPoolRejectsDuplicate(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	tx := feeTx(w[0], w[1].AddressEd, 1, 0)
+	if err := pool.Add(tx, 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Add(tx, 0, 1_000_000); !errors.Is(err, ledger.ErrTxKnown) {
+		t.Fatalf("duplicate: err=%v, want ErrTxKnown", err)
+	}
+}
+
+func TestTxPoolParksFutureNonce(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 3, 0), 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 2, 0), 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(pool.Pending(0)); got != 0 {
+		t.Fatalf("pending with gap = %d, want 0", got)
+	}
+	if _, queued := pool.Stats(); queued != 2 {
+		t.Fatalf("queued = %d, want 2", queued)
+	}
+
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 1, 0), 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	pending := pool.Pending(0)
+	if len(pending) != 3 {
+		t.Fatalf("pending after gap filled = %d, want 3", len(pending))
+	}
+	for i, tx := range pending {
+		if tx.Nonce != i+1 {
+			t.Fatalf("pending[%d].Nonce = %d", i, tx.Nonce)
+		}
+	}
+}
+
+func TestTxPoolNonceBounds(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	cfg := ledger.DefaultTxPoolConfig()
+	cfg.MaxNonceGap = 4
+	pool := ledger.NewTxPool(cfg)
+
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 5, 0), 5, 1_000_000); !errors.Is(err, ledger.ErrNonceTooLow) {
+		t.Fatalf("err=%v, want ErrNonceTooLow", err)
+	}
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 10, 0), 5, 1_000_000); !errors.Is(err, ledger.ErrNonceTooHigh) {
+		t.Fatalf("err=%v, want ErrNonceTooHigh", err)
+	}
+}
+
+func TestTxPoolOrdersByFeePerByte(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(3)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	low := feeTx(w[0], w[2].AddressEd, 1, 0)
+	high := feeTx(w[1], w[2].AddressEd, 1, 10*low.Fee)
+	for _, tx := range []ledger.Transaction{low, high} {
+		if err := pool.Add(tx, 0, 1_000_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending := pool.Pending(0)
+	if len(pending) != 2 || pending[0].From != w[1].AddressEd {
+		t.Fatalf("highest fee tx not first: %+v", pending)
+	}
+}
+
+func TestTxPoolReplaceByFee(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	orig := feeTx(w[0], w[1].AddressEd, 1, 0)
+	if err := pool.Add(orig, 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	// +1 fee < bump minimal 10%
+	small := feeTx(w[0], w[1].AddressEd, 1, orig.Fee+1)
+	if err := pool.Add(small, 0, 1_000_000); !errors.Is(err, ledger.ErrReplaceUnderpriced) {
+		t.Fatalf("err=%v, want ErrReplaceUnderpriced", err)
+	}
+	bumped := feeTx(w[0], w[1].AddressEd, 1, orig.Fee*2)
+	if err := pool.Add(bumped, 0, 1_000_000); err != nil {
+		t.Fatalf("replacement rejected: %v", err)
+	}
+	pending := pool.Pending(0)
+	if pool.Len() != 1 || len(pending) != 1 || pending[0].Fee != bumped.Fee {
+		t.Fatalf("pool after RBF: len=%d pending=%+v", pool.Len(), pending)
+	}
+}
+
+func TestTxPoolEvictsLowestFee(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(4)
+	cfg := ledger.DefaultTxPoolConfig()
+	cfg.MaxSize = 2
+	pool := ledger.NewTxPool(cfg)
+
+	base := feeTx(w[0], w[3].AddressEd, 1, 0).Fee
+	cheap := feeTx(w[0], w[3].AddressEd, 1, base)
+	mid := feeTx(w[1], w[3].AddressEd, 1, base*2)
+	rich := feeTx(w[2], w[3].AddressEd, 1, base*3)
+
+	for _, tx := range []ledger.Transaction{cheap, mid} {
+		if err := pool.Add(tx, 0, 1_000_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pool.Add(feeTx(w[2], w[3].AddressEd, 1, base), 0, 1_000_000); !errors.Is(err, ledger.ErrPoolFull) {
+		t.Fatalf("equal-fee tx into full pool: err=%v, want ErrPoolFull", err)
+	}
+	if err := pool.Add(rich, 0, 1_000_000); err != nil {
+		t.Fatalf("higher-fee tx rejected: %v", err)
+	}
+	if pool.Len() != 2 {
+		t.Fatalf("len = %d, want 2", pool.Len())
+	}
+	for _, tx := range pool.All() {
+		if tx.From == w[0].AddressEd {
+			t.Fatal("lowest-fee tx was not evicted")
+		}
+	}
+}
+
+func TestTxPoolCumulativeBalance(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	tx1 := feeTx(w[0], w[1].AddressEd, 1, 0)
+	cost := tx1.Amount + tx1.Fee
+	if err := pool.Add(tx1, 0, cost+cost/2); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 2, 0), 0, cost+cost/2); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("err=%v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestTxPoolTTL(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	cfg := ledger.DefaultTxPoolConfig()
+	cfg.TTL = 10 * time.Millisecond
+	pool := ledger.NewTxPool(cfg)
+
+	if err := pool.Add(feeTx(w[0], w[1].AddressEd, 1, 0), 0, 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := pool.Expire(); n != 1 || pool.Len() != 0 {
+		t.Fatalf("expired=%d len=%d, want 1/0", n, pool.Len())
+	}
+}
+
+func TestMempoolNextNonceSkipsPending(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 1_000_000
+
+	for i := 0; i < 3; i++ {
+		if err := ledger.ValidateAndAddToMempool(ledger.NewTransaction(w[0], w[1].AddressEd, 1)); err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+	}
+	if got := ledger.GetNextNonce(w[0].AddressEd); got != 4 {
+		t.Fatalf("next nonce = %d, want 4", got)
+	}
+}
+
+// underpaid: TX ditandatangani ulang dengan fee di bawah fee minimal.
+func underpaid(w, to *wallet.Wallet) ledger.Transaction {
+	tx := feeTx(w, to.AddressEd, 1, 0)
+	tx.Fee = ledger.CalculateFee(tx) - 1
+	tx.Signature = hex.EncodeToString(w.SignEd(ledger.TxSigningBytes(tx)))
+	return tx
+}
+
+func TestTxPoolRejectsFeeBelowMinimum(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	pool := ledger.NewTxPool(ledger.DefaultTxPoolConfig())
+
+	if err := pool.Add(underpaid(w[0], w[1]), 0, 1_000_000); !errors.Is(err, ledger.ErrTxFee) {
+		t.Fatalf("err=%v, want ErrTxFee", err)
+	}
+}
+
+func TestExecuteRejectsFeeBelowMinimum(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	balances := map[string]ledger.Amount{w[0].AddressEd: 1_000_000}
+	errs := ledger.ExecuteTxsResults([]ledger.Transaction{underpaid(w[0], w[1])}, 1, balances, map[string]int{})
+	if !errors.Is(errs[0], ledger.ErrTxFee) {
+		t.Fatalf("err=%v, want ErrTxFee", errs[0])
+	}
+}
+
+func TestMempoolKeepsAdmissionTimeAcrossRestart(t *testing.T) {
+	resetLedgerState()
+	w := testWallets(2)
+	ledger.Balances[w[0].AddressEd] = 1_000_000
+
+	saved := ledger.Pool
+	defer func() { ledger.Pool = saved }()
+	cfg := ledger.DefaultTxPoolConfig()
+	cfg.TTL = 50 * time.Millisecond
+	ledger.Pool = ledger.NewTxPool(cfg)
+
+	if err := ledger.ValidateAndAddToMempool(ledger.NewTransaction(w[0], w[1].AddressEd, 1)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	ledger.SaveMempool()
+
+	// restart: pool baru dari DB, TTL dihitung dari waktu masuk semula
+	ledger.Pool = ledger.NewTxPool(cfg)
+	ledger.LoadMempool()
+	if ledger.Pool.Len() != 1 {
+		t.Fatalf("restored len = %d, want 1", ledger.Pool.Len())
+	}
+	if n := ledger.Pool.Expire(); n != 1 {
+		t.Fatalf("expired after restart = %d, want 1", n)
+	}
+}
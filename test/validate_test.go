@@ -0,0 +1,123 @@
+package test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/network"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// resealBlock: hitung ulang merkle root & hash header setelah blok diubah,
+// lalu tanda tangani ulang (proposer & precommit signers) supaya validasi
+// sampai ke pemeriksaan yang sedang diuji.
+func resealBlock(t *testing.T, b ledger.Block, proposer *wallet.Wallet, signers ...*wallet.Wallet) ledger.Block {
+	t.Helper()
+	b.MerkleRoot = ledger.ComputeMerkleRoot(b.Transactions)
+	b.Hash = b.HeaderHash()
+	hash, _ := hex.DecodeString(b.Hash)
+	b.Signature = hex.EncodeToString(proposer.SignEd(hash))
+	set := ledger.NewVoteSet(b.Index, b.Commit.Round, ledger.VotePrecommit, ledger.ActiveValidators(b.Index))
+	for _, s := range signers {
+		if _, err := set.Add(ledger.SignVote(s, ledger.VotePrecommit, b.Index, b.Commit.Round, b.Hash)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var err error
+	if b.Commit, err = set.CommitCert(b.Hash); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestImportRejectsInvalidBlockWithTypedError(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	appendStateBlock(t)
+
+	setBalance(w[0].AddressEd, 10000)
+	for nonce := 1; nonce <= 2; nonce++ {
+		if err := ledger.ValidateAndAddToMempool(txWithNonce(w[0], w[1].AddressEd, 10, nonce)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := committedBlock(t, w[2], w[2])
+	if len(b.Transactions) != 2 {
+		t.Fatalf("block carries %d tx, want 2", len(b.Transactions))
+	}
+	head, _ := ledger.HeadBlock()
+
+	cases := []struct {
+		name   string
+		mutate func(b ledger.Block) ledger.Block
+		want   error
+	}{
+		{"known head", func(ledger.Block) ledger.Block { return head }, ledger.ErrBlockKnown},
+		{"index behind head", func(b ledger.Block) ledger.Block { b.Index = head.Index; return b }, ledger.ErrBlockIndex},
+		{"index ahead of head", func(b ledger.Block) ledger.Block { b.Index += 2; return b }, ledger.ErrBlockAhead},
+		{"prev hash", func(b ledger.Block) ledger.Block { b.PrevHash = hexOf(0x11, 32); return b }, ledger.ErrBlockPrevHash},
+		{"merkle root", func(b ledger.Block) ledger.Block { b.MerkleRoot = hexOf(0x22, 32); return b }, ledger.ErrBlockMerkleRoot},
+		{"state root", func(b ledger.Block) ledger.Block {
+			b.StateRoot = hexOf(0x33, 32)
+			return resealBlock(t, b, w[2], w[2])
+		}, ledger.ErrBlockStateRoot},
+		{"tx order breaks nonce", func(b ledger.Block) ledger.Block {
+			// kedua TX tetap tercampur di PoH, tapi nonce 2 dieksekusi lebih dulu
+			b.Transactions = []ledger.Transaction{b.Transactions[1], b.Transactions[0]}
+			return resealBlock(t, b, w[2], w[2])
+		}, ledger.ErrBlockTx},
+	}
+	for _, tc := range cases {
+		bad := tc.mutate(b)
+		if err := ledger.ImportBlock(bad); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: import err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if ledger.ChainHeight() != head.Index+1 {
+		t.Fatalf("rejected blocks changed the chain: height %d", ledger.ChainHeight())
+	}
+	if err := ledger.ImportBlock(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGossipReportsOnlyInvalidBlocks(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(3)
+	withBFTValidators(t, []ledger.ValidatorDef{{Address: w[2].AddressEd, Stake: 100}})
+	appendStateBlock(t)
+
+	b := committedBlock(t, w[2], w[2])
+	ahead := b
+	ahead.Index += 2
+	badPrev := b
+	badPrev.PrevHash = hexOf(0x44, 32)
+
+	const peer = "peer-gossip-test"
+	before := network.MisbehaviourCount(peer)
+	receive := func(blk ledger.Block) error {
+		_, err := network.ReceiveBlock(peer, ledger.EncodeBlock(blk))
+		return err
+	}
+
+	if err := receive(ahead); !errors.Is(err, ledger.ErrBlockAhead) || network.MisbehaviourCount(peer) != before {
+		t.Fatalf("block ahead: err = %v, misbehaviour %d", err, network.MisbehaviourCount(peer)-before)
+	}
+	if err := receive(badPrev); !errors.Is(err, ledger.ErrBlockPrevHash) || network.MisbehaviourCount(peer) != before+1 {
+		t.Fatalf("invalid block: err = %v, misbehaviour %d", err, network.MisbehaviourCount(peer)-before)
+	}
+	if _, err := network.ReceiveBlock(peer, []byte("not a block")); err == nil || network.MisbehaviourCount(peer) != before+2 {
+		t.Fatalf("garbage payload: err = %v, misbehaviour %d", err, network.MisbehaviourCount(peer)-before)
+	}
+	if err := receive(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := receive(b); !errors.Is(err, ledger.ErrBlockKnown) || network.MisbehaviourCount(peer) != before+2 {
+		t.Fatalf("known block: err = %v, misbehaviour %d", err, network.MisbehaviourCount(peer)-before)
+	}
+}
@@ -0,0 +1,283 @@
+package test
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/soden46/hyperlux-chain/crypto"
+	"github.com/soden46/hyperlux-chain/ledger"
+	"github.com/soden46/hyperlux-chain/wallet"
+)
+
+// RFC 9381 Appendix B.3 (ECVRF-EDWARDS25519-SHA512-TAI).
+var vrfVectors = []struct{ sk, pk, alpha, pi, beta string }{
+	{
+		sk:    "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		pk:    "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+		alpha: "",
+		pi:    "8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805",
+		beta:  "90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae",
+	},
+	{
+		sk:    "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+		pk:    "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+		alpha: "72",
+		pi:    "f3141cd382dc42909d19ec5110469e4feae18300e94f304590abdced48aed5933bf0864a62558b3ed7f2fea45c92a465301b3bbf5e3e54ddf2d935be3b67926da3ef39226bbc355bdc9850112c8f4b02",
+		beta:  "eb4440665d3891d668e7e0fcaf587f1b4bd7fbfe99d0eb2211ccec90496310eb5e33821bc613efb94db5e5b54c70a848a0bef4553a41befc57663b56373a5031",
+	},
+	{
+		sk:    "c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+		pk:    "fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025",
+		alpha: "af82",
+		pi:    "9bc0f79119cc5604bf02d23b4caede71393cedfbb191434dd016d30177ccbf8096bb474e53895c362d8628ee9f9ea3c0e52c7a5c691b6c18c9979866568add7a2d41b00b05081ed0f58ee5e31b3a970e",
+		beta:  "645427e5d00c62a23fb703732fa5d892940935942101e456ecca7bb217c61c452118fec1219202a0edcf038bb6373241578be7217ba85a2687f7a0310b2df19f",
+	},
+}
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVRFRFC9381Vectors(t *testing.T) {
+	for i, v := range vrfVectors {
+		priv := ed25519.NewKeyFromSeed(unhex(t, v.sk))
+		pub := priv.Public().(ed25519.PublicKey)
+		if hex.EncodeToString(pub) != v.pk {
+			t.Fatalf("vector %d: pk = %x", i, pub)
+		}
+		alpha := unhex(t, v.alpha)
+		pi, err := crypto.VRFProve(priv, alpha)
+		if err != nil || len(pi) != crypto.VRFProofLen {
+			t.Fatalf("vector %d: prove err=%v len=%d", i, err, len(pi))
+		}
+		if hex.EncodeToString(pi) != v.pi {
+			t.Fatalf("vector %d: pi = %x", i, pi)
+		}
+		beta, err := crypto.VRFVerify(pub, alpha, pi)
+		if err != nil || hex.EncodeToString(beta) != v.beta {
+			t.Fatalf("vector %d: beta = %x (%v)", i, beta, err)
+		}
+
+		// proof hanya valid untuk (pk, alpha) & tidak bisa diubah
+		if _, err := crypto.VRFVerify(pub, append(alpha, 0), pi); !errors.Is(err, crypto.ErrVRFProof) {
+			t.Fatalf("vector %d: other alpha err = %v", i, err)
+		}
+		other := ed25519.NewKeyFromSeed(unhex(t, vrfVectors[(i+1)%len(vrfVectors)].sk)).Public().(ed25519.PublicKey)
+		if _, err := crypto.VRFVerify(other, alpha, pi); !errors.Is(err, crypto.ErrVRFProof) {
+			t.Fatalf("vector %d: other key err = %v", i, err)
+		}
+		bad := append([]byte(nil), pi...)
+		bad[40] ^= 1 // challenge c
+		if _, err := crypto.VRFVerify(pub, alpha, bad); !errors.Is(err, crypto.ErrVRFProof) {
+			t.Fatalf("vector %d: tampered proof err = %v", i, err)
+		}
+	}
+	// identity point (small order) bukan public key yang sah
+	identity := make([]byte, 32)
+	identity[0] = 1
+	if _, err := crypto.VRFVerify(identity, nil, make([]byte, crypto.VRFProofLen)); !errors.Is(err, crypto.ErrVRFPublicKey) {
+		t.Fatalf("small-order pk err = %v", err)
+	}
+}
+
+// proposerTicket: proof VRF w untuk round pertama di mana w lolos undian di atas head.
+func proposerTicket(t *testing.T, w *wallet.Wallet) ledger.VRFHeader {
+	t.Helper()
+	head, _ := ledger.HeadBlock()
+	seed := ledger.EpochSeed(head, head.Index+1)
+	for r := 0; r < 100; r++ {
+		vrf, _, ok, err := ledger.ProveProposer(w, seed, head.Index+1, r, ledger.ActiveValidators(head.Index+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			return vrf
+		}
+	}
+	t.Fatal("validator never eligible")
+	return ledger.VRFHeader{}
+}
+
+func TestVRFProposerEligibilityIsStakeWeighted(t *testing.T) {
+	wins := 0
+	const draws = 4000
+	for i := 0; i < draws; i++ {
+		beta := sha256.Sum256([]byte(strconv.Itoa(i)))
+		if ledger.ProposerEligible(beta[:], 70, 100, 0) {
+			wins++
+		}
+		if !ledger.ProposerEligible(beta[:], 100, 100, 0) || !ledger.ProposerEligible(beta[:], 30, 100, 3) {
+			t.Fatal("full stake / late round must always be eligible")
+		}
+		if ledger.ProposerEligible(beta[:], 0, 100, 5) {
+			t.Fatal("zero stake must never be eligible")
+		}
+	}
+	if wins < draws*65/100 || wins > draws*75/100 {
+		t.Fatalf("70%% stake won %d/%d draws", wins, draws)
+	}
+}
+
+func TestBlockVRFProofVerifiedOnValidation(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 70},
+		{Address: w[3].AddressEd, Stake: 30},
+	})
+	head, _ := ledger.HeadBlock()
+	height := head.Index + 1
+	seed := ledger.EpochSeed(head, height)
+
+	vrf := proposerTicket(t, w[2])
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], vrf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ValidateBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ledger.DecodeBlock(ledger.EncodeBlock(b))
+	if err != nil || decoded.VRF == nil || *decoded.VRF != vrf || decoded.Hash != b.Hash {
+		t.Fatalf("decoded vrf = %+v (%v)", decoded.VRF, err)
+	}
+
+	// proof orang lain, round yang kalah undian, seed karangan, tanpa proof
+	var lost ledger.VRFHeader
+	for r := 0; ; r++ {
+		v, _, ok, err := ledger.ProveProposer(w[3], seed, height, r, ledger.Validators)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			lost = v
+			break
+		}
+	}
+	forged, _, ok, _ := ledger.ProveProposer(w[2], hex.EncodeToString(make([]byte, 32)), height, 3, ledger.Validators)
+	if !ok {
+		t.Fatal("round 3 must be eligible for 70% stake")
+	}
+	for name, c := range map[string]struct {
+		val *ledger.ValidatorDef
+		w   *wallet.Wallet
+		vrf ledger.VRFHeader
+	}{
+		"foreign proof": {&ledger.Validators[0], w[2], proposerTicket(t, w[3])},
+		"lost draw":     {&ledger.Validators[1], w[3], lost},
+		"wrong seed":    {&ledger.Validators[0], w[2], forged},
+	} {
+		bad, _, _, err := ledger.BuildProposalBlock(c.val, c.w, c.vrf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ledger.ValidateBlock(bad); !errors.Is(err, ledger.ErrBlockProposer) {
+			t.Fatalf("%s: err = %v", name, err)
+		}
+	}
+	legacy := ledger.NewBlock(height, nil, head.Hash, b.StateRoot, w[2])
+	if err := ledger.ValidateBlock(legacy); !errors.Is(err, ledger.ErrBlockProposer) {
+		t.Fatalf("block without proof err = %v", err)
+	}
+
+	// proposal membawa proof round-nya sendiri
+	p := ledger.SignProposal(w[2], height, vrf.Round, b, vrf.Proof)
+	got, err := ledger.DecodeProposal(ledger.EncodeProposal(p))
+	if err != nil || got.VRFProof != vrf.Proof || ledger.VerifyProposal(got) != nil {
+		t.Fatalf("proposal roundtrip = %+v (%v)", got, err)
+	}
+}
+
+func TestEpochSeedChainsVRFOutputNotBlockHash(t *testing.T) {
+	w := testWallets(1)
+	vals := []ledger.ValidatorDef{{Address: w[0].AddressEd, Stake: 1}}
+	genesis := ledger.Block{Index: 0, Hash: "aa"}
+	seed := ledger.EpochSeed(genesis, 1)
+
+	vrf, _, ok, err := ledger.ProveProposer(w[0], seed, ledger.Params.EpochLength-1, 0, vals)
+	if err != nil || !ok {
+		t.Fatalf("single validator must win: %v", err)
+	}
+	parent := ledger.Block{Index: ledger.Params.EpochLength - 1, Hash: "bb", VRF: &vrf}
+	if ledger.EpochSeed(parent, ledger.Params.EpochLength-1) != seed {
+		t.Fatal("seed must stay fixed within an epoch")
+	}
+	next := ledger.EpochSeed(parent, ledger.Params.EpochLength)
+	if next == seed {
+		t.Fatal("seed must change at the epoch boundary")
+	}
+	// isi / hash blok tidak memengaruhi seed berikutnya (tidak bisa di-grind)
+	ground := parent
+	ground.Hash, ground.Timestamp = "cc", 99
+	if ledger.EpochSeed(ground, ledger.Params.EpochLength) != next {
+		t.Fatal("epoch seed depends on block hash")
+	}
+}
+
+func TestForgedHighRoundVRFRejected(t *testing.T) {
+	initTempLedger(t)
+	resetLedgerState()
+	w := testWallets(4)
+	withBFTValidators(t, []ledger.ValidatorDef{
+		{Address: w[2].AddressEd, Stake: 10},
+		{Address: w[3].AddressEd, Stake: 90},
+	})
+	appendStateBlock(t) // set validator tercatat di history sebelum blok berikutnya
+	head, _ := ledger.HeadBlock()
+	height := head.Index + 1
+	seed := ledger.EpochSeed(head, height)
+
+	// round di atas batas: stake × (round+1) ≥ total tapi tetap tidak eligible
+	high, _, ok, err := ledger.ProveProposer(w[2], seed, height, 1000, ledger.Validators)
+	if err != nil || ok {
+		t.Fatalf("round 1000 eligible=%v (%v)", ok, err)
+	}
+	b, _, _, err := ledger.BuildProposalBlock(&ledger.Validators[0], w[2], high)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.ValidateBlock(b); !errors.Is(err, ledger.ErrBlockProposer) {
+		t.Fatalf("round over limit err = %v", err)
+	}
+
+	// round 9 selalu lolos untuk 10% stake, tapi commit hanya di round 0
+	late, _, ok, _ := ledger.ProveProposer(w[2], seed, height, 9, ledger.Validators)
+	if !ok {
+		t.Fatal("round 9 must be eligible for 10% stake")
+	}
+	b, _, _, err = ledger.BuildProposalBlock(&ledger.Validators[0], w[2], late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certAt := func(round int) *ledger.CommitCert {
+		set := ledger.NewVoteSet(b.Index, round, ledger.VotePrecommit, ledger.Validators)
+		for _, v := range w[2:] {
+			if _, err := set.Add(ledger.SignVote(v, ledger.VotePrecommit, b.Index, round, b.Hash)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cert, err := set.CommitCert(b.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	b.Commit = certAt(0)
+	if err := ledger.ImportBlock(b); !errors.Is(err, ledger.ErrBlockProposer) {
+		t.Fatalf("vrf round above commit round err = %v", err)
+	}
+	b.Commit = certAt(9)
+	if err := ledger.ImportBlock(b); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,261 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ================== Address format ==================
+//
+// Address = bech32m(hrp="hlc", [keyType] ++ sha256(keyType || pubkey)[:20])
+// contoh: hlc1qxyz... — checksum 6 karakter menangkap salah ketik.
+//
+// Format lama ("hlcEd"/"hlcSec" + 4 byte pertama pubkey/hash) hanya 32-bit
+// sehingga gampang dicari tabrakannya; lihat IsLegacyAddress & LegacyAddressFromPubKey.
+
+const (
+	AddressHRP      = "hlc"
+	AddressHashSize = 20
+)
+
+type KeyType byte
+
+const (
+	KeyEd25519   KeyType = 0
+	KeySecp256k1 KeyType = 1
+)
+
+type Address struct {
+	Type KeyType
+	Hash [AddressHashSize]byte
+}
+
+var (
+	ErrAddressChecksum = errors.New("address checksum salah (typo?)")
+	ErrAddressHRP      = errors.New("address prefix bukan " + AddressHRP)
+	ErrAddressFormat   = errors.New("format address tidak valid")
+	ErrAddressLegacy   = errors.New("address format lama, migrasi dulu ke format baru")
+)
+
+// NewAddress: hash pubkey dengan domain key type supaya ed25519 & secp256k1 tidak bertabrakan.
+func NewAddress(t KeyType, pub []byte) Address {
+	buf := make([]byte, 0, 1+len(pub))
+	buf = append(buf, byte(t))
+	buf = append(buf, pub...)
+	sum := sha256.Sum256(buf)
+	a := Address{Type: t}
+	copy(a.Hash[:], sum[:AddressHashSize])
+	return a
+}
+
+// RandomAddress: address acak tanpa pemilik (untuk stress test / tujuan dummy).
+func RandomAddress() string {
+	a := Address{Type: KeyEd25519}
+	_, _ = rand.Read(a.Hash[:])
+	return a.String()
+}
+
+func (a Address) String() string {
+	data := append([]byte{byte(a.Type)}, convertBits(a.Hash[:], 8, 5, true)...)
+	return bech32Encode(AddressHRP, data)
+}
+
+// ParseAddress decode + verifikasi checksum, prefix, key type & panjang hash.
+func ParseAddress(s string) (Address, error) {
+	if IsLegacyAddress(s) {
+		return Address{}, ErrAddressLegacy
+	}
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return Address{}, err
+	}
+	if hrp != AddressHRP {
+		return Address{}, ErrAddressHRP
+	}
+	if len(data) < 1 {
+		return Address{}, ErrAddressFormat
+	}
+	t := KeyType(data[0])
+	if t != KeyEd25519 && t != KeySecp256k1 {
+		return Address{}, fmt.Errorf("%w: key type %d tidak dikenal", ErrAddressFormat, t)
+	}
+	hash, err := convertBitsStrict(data[1:], 5, 8)
+	if err != nil || len(hash) != AddressHashSize {
+		return Address{}, fmt.Errorf("%w: panjang hash salah", ErrAddressFormat)
+	}
+	a := Address{Type: t}
+	copy(a.Hash[:], hash)
+	return a, nil
+}
+
+// ValidateAddress: nil jika s address format baru yang valid.
+func ValidateAddress(s string) error {
+	_, err := ParseAddress(s)
+	return err
+}
+
+// ================== Legacy ==================
+
+// IsLegacyAddress: "hlcEd"/"hlcSec"/"hlcRnd" + 8 hex (format sebelum bech32).
+func IsLegacyAddress(s string) bool {
+	for _, p := range []string{"hlcEd", "hlcSec", "hlcRnd"} {
+		if strings.HasPrefix(s, p) {
+			rest := s[len(p):]
+			if len(rest) != 8 {
+				return false
+			}
+			_, err := hex.DecodeString(rest)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// LegacyAddressFromPubKey: derivasi lama untuk Ed25519, dipakai saat migrasi saldo.
+func LegacyAddressFromPubKey(pub []byte) string {
+	if len(pub) < 4 {
+		return ""
+	}
+	return "hlcEd" + hex.EncodeToString(pub[:4])
+}
+
+// LegacySecAddressFromPubKey: derivasi lama untuk secp256k1.
+func LegacySecAddressFromPubKey(pub []byte) string {
+	h := sha256.Sum256(pub)
+	return "hlcSec" + hex.EncodeToString(h[:4])
+}
+
+// ================== bech32m (BIP-350) ==================
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32mConst  = 0x2bc830a3
+)
+
+var bech32Gen = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ bech32mConst
+	out := make([]byte, 6)
+	for i := 0; i < 6; i++ {
+		out[i] = byte((mod >> uint(5*(5-i))) & 31)
+	}
+	return out
+}
+
+func bech32Encode(hrp string, data []byte) string {
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, d := range append(data, bech32Checksum(hrp, data)...) {
+		sb.WriteByte(bech32Charset[d])
+	}
+	return sb.String()
+}
+
+// Bech32mDecode: decode string bech32m (BIP-350) dengan HRP apa pun; data =
+// nilai 5-bit tanpa checksum. Untuk address pakai ParseAddress.
+func Bech32mDecode(s string) (hrp string, data []byte, err error) {
+	return bech32Decode(s)
+}
+
+func bech32Decode(s string) (string, []byte, error) {
+	if len(s) > 90 || strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, ErrAddressFormat
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return "", nil, fmt.Errorf("%w: karakter %q", ErrAddressFormat, s[i])
+		}
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, ErrAddressFormat
+	}
+	hrp := s[:pos]
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		idx := strings.IndexByte(bech32Charset, s[i])
+		if idx < 0 {
+			return "", nil, fmt.Errorf("%w: karakter %q", ErrAddressFormat, s[i])
+		}
+		data = append(data, byte(idx))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != bech32mConst {
+		return "", nil, ErrAddressChecksum
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+func convertBits(data []byte, from, to uint, pad bool) []byte {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, v := range data {
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte((acc>>bits)&maxv))
+		}
+	}
+	if pad && bits > 0 {
+		out = append(out, byte((acc<<(to-bits))&maxv))
+	}
+	return out
+}
+
+// convertBitsStrict: tanpa padding; sisa bit harus < from dan bernilai nol.
+func convertBitsStrict(data []byte, from, to uint) ([]byte, error) {
+	acc, bits := uint32(0), uint(0)
+	maxv := uint32(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to))
+	for _, v := range data {
+		if uint32(v)>>from != 0 {
+			return nil, ErrAddressFormat
+		}
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte((acc>>bits)&maxv))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxv != 0 {
+		return nil, ErrAddressFormat
+	}
+	return out, nil
+}
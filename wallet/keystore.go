@@ -128,16 +128,16 @@ func LoadKeystore(filename, password string) (*Wallet, error) {
 	privSec := secp256k1.PrivKeyFromBytes(privSecBytes)
 	pubSec := privSec.PubKey().SerializeCompressed()
 
-	// kalau AddressSec kosong di file, generate lagi dari pubSec
-	addrSec := ks.AddressSec
-	if addrSec == "" {
-		h := sha256.Sum256(pubSec)
-		addrSec = "hlcSec" + hex.EncodeToString(h[:4])
+	// address diturunkan ulang dari pubkey (keystore lama menyimpan format legacy)
+	addrSec := AddressFromSecPubKey(pubSec)
+	addrEd := ks.AddressEd
+	if len(privEd) == 64 {
+		addrEd = AddressFromPubKey(privEd[32:])
 	}
 
 	// reconstruct wallet dengan dua keypair
 	return &Wallet{
-		AddressEd:  ks.AddressEd,
+		AddressEd:  addrEd,
 		PrivEd:     privEd,
 		PubEd:      privEd[32:], // Ed25519: pubKey = last 32 bytes
 		AddressSec: addrSec,
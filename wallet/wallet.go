@@ -2,7 +2,6 @@ package wallet
 
 import (
 	"crypto/ed25519"
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -26,11 +25,25 @@ type Wallet struct {
 	PrivSec    *secp256k1.PrivateKey
 }
 
+// AddressFromPubKey menurunkan address Ed25519 dari public key.
+// Satu-satunya tempat derivasi address; verifikasi TX/blok memakai fungsi ini juga.
+func AddressFromPubKey(pub ed25519.PublicKey) string {
+	if len(pub) != ed25519.PublicKeySize {
+		return ""
+	}
+	return NewAddress(KeyEd25519, pub).String()
+}
+
+// AddressFromSecPubKey menurunkan address secp256k1 dari compressed public key.
+func AddressFromSecPubKey(pub []byte) string {
+	return NewAddress(KeySecp256k1, pub).String()
+}
+
 // GenerateWallet membuat wallet baru dengan Ed25519 + secp256k1
 func GenerateWallet() *Wallet {
 	// ===== Ed25519 =====
 	pubEd, privEd, _ := ed25519.GenerateKey(nil)
-	addrEd := "hlcEd" + hex.EncodeToString(pubEd[:4])
+	addrEd := AddressFromPubKey(pubEd)
 
 	// ===== secp256k1 =====
 	privSec, err := secp256k1.GeneratePrivateKey()
@@ -40,8 +53,7 @@ func GenerateWallet() *Wallet {
 	pubSec := privSec.PubKey().SerializeCompressed()
 
 	// buat address dari hash public key
-	h := sha256.Sum256(pubSec)
-	addrSec := "hlcSec" + hex.EncodeToString(h[:4])
+	addrSec := AddressFromSecPubKey(pubSec)
 
 	return &Wallet{
 		AddressEd:  addrEd,
@@ -87,16 +99,30 @@ func LoadWallet(filename string) (*Wallet, error) {
 	privSec := secp256k1.PrivKeyFromBytes(privSecBytes)
 	pubSec, _ := hex.DecodeString(m["pub_sec"])
 
+	// address selalu diturunkan ulang dari pubkey; file lama masih berisi format legacy
+	addrEd, addrSec := m["address_ed"], m["address_sec"]
+	if len(pubEd) == ed25519.PublicKeySize {
+		addrEd = AddressFromPubKey(pubEd)
+	}
+	if len(pubSec) > 0 {
+		addrSec = AddressFromSecPubKey(pubSec)
+	}
+
 	return &Wallet{
-		AddressEd:  m["address_ed"],
+		AddressEd:  addrEd,
 		PubEd:      ed25519.PublicKey(pubEd),
 		PrivEd:     ed25519.PrivateKey(privEd),
-		AddressSec: m["address_sec"],
+		AddressSec: addrSec,
 		PubSec:     pubSec,
 		PrivSec:    privSec,
 	}, nil
 }
 
+// LegacyAddressEd: address format lama wallet ini (untuk migrasi saldo).
+func (w *Wallet) LegacyAddressEd() string {
+	return LegacyAddressFromPubKey(w.PubEd)
+}
+
 // SignEd menandatangani data pakai Ed25519
 func (w *Wallet) SignEd(data []byte) []byte {
 	return ed25519.Sign(w.PrivEd, data)